@@ -26,3 +26,27 @@ func Auth(validator auth.Validator, next Handler) Handler {
 		return nil
 	}
 }
+
+// RequirePermission ensures that the authenticated user was granted the given
+// permission, either directly or through one of its roles, before letting the
+// next handler handle the request.
+//
+// It must be used after the Auth handler, since it relies on the
+// authenticated user's information being present in the request's context.
+func RequirePermission(permission string, next Handler) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		err = userInfo.RequirePermission(permission)
+		if err != nil {
+			return err
+		}
+
+		next.ServeHTTP(w, r)
+
+		return nil
+	}
+}
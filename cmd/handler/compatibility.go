@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/compatibility"
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// GetCompatibility handles a request to compute the compatibility of a user
+// with another one. Only the user itself and the callers allowed to read any
+// user can compute it.
+func GetCompatibility(service compatibility.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		otherID, err := pathID(r, "otherId")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		result, err := service.Score(id, otherID, compatibilityCaller(userInfo))
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(result)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetCompatibilityRanking handles a request to rank candidates on their
+// compatibility with a user. The candidates are given as a comma-separated
+// list of unique identifiers in the candidates query parameter.
+func GetCompatibilityRanking(service compatibility.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		var candidateIDs []entity.ID
+		if candidates := r.URL.Query().Get("candidates"); candidates != "" {
+			for _, candidate := range strings.Split(candidates, ",") {
+				candidateID := entity.NewIDFromHex(strings.TrimSpace(candidate))
+				if !candidateID.IsValid() {
+					return requestError{malformedID.NewForField("candidates", fmt.Sprintf("candidate \"%s\" is malformed", candidate))}
+				}
+
+				candidateIDs = append(candidateIDs, candidateID)
+			}
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		ranking, err := service.ScoreCandidates(id, candidateIDs, compatibilityCaller(userInfo))
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(ranking)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// compatibilityCaller creates the caller on behalf of whom compatibilities are
+// computed.
+func compatibilityCaller(userInfo *auth.UserInfo) *user.Caller {
+	return &user.Caller{
+		SubID:      userInfo.SubID,
+		Privileged: userInfo.HasPermission(auth.PermissionReadUsers),
+	}
+}
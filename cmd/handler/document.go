@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/verification"
+)
+
+// UploadDocument handles a request from the authenticated user to upload one
+// of its documents as a multipart form with the type, expiresAt, vehiculeId
+// (optional) and file fields.
+func UploadDocument(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		err = parseMultipartForm(w, r, service.MaxFileSize())
+		if err != nil {
+			return err
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		d, err := documentFromForm(r)
+		if err != nil {
+			return err
+		}
+		d.UserID = userID
+
+		var file multipart.File
+		file, _, err = r.FormFile("file")
+		if err == nil {
+			defer file.Close()
+		} else if err != http.ErrMissingFile {
+			return requestError{malformedBody.NewForField("file", fmt.Sprintf("file could not be read (%s)", err))}
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		// A nil file must be passed as a nil interface for the service to
+		// know that it is missing.
+		var content io.Reader
+		if file != nil {
+			content = file
+		}
+
+		uploaded, err := service.Upload(d, content, userInfo.SubID)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+		err = json.NewEncoder(w).Encode(uploaded)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// documentFromForm creates a document from the fields of a multipart form. The
+// expiration date is either a date (ex. 2025-02-12) or a date and time in the
+// RFC 3339 format.
+func documentFromForm(r *http.Request) (*entity.Document, error) {
+	d := &entity.Document{Type: r.FormValue("type")}
+
+	if vehiculeID := r.FormValue("vehiculeId"); vehiculeID != "" {
+		d.VehiculeID = entity.NewIDFromHex(vehiculeID)
+		if !d.VehiculeID.IsValid() {
+			return nil, requestError{malformedID.NewForField("vehiculeId", fmt.Sprintf("vehiculeId \"%s\" is malformed", vehiculeID))}
+		}
+	}
+
+	if expiresAt := r.FormValue("expiresAt"); expiresAt != "" {
+		var err error
+		d.ExpiresAt, err = time.Parse("2006-01-02", expiresAt)
+		if err != nil {
+			d.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
+		}
+		if err != nil {
+			return nil, requestError{malformedParameter.NewForField("expiresAt", fmt.Sprintf("expiresAt \"%s\" is not a date", expiresAt))}
+		}
+	}
+
+	return d, nil
+}
+
+// GetDocumentsByUserID handles a request to retrieve the documents of a user.
+// Only the user itself and the callers allowed to review documents can see
+// them.
+func GetDocumentsByUserID(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		documents, err := service.FindByUserID(userID, documentCaller(userInfo))
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(struct {
+			Documents []*entity.Document `json:"documents"`
+		}{documents})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetDocumentFile handles a request to download the file of one of a user's
+// documents. Only the user itself and the callers allowed to review documents
+// can download it.
+func GetDocumentFile(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := pathID(r, "documentId")
+		if err != nil {
+			return err
+		}
+
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		d, err := service.FindByID(id, userID, documentCaller(userInfo))
+		if err != nil {
+			return err
+		}
+
+		f, err := service.Open(d)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", d.ContentType)
+		w.Header().Set("Content-Length", fmt.Sprint(d.Size))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		_, err = io.Copy(w, f)
+
+		return err
+	}
+}
+
+// documentCaller creates the caller on behalf of whom documents are looked
+// up.
+func documentCaller(userInfo *auth.UserInfo) *user.Caller {
+	return &user.Caller{
+		SubID:      userInfo.SubID,
+		Privileged: userInfo.HasPermission(auth.PermissionReviewDocuments),
+	}
+}
+
+// SearchDocuments handles a request to list the documents of every user with
+// a given status, such as the documents waiting to be reviewed.
+func SearchDocuments(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		q, err := verification.ParseQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		p, err := service.Search(q)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(p)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// ApproveDocument handles a request to approve a pending document.
+func ApproveDocument(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		d, err := service.Approve(id)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(d)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// RejectDocument handles a request to reject a document for the reason in the
+// body.
+func RejectDocument(service verification.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		err = decodeBody(r, &body)
+		if err != nil {
+			return err
+		}
+
+		d, err := service.Reject(id, body.Reason)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(d)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
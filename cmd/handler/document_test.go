@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"azure.com/ecovo/user-service/pkg/verification"
+	"github.com/gorilla/mux"
+)
+
+func TestUploadDocument(t *testing.T) {
+	service := verification.NewService(nil, nil, nil, nil, &verification.Config{MaxFileSize: 1 << 10})
+
+	r := mux.NewRouter()
+	r.Handle("/users/{id}/documents", UploadDocument(service))
+
+	t.Run("Should reject bodies larger than the maximum file size before parsing them", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("type", "insurance")
+		file, err := form.CreateFormFile("file", "insurance.pdf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write(bytes.Repeat([]byte{'0'}, 2*multipartOverhead))
+		form.Close()
+
+		req := httptest.NewRequest("POST", "/users/5c6d9a0b4f0e8a0001a1b2c3/documents", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var problem Error
+		err = json.NewDecoder(w.Body).Decode(&problem)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.Code != http.StatusRequestEntityTooLarge || problem.Code != "bodyTooLarge" {
+			t.Errorf("expected status %d and code %q, got %d and %q", http.StatusRequestEntityTooLarge, "bodyTooLarge", w.Code, problem.Code)
+		}
+	})
+}
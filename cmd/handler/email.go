@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/email"
+)
+
+// RequestEmailChange handles a request from the authenticated user to change
+// its email, which sends a confirmation token to the new email.
+func RequestEmailChange(service email.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		var body struct {
+			Email string `json:"email"`
+		}
+		err := decodeBody(r, &body)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		c, err := service.RequestChange(userInfo.SubID, body.Email)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+
+		err = json.NewEncoder(w).Encode(c)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// ConfirmEmailChange handles a request from the authenticated user to confirm
+// the change of its email with the token it received.
+func ConfirmEmailChange(service email.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Token string `json:"token"`
+		}
+		err := decodeBody(r, &body)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		err = service.ConfirmChange(userInfo.SubID, body.Token)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return nil
+	}
+}
@@ -1,48 +1,136 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
-	"azure.com/ecovo/user-service/cmd/middleware/auth"
 	"azure.com/ecovo/user-service/pkg/entity"
-	"azure.com/ecovo/user-service/pkg/user"
-	"azure.com/ecovo/user-service/pkg/vehicule"
 )
 
-// An Error is an application error that can be handled by a handler.
+// An Error is an application error that can be handled by a handler. It is
+// serialized as a problem details object (RFC 7807).
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Error   error  `json:"-"`
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Status    int           `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Instance  string        `json:"instance,omitempty"`
+	Code      string        `json:"code"`
+	RequestID string        `json:"requestId,omitempty"`
+	Errors    []*FieldError `json:"errors,omitempty"`
+	Error     error         `json:"-"`
+}
+
+// A FieldError describes why a field of a request is not valid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
 }
 
 func (err Error) String() string {
-	return fmt.Sprintf("code=%d, message=\"%s\", error=\"%s\"", err.Code, err.Message, err.Error)
+	return fmt.Sprintf("status=%d, code=\"%s\", detail=\"%s\", error=\"%s\"", err.Status, err.Code, err.Detail, err.Error)
+}
+
+// problemTypeBase is the URI prefix of the problem types, which is followed by
+// the error's code.
+const problemTypeBase = "https://ecovo.ca/problems/"
+
+// statusByKind maps each kind of error to the HTTP status code that is
+// returned for it.
+var statusByKind = map[entity.ErrorKind]int{
+	entity.ErrorKindInvalid:         http.StatusBadRequest,
+	entity.ErrorKindUnauthorized:    http.StatusUnauthorized,
+	entity.ErrorKindForbidden:       http.StatusForbidden,
+	entity.ErrorKindNotFound:        http.StatusNotFound,
+	entity.ErrorKindAlreadyExists:   http.StatusConflict,
+	entity.ErrorKindTooManyRequests: http.StatusTooManyRequests,
+	entity.ErrorKindTooLarge:        http.StatusRequestEntityTooLarge,
 }
 
 // WrapError wraps the given error in an application error that can be handled
-// by a handler.
+// by a handler. Errors that do not describe what went wrong with an
+// entity.Error are internal server errors. Errors whose message is meant for
+// logs are reported with their detail instead. Validation errors are reported
+// with every field that is not valid.
 func WrapError(err error) *Error {
 	if err == nil {
 		return nil
-	} else if _, ok := err.(auth.UnauthorizedError); ok {
-		return &Error{http.StatusUnauthorized, "unauthorized", err}
-	} else if _, ok := err.(entity.ValidationError); ok {
-		return &Error{http.StatusBadRequest, err.Error(), err}
-	} else if _, ok := err.(user.NotFoundError); ok {
-		return &Error{http.StatusNotFound, "user does not exist", err}
-	} else if _, ok := err.(user.AlreadyExistsError); ok {
-		return &Error{http.StatusInternalServerError, "user already exists", err}
-	} else if _, ok := err.(vehicule.NotFoundError); ok {
-		return &Error{http.StatusNotFound, "vehicule does not exist", err}
-	} else if _, ok := err.(vehicule.WrongUserError); ok {
-		return &Error{http.StatusForbidden, "cannot modify vehicule of another user", err}
-	} else {
+	}
+
+	var e entity.Error
+	status, ok := 0, errors.As(err, &e)
+	if ok {
+		status, ok = statusByKind[e.Kind()]
+	}
+	if !ok {
 		return &Error{
-			http.StatusInternalServerError,
-			"Something went wrong while processing your request. Please contact your system administrator.",
-			err,
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+			Detail: "Something went wrong while processing your request. Please contact your system administrator.",
+			Code:   "internal",
+			Error:  err,
+		}
+	}
+
+	detail := e.Error()
+	if d, ok := e.(entity.DetailedError); ok {
+		detail = d.Detail()
+	}
+
+	handlerErr := &Error{
+		Type:   problemTypeBase + e.Code(),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   e.Code(),
+		Error:  err,
+	}
+
+	var validationErrs entity.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		handlerErr.Detail = fmt.Sprintf("%d field(s) are not valid", len(validationErrs))
+		for _, v := range validationErrs {
+			handlerErr.Errors = append(handlerErr.Errors, &FieldError{v.Field(), v.Code(), v.Error()})
 		}
+	} else if e.Field() != "" {
+		handlerErr.Errors = []*FieldError{{e.Field(), e.Code(), detail}}
 	}
+
+	return handlerErr
+}
+
+// A requestError is an error that occurs when a request itself is malformed,
+// before it reaches the service's packages.
+type requestError struct {
+	entity.BaseError
+}
+
+var malformedBody = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "malformedBody",
+}
+
+var malformedID = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "malformedId",
+}
+
+var malformedParameter = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: entity.CodeMalformed,
+}
+
+// A bodyTooLargeError is an error that occurs when the body of a request is
+// larger than the handler accepts, which is detected before it is read
+// entirely.
+type bodyTooLargeError struct {
+	entity.BaseError
+}
+
+var bodyTooLarge = &entity.ErrorDescription{
+	Kind: entity.ErrorKindTooLarge,
+	Code: "bodyTooLarge",
 }
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+	"github.com/gorilla/mux"
+)
+
+func TestWrapError(t *testing.T) {
+	invalidUser := (&entity.User{SubID: "harold|1"}).Validate()
+
+	uService := user.NewService(user.NewMemoryRepository())
+	harold := &entity.User{
+		SubID:       "harold|1",
+		FirstName:   "Harold",
+		LastName:    "The Great",
+		DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+		Gender:      entity.GenderMale,
+	}
+	if _, err := uService.Register(harold); err != nil {
+		t.Fatal(err)
+	}
+	_, userExists := uService.Register(harold)
+	_, userNotFound := uService.FindBySubID("maurice|1")
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		fields string
+	}{
+		{"Should report every validation error with its field", invalidUser, http.StatusBadRequest, entity.CodeValidationFailed, "firstName lastName dateOfBirth gender signUpPhase"},
+		{"Should report conflicts", userExists, http.StatusConflict, "userAlreadyExists", ""},
+		{"Should match wrapped errors", fmt.Errorf("while registering: %w", userNotFound), http.StatusNotFound, "userNotFound", ""},
+		{"Should report malformed IDs", requestError{malformedID.NewForField("id", "id is malformed")}, http.StatusBadRequest, "malformedId", "id"},
+		{"Should hide unknown errors", errors.New("database flipped us off"), http.StatusInternalServerError, "internal", ""},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			e := WrapError(test.err)
+
+			if e.Status != test.status || e.Code != test.code {
+				t.Errorf("expected status %d and code %q, got %d and %q", test.status, test.code, e.Status, e.Code)
+			}
+
+			var fields []string
+			for _, fieldErr := range e.Errors {
+				fields = append(fields, fieldErr.Field)
+			}
+
+			if strings.Join(fields, " ") != test.fields {
+				t.Errorf("expected errors for fields %q, got %q", test.fields, fields)
+			}
+		})
+	}
+}
+
+func TestWrapErrorDetail(t *testing.T) {
+	uService := user.NewService(user.NewMemoryRepository())
+	_, err := uService.FindBySubID("maurice|1")
+
+	e := WrapError(err)
+	if e.Detail != "user does not exist" {
+		t.Errorf("expected the detail of the error's type, got %q", e.Detail)
+	}
+
+	e = WrapError(requestError{malformedID.NewForField("id", "id is malformed")})
+	if e.Detail != "id is malformed" {
+		t.Errorf("expected the message of the error, got %q", e.Detail)
+	}
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/users/{id}", Handler(func(w http.ResponseWriter, r *http.Request) error {
+		_, err := pathID(r, "id")
+		return err
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/harold", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("expected problem details, got %q", contentType)
+	}
+
+	var problem Error
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if problem.Type != problemTypeBase+"malformedId" || problem.Title != "Bad Request" || problem.Instance != "/users/harold" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/export"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// ExportUser handles a request to export the data of a user as a zip archive.
+// The archive is written right away, unless the async query parameter is true,
+// in which case a job that generates it in the background is started. Only the
+// user itself and the callers allowed to read users can export its data.
+func ExportUser(service export.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		async := false
+		if value := r.URL.Query().Get("async"); value != "" {
+			async, err = strconv.ParseBool(value)
+			if err != nil {
+				return requestError{malformedParameter.NewForField("async", fmt.Sprintf("async \"%s\" is not a boolean", value))}
+			}
+		}
+
+		caller, err := exportCaller(r)
+		if err != nil {
+			return err
+		}
+
+		if async {
+			j, err := service.Start(userID, caller)
+			if err != nil {
+				return err
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", fmt.Sprintf("/users/%s/exports/%s", userID, j.ID))
+			w.WriteHeader(http.StatusAccepted)
+
+			return json.NewEncoder(w).Encode(j)
+		}
+
+		a, err := service.Export(userID, caller)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName()}))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+
+		return a.Write(w)
+	}
+}
+
+// GetExportJob handles a request to retrieve an export job of a user, along
+// with the download link of its archive once it is ready.
+func GetExportJob(service export.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		id, err := pathID(r, "jobId")
+		if err != nil {
+			return err
+		}
+
+		caller, err := exportCaller(r)
+		if err != nil {
+			return err
+		}
+
+		j, err := service.FindJob(id, userID, caller)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		return json.NewEncoder(w).Encode(j)
+	}
+}
+
+// DownloadExport handles a request to download the archive of an export job
+// through its signed link. The link's token authorizes the download, so that
+// it can be opened in a browser without an access token.
+func DownloadExport(service export.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		j, f, err := service.Open(id, r.URL.Query().Get("token"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Length", fmt.Sprint(j.Size))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": j.FileName()}))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+
+		_, err = io.Copy(w, f)
+
+		return err
+	}
+}
+
+// exportCaller creates the caller on behalf of whom the data of users is
+// exported.
+func exportCaller(r *http.Request) (*user.Caller, error) {
+	userInfo, err := auth.FromContext(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.Caller{
+		SubID:      userInfo.SubID,
+		Privileged: userInfo.HasPermission(auth.PermissionReadUsers),
+	}, nil
+}
@@ -19,16 +19,12 @@ func (handler Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("[Request ID=%s] error: %s", requestID, handlerErr)
 
-		type errorResponse struct {
-			*Error
-			RequestID string `json:"requestId"`
-		}
+		handlerErr.Instance = r.URL.Path
+		handlerErr.RequestID = requestID
 
-		w.WriteHeader(handlerErr.Code)
-		err := json.NewEncoder(w).Encode(errorResponse{
-			handlerErr,
-			requestID,
-		})
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(handlerErr.Status)
+		err := json.NewEncoder(w).Encode(handlerErr)
 		if err != nil {
 			http.Error(
 				w,
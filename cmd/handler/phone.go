@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/phone"
+)
+
+// StartPhoneVerification handles a request from the authenticated user to
+// receive a code by text message to verify its phone number.
+func StartPhoneVerification(service phone.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		v, err := service.Start(userInfo.SubID)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+
+		err = json.NewEncoder(w).Encode(v)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// ConfirmPhoneVerification handles a request from the authenticated user to
+// verify its phone number with the code it received.
+func ConfirmPhoneVerification(service phone.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Code string `json:"code"`
+		}
+		err := decodeBody(r, &body)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		err = service.Confirm(userInfo.SubID, body.Code)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return nil
+	}
+}
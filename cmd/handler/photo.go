@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/photo"
+	"azure.com/ecovo/user-service/pkg/user"
+	"github.com/gorilla/mux"
+)
+
+// UploadUserPhoto handles a request to replace the photo of a user. The photo
+// is either the photo field of a multipart form or the body itself. Only the
+// user itself and the callers allowed to update users can replace it.
+func UploadUserPhoto(service photo.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		content, cleanup, err := photoFromRequest(w, r, service.MaxFileSize())
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		caller := user.Caller{
+			SubID:      userInfo.SubID,
+			Privileged: userInfo.HasPermission(auth.PermissionUpdateUsers),
+		}
+		p, err := service.UploadUserPhoto(userID, content, &caller)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(p)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// UploadVehiculePhoto handles a request from the authenticated user to replace
+// the photo of one of its vehicules. The photo is either the photo field of a
+// multipart form or the body itself.
+func UploadVehiculePhoto(service photo.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			return err
+		}
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		content, cleanup, err := photoFromRequest(w, r, service.MaxFileSize())
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		p, err := service.UploadVehiculePhoto(id, userID, content, userInfo.SubID)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(p)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// photoFromRequest returns the uploaded photo, which is the photo field of a
+// multipart form or the body of any other request, along with a function that
+// releases the resources held by the form. A missing photo is returned as a nil
+// reader for the service to report it. A multipart form cannot be much larger
+// than the maximum file size, while the service stops reading any other body
+// once it is too large.
+func photoFromRequest(w http.ResponseWriter, r *http.Request, maxFileSize int64) (io.Reader, func(), error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, func() {}, nil
+	}
+
+	err := parseMultipartForm(w, r, maxFileSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, _, err := r.FormFile("photo")
+	if err == http.ErrMissingFile {
+		return nil, func() { r.MultipartForm.RemoveAll() }, nil
+	} else if err != nil {
+		r.MultipartForm.RemoveAll()
+		return nil, nil, requestError{malformedBody.NewForField("photo", fmt.Sprintf("photo could not be read (%s)", err))}
+	}
+
+	return file, func() {
+		file.Close()
+		r.MultipartForm.RemoveAll()
+	}, nil
+}
+
+// GetPhoto handles a request to download a stored photo. Photos are public and
+// their keys change whenever they are replaced, so they can be cached forever.
+func GetPhoto(store media.Store) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		f, err := store.Get(mux.Vars(r)["key"])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		content := bufio.NewReader(f)
+		head, _ := content.Peek(512)
+
+		w.Header().Set("Content-Type", http.DetectContentType(head))
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		_, err = io.Copy(w, content)
+
+		return err
+	}
+}
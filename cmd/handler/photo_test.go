@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"azure.com/ecovo/user-service/pkg/photo"
+	"github.com/gorilla/mux"
+)
+
+func TestUploadUserPhoto(t *testing.T) {
+	service, err := photo.NewService(nil, nil, nil, &photo.Config{BaseURL: "http://localhost/media", MaxFileSize: 1 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	r.Handle("/users/{id}/photo", UploadUserPhoto(service))
+
+	t.Run("Should reject forms larger than the maximum file size before parsing them", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, err := form.CreateFormFile("photo", "harold.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write(bytes.Repeat([]byte{'0'}, 2*multipartOverhead))
+		form.Close()
+
+		req := httptest.NewRequest("PUT", "/users/5c6d9a0b4f0e8a0001a1b2c3/photo", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// GetPreferenceCatalog handles a request to retrieve the preferences that
+// users can express, along with the values each of them can take.
+func GetPreferenceCatalog() Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(w).Encode(struct {
+			Preferences []entity.PreferenceDefinition `json:"preferences"`
+		}{entity.PreferenceCatalog})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
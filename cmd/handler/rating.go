@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/rating"
+)
+
+// SubmitReview handles a request from the authenticated user to review the
+// user in the path.
+func SubmitReview(service rating.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		subjectID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		var review entity.Review
+		err = decodeBody(r, &review)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		review.SubjectID = subjectID
+
+		submitted, err := service.Submit(&review, userInfo.SubID)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+		err = json.NewEncoder(w).Encode(submitted)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetReviewsBySubjectID handles a request to retrieve a page of the reviews a
+// user received.
+func GetReviewsBySubjectID(service rating.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		subjectID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		q, err := rating.ParseQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		p, err := service.FindBySubjectID(subjectID, q)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(p)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetRatings handles a request to retrieve the summaries of the reviews a user
+// received as a rider and as a driver.
+func GetRatings(service rating.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		subjectID, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		ratings, err := service.Summarize(subjectID)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(ratings)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
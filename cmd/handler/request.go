@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/gorilla/mux"
+)
+
+// multipartMemory represents the number of bytes of a multipart form kept in
+// memory, the rest being stored in temporary files.
+const multipartMemory = 1 << 20
+
+// multipartOverhead represents the number of bytes that a multipart form can
+// contain on top of its file, for its boundaries, headers and other fields.
+const multipartOverhead = 64 << 10
+
+// decodeBody decodes the JSON body of a request into the given value.
+func decodeBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return requestError{malformedBody.New(fmt.Sprintf("body is not valid JSON (%s)", err))}
+	}
+
+	return nil
+}
+
+// pathID returns the unique identifier in the path parameter with the given
+// name, making sure that it is well formed.
+func pathID(r *http.Request, name string) (entity.ID, error) {
+	id := entity.NewIDFromHex(mux.Vars(r)[name])
+	if !id.IsValid() {
+		return entity.NilID, requestError{malformedID.NewForField(name, fmt.Sprintf("%s \"%s\" is malformed", name, id))}
+	}
+
+	return id, nil
+}
+
+// parseMultipartForm parses the multipart form of a request whose file is at
+// most maxFileSize bytes. The body is limited before being parsed, so that a
+// larger body is rejected instead of being written to temporary files.
+func parseMultipartForm(w http.ResponseWriter, r *http.Request, maxFileSize int64) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize+multipartOverhead)
+
+	err := r.ParseMultipartForm(multipartMemory)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLargeError{bodyTooLarge.New(fmt.Sprintf("body is larger than %d bytes", maxBytesErr.Limit))}
+	} else if err != nil {
+		return requestError{malformedBody.New(fmt.Sprintf("body is not a valid multipart form (%s)", err))}
+	}
+
+	return nil
+}
@@ -5,18 +5,25 @@ import (
 	"net/http"
 
 	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/cmd/view"
+	"azure.com/ecovo/user-service/pkg/account"
 	"azure.com/ecovo/user-service/pkg/entity"
 	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
 	"github.com/gorilla/mux"
 )
 
-// CreateUser handles a request to create a user.
-func CreateUser(service user.UseCase) Handler {
+// CreateUser handles a request to create a user. Users whose account was
+// recently deleted cannot be created again until the grace period is over.
+//
+// The user is kept even if the response cannot be written, since deleting it
+// would prevent it from being created again during the grace period.
+func CreateUser(aService account.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
 
-		var u *entity.User
-		err := json.NewDecoder(r.Body).Decode(&u)
+		var u entity.User
+		err := decodeBody(r, &u)
 		if err != nil {
 			return err
 		}
@@ -28,18 +35,17 @@ func CreateUser(service user.UseCase) Handler {
 
 		u.SubID = userInfo.SubID
 		u.Email = userInfo.Email
+		u.EmailVerified = userInfo.EmailVerified
 
-		u, err = service.Register(u)
+		registered, err := aService.Register(&u)
 		if err != nil {
 			return err
 		}
 
 		w.WriteHeader(http.StatusCreated)
 
-		err = json.NewEncoder(w).Encode(u)
+		err = json.NewEncoder(w).Encode(registered)
 		if err != nil {
-			_ = service.Delete(entity.ID(u.ID))
-
 			return err
 		}
 
@@ -52,17 +58,58 @@ func UpdateUser(service user.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
 
-		vars := mux.Vars(r)
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		var u entity.User
+		err = decodeBody(r, &u)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		u.ID = id
+
+		caller := user.Caller{
+			SubID:      userInfo.SubID,
+			Privileged: userInfo.HasPermission(auth.PermissionUpdateUsers),
+		}
+		err = service.Update(&u, &caller)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return nil
+	}
+}
 
-		var u *entity.User
-		err := json.NewDecoder(r.Body).Decode(&u)
+// DeleteUser handles a request to delete a user along with its vehicules. Only
+// the user itself and the callers allowed to delete users can delete it.
+func DeleteUser(service account.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		id, err := pathID(r, "id")
 		if err != nil {
 			return err
 		}
 
-		u.ID = entity.NewIDFromHex(vars["id"])
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
 
-		err = service.Update(u)
+		caller := user.Caller{
+			SubID:      userInfo.SubID,
+			Privileged: userInfo.HasPermission(auth.PermissionDeleteUsers),
+		}
+		err = service.Delete(id, &caller)
 		if err != nil {
 			return err
 		}
@@ -73,15 +120,129 @@ func UpdateUser(service user.UseCase) Handler {
 	}
 }
 
+// AdvanceSignUp handles a request to move a user on to the next sign up phase.
+func AdvanceSignUp(service user.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		caller := user.Caller{
+			SubID:      userInfo.SubID,
+			Privileged: userInfo.HasPermission(auth.PermissionUpdateUsers),
+		}
+		u, err := service.AdvanceSignUp(id, &caller)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(u)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// DeactivateUser handles a request to pause the account of a user.
+func DeactivateUser(service user.UseCase) Handler {
+	return changeUserStatus(service.Deactivate)
+}
+
+// ReactivateUser handles a request to reactivate the deactivated or suspended
+// account of a user.
+func ReactivateUser(service user.UseCase) Handler {
+	return changeUserStatus(service.Reactivate)
+}
+
+// changeUserStatus handles a request to change the status of the account of a
+// user with the given operation. Only the user itself and the callers allowed
+// to update users can change it.
+func changeUserStatus(change func(ID entity.ID, caller *user.Caller) (*entity.User, error)) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		caller := user.Caller{
+			SubID:      userInfo.SubID,
+			Privileged: userInfo.HasPermission(auth.PermissionUpdateUsers),
+		}
+		u, err := change(id, &caller)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(u)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
 // GetUserByID handles a request to retrieve a user by its unique identifier.
-func GetUserByID(service user.UseCase) Handler {
+// Only the user itself and privileged callers get its private profile, anyone
+// else gets its public profile.
+func GetUserByID(uService user.UseCase, vService vehicule.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		u, err := uService.FindByID(id)
+		if err != nil {
+			return err
+		}
+
+		p, err := vService.FindByUserID(u.ID, &vehicule.Query{Limit: vehicule.MaxLimit})
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(view.NewUser(u, p.Vehicules, userInfo))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetUserBySubID handles a request to retrieve a user by its subscription ID.
+func GetUserBySubID(service user.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
 
 		vars := mux.Vars(r)
 
-		id := entity.NewIDFromHex(vars["id"])
-		u, err := service.FindByID(id)
+		u, err := service.FindBySubID(vars["subId"])
 		if err != nil {
 			return err
 		}
@@ -91,13 +252,53 @@ func GetUserByID(service user.UseCase) Handler {
 			return err
 		}
 
-		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+}
+
+// SearchUsers handles a request to search the user directory. Only callers
+// allowed to read any user can search it, and only the users' public profiles
+// are returned.
+func SearchUsers(service user.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		err = userInfo.RequirePermission(auth.PermissionReadUsers)
+		if err != nil {
+			return err
+		}
+
+		q, err := user.ParseQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
+
+		p, err := service.Search(q)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewEncoder(w).Encode(&view.UserPage{
+			Users: view.NewPublicUsers(p.Users),
+			Next:  p.Next,
+		})
+		if err != nil {
+			return err
+		}
 
 		return nil
 	}
 }
 
-// GetUserFromAuth handles a request to retrieve the authenticated user.
+// GetUserFromAuth handles a request to retrieve the authenticated user. The
+// user's email is synced with the one known by the identity provider first.
+// Deactivated and suspended users are returned too, with their status, so that
+// they can be offered to reactivate their account.
 func GetUserFromAuth(service user.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
@@ -107,7 +308,7 @@ func GetUserFromAuth(service user.UseCase) Handler {
 			return err
 		}
 
-		u, err := service.FindBySubID(userInfo.SubID)
+		u, err := service.FindBySubID(userInfo.SubID, user.AccountStatuses...)
 		if err != nil {
 			type tmpUser struct {
 				Email       string `json:"email"`
@@ -128,6 +329,11 @@ func GetUserFromAuth(service user.UseCase) Handler {
 				return err
 			}
 		} else {
+			u, err = service.SyncIdentityEmail(u.ID, userInfo.Email, userInfo.EmailVerified)
+			if err != nil {
+				return err
+			}
+
 			err = json.NewEncoder(w).Encode(u)
 			if err != nil {
 				return err
@@ -8,7 +8,6 @@ import (
 	"azure.com/ecovo/user-service/pkg/entity"
 	"azure.com/ecovo/user-service/pkg/user"
 	"azure.com/ecovo/user-service/pkg/vehicule"
-	"github.com/gorilla/mux"
 )
 
 // CreateVehicule handles a request to create a vehicule. We first verify the User related to the
@@ -16,10 +15,14 @@ import (
 func CreateVehicule(uService user.UseCase, vService vehicule.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
-		vars := mux.Vars(r)
 
-		var v *entity.Vehicule
-		err := json.NewDecoder(r.Body).Decode(&v)
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			return err
+		}
+
+		var v entity.Vehicule
+		err = decodeBody(r, &v)
 		if err != nil {
 			return err
 		}
@@ -29,19 +32,18 @@ func CreateVehicule(uService user.UseCase, vService vehicule.UseCase) Handler {
 			return err
 		}
 
-		userID := entity.NewIDFromHex(vars["userId"])
 		v.UserID = userID
 
-		v, err = vService.Register(v, userInfo.SubID)
+		created, err := vService.Register(&v, userInfo.SubID)
 		if err != nil {
 			return err
 		}
 
 		w.WriteHeader(http.StatusCreated)
 
-		err = json.NewEncoder(w).Encode(v)
+		err = json.NewEncoder(w).Encode(created)
 		if err != nil {
-			_ = vService.Delete(entity.ID(v.ID), userID, userInfo.SubID)
+			_ = vService.Delete(created.ID, userID, userInfo.SubID)
 
 			return err
 		}
@@ -50,18 +52,63 @@ func CreateVehicule(uService user.UseCase, vService vehicule.UseCase) Handler {
 	}
 }
 
+// UpdateVehicule handles a request to update a vehicule.
+func UpdateVehicule(uService user.UseCase, vService vehicule.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			return err
+		}
+
+		var v entity.Vehicule
+		err = decodeBody(r, &v)
+		if err != nil {
+			return err
+		}
+
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
+
+		v.ID = id
+		v.UserID = userID
+
+		err = vService.Update(&v, userInfo.SubID)
+		if err != nil {
+			return err
+		}
+
+		w.WriteHeader(http.StatusOK)
+
+		return nil
+	}
+}
+
 // DeleteVehicule handles a request to delete a vehicule.
 func DeleteVehicule(uService user.UseCase, vService vehicule.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		vars := mux.Vars(r)
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
 
-		userInfo, err := auth.FromContext(r.Context())
+		userID, err := pathID(r, "userId")
 		if err != nil {
 			return err
 		}
 
-		id := entity.NewIDFromHex(vars["id"])
-		userID := entity.NewIDFromHex(vars["userId"])
+		userInfo, err := auth.FromContext(r.Context())
+		if err != nil {
+			return err
+		}
 
 		err = vService.Delete(id, userID, userInfo.SubID)
 		if err != nil {
@@ -79,10 +126,17 @@ func GetVehiculeByID(uService user.UseCase, vService vehicule.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
 
-		vars := mux.Vars(r)
+		id, err := pathID(r, "id")
+		if err != nil {
+			return err
+		}
+
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			return err
+		}
 
-		id := entity.NewIDFromHex(vars["id"])
-		v, err := vService.FindByID(id)
+		v, err := vService.FindByID(id, userID)
 		if err != nil {
 			return err
 		}
@@ -92,26 +146,49 @@ func GetVehiculeByID(uService user.UseCase, vService vehicule.UseCase) Handler {
 			return err
 		}
 
-		w.WriteHeader(http.StatusOK)
-
 		return nil
 	}
 }
 
-// GetVehiculesByUserID handles a request to retrieve the authenticated user's vehicule.
+// GetVehiculesByUserID handles a request to retrieve a page of a user's
+// vehicules.
 func GetVehiculesByUserID(uService user.UseCase, vService vehicule.UseCase) Handler {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		w.Header().Set("Content-Type", "application/json")
 
-		vars := mux.Vars(r)
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			return err
+		}
+
+		q, err := vehicule.ParseQuery(r.URL.Query())
+		if err != nil {
+			return err
+		}
 
-		userID := entity.NewIDFromHex(vars["userId"])
-		v, err := vService.FindByUserID(userID)
+		p, err := vService.FindByUserID(userID, q)
 		if err != nil {
 			return err
 		}
 
-		err = json.NewEncoder(w).Encode(v)
+		err = json.NewEncoder(w).Encode(p)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// GetAccessories handles a request to retrieve the accessories that vehicules
+// can have.
+func GetAccessories(vService vehicule.UseCase) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(w).Encode(struct {
+			Accessories []entity.Accessory `json:"accessories"`
+		}{vService.Accessories()})
 		if err != nil {
 			return err
 		}
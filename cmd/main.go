@@ -1,16 +1,28 @@
 package main
 
 import (
+	"crypto/rand"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"azure.com/ecovo/user-service/cmd/handler"
 	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/account"
+	"azure.com/ecovo/user-service/pkg/compatibility"
 	"azure.com/ecovo/user-service/pkg/db"
+	"azure.com/ecovo/user-service/pkg/email"
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/export"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/phone"
+	"azure.com/ecovo/user-service/pkg/photo"
+	"azure.com/ecovo/user-service/pkg/rating"
 	"azure.com/ecovo/user-service/pkg/user"
 	"azure.com/ecovo/user-service/pkg/vehicule"
+	"azure.com/ecovo/user-service/pkg/verification"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
@@ -21,67 +33,355 @@ func main() {
 		port = "8080"
 	}
 
-	authConfig := auth.Config{
-		Domain: os.Getenv("AUTH_DOMAIN")}
-	authValidator, err := auth.NewTokenValidator(&authConfig)
+	var authValidator auth.Validator
+	var err error
+	if os.Getenv("AUTH_JWKS_URL") != "" || os.Getenv("AUTH_JWKS_FILE") != "" {
+		authIssuer := os.Getenv("AUTH_ISSUER")
+		if authIssuer == "" {
+			authIssuer = "https://" + os.Getenv("AUTH_DOMAIN") + "/"
+		}
+		authConfig := auth.JWKSConfig{
+			URL:        os.Getenv("AUTH_JWKS_URL"),
+			File:       os.Getenv("AUTH_JWKS_FILE"),
+			Issuer:     authIssuer,
+			Audience:   os.Getenv("AUTH_AUDIENCE"),
+			RolesClaim: os.Getenv("AUTH_ROLES_CLAIM")}
+		authValidator, err = auth.NewJWKSValidator(&authConfig)
+	} else {
+		authConfig := auth.Config{
+			Domain:     os.Getenv("AUTH_DOMAIN"),
+			RolesClaim: os.Getenv("AUTH_ROLES_CLAIM")}
+		authValidator, err = auth.NewTokenValidator(&authConfig)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	dbConnectionTimeout, err := time.ParseDuration(os.Getenv("DB_CONNECTION_TIMEOUT") + "s")
+	if os.Getenv("AUTH_CACHE_TTL") != "0" {
+		authCacheTTL, err := time.ParseDuration(os.Getenv("AUTH_CACHE_TTL") + "s")
+		if err != nil {
+			authCacheTTL = auth.DefaultCacheTTL
+		}
+		authCacheConfig := auth.CacheConfig{
+			TTL: authCacheTTL}
+		authValidator, err = auth.NewCachingValidator(authValidator, &authCacheConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var userRepository user.Repository
+	var vehiculeRepository vehicule.Repository
+	var ratingRepository rating.Repository
+	var phoneRepository phone.Repository
+	var emailRepository email.Repository
+	var documentRepository verification.Repository
+	var tombstoneRepository account.Repository
+	var exportRepository export.Repository
+	switch os.Getenv("STORAGE") {
+	case "memory":
+		log.Println("using in-memory storage, data will be lost when the service stops")
+
+		userRepository = user.NewMemoryRepository()
+		vehiculeRepository = vehicule.NewMemoryRepository()
+		ratingRepository = rating.NewMemoryRepository()
+		phoneRepository = phone.NewMemoryRepository()
+		emailRepository = email.NewMemoryRepository()
+		documentRepository = verification.NewMemoryRepository()
+		tombstoneRepository = account.NewMemoryRepository()
+		exportRepository = export.NewMemoryRepository()
+	default:
+		dbConnectionTimeout, err := time.ParseDuration(os.Getenv("DB_CONNECTION_TIMEOUT") + "s")
+		if err != nil {
+			dbConnectionTimeout = db.DefaultConnectionTimeout
+		}
+		dbConfig := db.Config{
+			Host:              os.Getenv("DB_HOST"),
+			Username:          os.Getenv("DB_USERNAME"),
+			Password:          os.Getenv("DB_PASSWORD"),
+			Name:              os.Getenv("DB_NAME"),
+			ConnectionTimeout: dbConnectionTimeout}
+		db, err := db.New(&dbConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		userRepository, err = user.NewMongoRepository(db.Users)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		vehiculeRepository, err = vehicule.NewMongoRepository(db.Vehicules)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ratingRepository, err = rating.NewMongoRepository(db.Reviews)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		phoneRepository, err = phone.NewMongoRepository(db.PhoneVerifications)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		emailRepository, err = email.NewMongoRepository(db.EmailChanges)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		documentRepository, err = verification.NewMongoRepository(db.Documents)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tombstoneRepository, err = account.NewMongoRepository(db.Tombstones)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		exportRepository, err = export.NewMongoRepository(db.Exports)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	userUseCase := user.NewService(userRepository)
+	vehiculePolicy := entity.DefaultVehiculePolicy
+	if seatsMinimum, err := strconv.Atoi(os.Getenv("VEHICULE_SEATS_MINIMUM")); err == nil {
+		vehiculePolicy.SeatsMinimum = seatsMinimum
+	}
+	if seatsMaximum, err := strconv.Atoi(os.Getenv("VEHICULE_SEATS_MAXIMUM")); err == nil {
+		vehiculePolicy.SeatsMaximum = seatsMaximum
+	}
+	vehiculeUseCase := vehicule.NewService(vehiculeRepository, userUseCase, &vehiculePolicy)
+	ratingUseCase := rating.NewService(ratingRepository, userUseCase)
+
+	smsLog := os.Stdout
+	if os.Getenv("SMS_LOG_FILE") != "" {
+		smsLog, err = os.OpenFile(os.Getenv("SMS_LOG_FILE"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer smsLog.Close()
+	}
+	phoneUseCase := phone.NewService(phoneRepository, userUseCase, phone.NewLogSender(smsLog), nil)
+
+	mailLog := os.Stdout
+	if os.Getenv("MAIL_LOG_FILE") != "" {
+		mailLog, err = os.OpenFile(os.Getenv("MAIL_LOG_FILE"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer mailLog.Close()
+	}
+	emailTokenSecret := []byte(os.Getenv("EMAIL_TOKEN_SECRET"))
+	if len(emailTokenSecret) == 0 {
+		log.Println("EMAIL_TOKEN_SECRET is not set, email confirmation tokens will not survive a restart")
+
+		emailTokenSecret = make([]byte, 32)
+		_, err = rand.Read(emailTokenSecret)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	emailConfig := email.Config{
+		Secret:          emailTokenSecret,
+		ConfirmationURL: os.Getenv("EMAIL_CONFIRMATION_URL")}
+	emailUseCase, err := email.NewService(emailRepository, userUseCase, email.NewLogMailer(mailLog), &emailConfig)
 	if err != nil {
-		dbConnectionTimeout = db.DefaultConnectionTimeout
-	}
-	dbConfig := db.Config{
-		Host:              os.Getenv("DB_HOST"),
-		Username:          os.Getenv("DB_USERNAME"),
-		Password:          os.Getenv("DB_PASSWORD"),
-		Name:              os.Getenv("DB_NAME"),
-		ConnectionTimeout: dbConnectionTimeout}
-	db, err := db.New(&dbConfig)
+		log.Fatal(err)
+	}
+
+	var mediaStore media.Store
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		s3Config := media.S3Config{
+			Endpoint:           os.Getenv("S3_ENDPOINT"),
+			Region:             os.Getenv("S3_REGION"),
+			Bucket:             os.Getenv("S3_BUCKET"),
+			AccessKeyID:        os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey:    os.Getenv("S3_SECRET_ACCESS_KEY"),
+			VirtualHostedStyle: os.Getenv("S3_VIRTUAL_HOSTED_STYLE") == "true"}
+		mediaStore, err = media.NewS3Store(&s3Config)
+	} else {
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = "media"
+		}
+		mediaStore, err = media.NewFileStore(mediaDir)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+	var verificationConfig verification.Config
+	if maxFileSize, err := strconv.ParseInt(os.Getenv("DOCUMENT_MAX_FILE_SIZE"), 10, 64); err == nil {
+		verificationConfig.MaxFileSize = maxFileSize
+	}
+	verificationUseCase := verification.NewService(documentRepository, userUseCase, vehiculeUseCase, mediaStore, &verificationConfig)
 
-	userRepository, err := user.NewMongoRepository(db.Users)
+	photoConfig := photo.Config{BaseURL: os.Getenv("MEDIA_BASE_URL")}
+	if photoConfig.BaseURL == "" {
+		photoConfig.BaseURL = "http://localhost:" + port + "/media"
+	}
+	if maxFileSize, err := strconv.ParseInt(os.Getenv("PHOTO_MAX_FILE_SIZE"), 10, 64); err == nil {
+		photoConfig.MaxFileSize = maxFileSize
+	}
+	photoUseCase, err := photo.NewService(mediaStore, userUseCase, vehiculeUseCase, &photoConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
-	userUseCase := user.NewService(userRepository)
 
-	vehiculeRepository, err := vehicule.NewMongoRepository(db.Vehicules)
+	compatibilityUseCase := compatibility.NewService(userUseCase)
+
+	var accountConfig account.Config
+	if gracePeriod, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")); err == nil {
+		accountConfig.GracePeriod = time.Duration(gracePeriod) * 24 * time.Hour
+	}
+	accountUseCase := account.NewService(tombstoneRepository, userUseCase, vehiculeRepository, &accountConfig)
+
+	exportLinkSecret := []byte(os.Getenv("EXPORT_LINK_SECRET"))
+	if len(exportLinkSecret) == 0 {
+		log.Println("EXPORT_LINK_SECRET is not set, export download links will not survive a restart")
+
+		exportLinkSecret = make([]byte, 32)
+		_, err = rand.Read(exportLinkSecret)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	exportConfig := export.Config{
+		Secret:       exportLinkSecret,
+		BaseURL:      os.Getenv("EXPORT_BASE_URL"),
+		MediaBaseURL: photoConfig.BaseURL}
+	if exportConfig.BaseURL == "" {
+		exportConfig.BaseURL = "http://localhost:" + port
+	}
+	if linkTTL, err := strconv.Atoi(os.Getenv("EXPORT_LINK_TTL")); err == nil {
+		exportConfig.LinkTTL = time.Duration(linkTTL) * time.Minute
+	}
+	if retention, err := strconv.Atoi(os.Getenv("EXPORT_RETENTION")); err == nil {
+		exportConfig.Retention = time.Duration(retention) * time.Hour
+	}
+	exportUseCase, err := export.NewService(exportRepository, userUseCase, vehiculeRepository, ratingRepository, documentRepository, mediaStore, &exportConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
-	vehiculeUseCase := vehicule.NewService(vehiculeRepository, userUseCase)
+	go func() {
+		for range time.Tick(export.SweepInterval) {
+			err := exportUseCase.Sweep()
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}()
 
 	r := mux.NewRouter()
 
 	// Users
 	r.Handle("/users/me", handler.RequestID(handler.Auth(authValidator, handler.GetUserFromAuth(userUseCase)))).
 		Methods("GET")
-	r.Handle("/users/{id}", handler.RequestID(handler.Auth(authValidator, handler.GetUserByID(userUseCase)))).
+	r.Handle("/users/me/phone/verification", handler.RequestID(handler.Auth(authValidator, handler.StartPhoneVerification(phoneUseCase)))).
+		Methods("POST")
+	r.Handle("/users/me/phone/verification/confirm", handler.RequestID(handler.Auth(authValidator, handler.ConfirmPhoneVerification(phoneUseCase)))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+	r.Handle("/users/me/email", handler.RequestID(handler.Auth(authValidator, handler.RequestEmailChange(emailUseCase)))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+	r.Handle("/users/me/email/confirm", handler.RequestID(handler.Auth(authValidator, handler.ConfirmEmailChange(emailUseCase)))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+	r.Handle("/users/subs/{subId}", handler.RequestID(handler.Auth(authValidator, handler.RequirePermission(auth.PermissionReadUsers, handler.GetUserBySubID(userUseCase))))).
+		Methods("GET")
+	r.Handle("/users/{id}", handler.RequestID(handler.Auth(authValidator, handler.GetUserByID(userUseCase, vehiculeUseCase)))).
 		Methods("GET").
 		Headers("Content-Type", "application/json")
 	r.Handle("/users/{id}", handler.RequestID(handler.Auth(authValidator, handler.UpdateUser(userUseCase)))).
 		Methods("PATCH").
 		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
-	r.Handle("/users", handler.RequestID(handler.Auth(authValidator, handler.CreateUser(userUseCase)))).
+	r.Handle("/users/{id}", handler.RequestID(handler.Auth(authValidator, handler.DeleteUser(accountUseCase)))).
+		Methods("DELETE")
+	r.Handle("/users/{id}/deactivate", handler.RequestID(handler.Auth(authValidator, handler.DeactivateUser(userUseCase)))).
+		Methods("POST")
+	r.Handle("/users/{id}/reactivate", handler.RequestID(handler.Auth(authValidator, handler.ReactivateUser(userUseCase)))).
+		Methods("POST")
+	r.Handle("/users/{id}/export", handler.RequestID(handler.Auth(authValidator, handler.ExportUser(exportUseCase)))).
+		Methods("GET")
+	r.Handle("/users/{id}/exports/{jobId}", handler.RequestID(handler.Auth(authValidator, handler.GetExportJob(exportUseCase)))).
+		Methods("GET")
+	r.Handle("/users/{id}/signup/advance", handler.RequestID(handler.Auth(authValidator, handler.AdvanceSignUp(userUseCase)))).
+		Methods("POST")
+	r.Handle("/users/{id}/photo", handler.RequestID(handler.Auth(authValidator, handler.UploadUserPhoto(photoUseCase)))).
+		Methods("PUT")
+	r.Handle("/users", handler.RequestID(handler.Auth(authValidator, handler.SearchUsers(userUseCase)))).
+		Methods("GET")
+	r.Handle("/users", handler.RequestID(handler.Auth(authValidator, handler.CreateUser(accountUseCase)))).
 		Methods("POST").
 		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
 
+	// Preferences
+	r.Handle("/preferences/catalog", handler.RequestID(handler.Auth(authValidator, handler.GetPreferenceCatalog()))).
+		Methods("GET")
+
+	// Compatibility
+	r.Handle("/users/{id}/compatibility/{otherId}", handler.RequestID(handler.Auth(authValidator, handler.GetCompatibility(compatibilityUseCase)))).
+		Methods("GET")
+	r.Handle("/users/{id}/compatibility", handler.RequestID(handler.Auth(authValidator, handler.GetCompatibilityRanking(compatibilityUseCase)))).
+		Methods("GET")
+
 	// Vehicules
+	r.Handle("/vehicules/accessories", handler.RequestID(handler.Auth(authValidator, handler.GetAccessories(vehiculeUseCase)))).
+		Methods("GET")
 	r.Handle("/users/{userId}/vehicules", handler.RequestID(handler.Auth(authValidator, handler.GetVehiculesByUserID(userUseCase, vehiculeUseCase)))).
 		Methods("GET")
 	r.Handle("/users/{userId}/vehicules/{id}", handler.RequestID(handler.Auth(authValidator, handler.GetVehiculeByID(userUseCase, vehiculeUseCase)))).
 		Methods("GET").
 		Headers("Content-Type", "application/json")
+	r.Handle("/users/{userId}/vehicules/{id}", handler.RequestID(handler.Auth(authValidator, handler.UpdateVehicule(userUseCase, vehiculeUseCase)))).
+		Methods("PATCH").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
 	r.Handle("/users/{userId}/vehicules/{id}", handler.RequestID(handler.Auth(authValidator, handler.DeleteVehicule(userUseCase, vehiculeUseCase)))).
 		Methods("DELETE").
 		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+	r.Handle("/users/{userId}/vehicules/{id}/photo", handler.RequestID(handler.Auth(authValidator, handler.UploadVehiculePhoto(photoUseCase)))).
+		Methods("PUT")
 	r.Handle("/users/{userId}/vehicules", handler.RequestID(handler.Auth(authValidator, handler.CreateVehicule(userUseCase, vehiculeUseCase)))).
 		Methods("POST").
 		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
 
+	// Ratings
+	r.Handle("/users/{id}/reviews", handler.RequestID(handler.Auth(authValidator, handler.GetReviewsBySubjectID(ratingUseCase)))).
+		Methods("GET")
+	r.Handle("/users/{id}/reviews", handler.RequestID(handler.Auth(authValidator, handler.SubmitReview(ratingUseCase)))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+	r.Handle("/users/{id}/ratings", handler.RequestID(handler.Auth(authValidator, handler.GetRatings(ratingUseCase)))).
+		Methods("GET")
+
+	// Driver verification
+	r.Handle("/users/{id}/documents", handler.RequestID(handler.Auth(authValidator, handler.GetDocumentsByUserID(verificationUseCase)))).
+		Methods("GET")
+	r.Handle("/users/{id}/documents", handler.RequestID(handler.Auth(authValidator, handler.UploadDocument(verificationUseCase)))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "multipart/form-data")
+	r.Handle("/users/{id}/documents/{documentId}/file", handler.RequestID(handler.Auth(authValidator, handler.GetDocumentFile(verificationUseCase)))).
+		Methods("GET")
+	r.Handle("/documents", handler.RequestID(handler.Auth(authValidator, handler.RequirePermission(auth.PermissionReviewDocuments, handler.SearchDocuments(verificationUseCase))))).
+		Methods("GET")
+	r.Handle("/documents/{id}/approve", handler.RequestID(handler.Auth(authValidator, handler.RequirePermission(auth.PermissionReviewDocuments, handler.ApproveDocument(verificationUseCase))))).
+		Methods("POST")
+	r.Handle("/documents/{id}/reject", handler.RequestID(handler.Auth(authValidator, handler.RequirePermission(auth.PermissionReviewDocuments, handler.RejectDocument(verificationUseCase))))).
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/(json|json; charset=utf8)")
+
+	// Exports
+	r.Handle("/exports/{id}/archive", handler.RequestID(handler.DownloadExport(exportUseCase))).
+		Methods("GET")
+
+	// Media
+	r.Handle("/media/{key:photos/.+}", handler.RequestID(handler.GetPhoto(mediaStore))).
+		Methods("GET")
+
 	log.Fatal(http.ListenAndServe(":"+port, handlers.LoggingHandler(os.Stdout, r)))
 }
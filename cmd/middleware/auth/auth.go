@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // UserInfo contains a user's basic information extracted from an access token.
@@ -15,6 +16,21 @@ type UserInfo struct {
 	LastName  string `json:"family_name"`
 	Picture   string `json:"picture"`
 	Email     string `json:"email"`
+
+	// EmailVerified specifies whether the identity provider verified that
+	// the user owns its email address.
+	EmailVerified bool `json:"email_verified"`
+
+	// Roles contains the roles given to the user, extracted from a
+	// namespaced claim.
+	Roles []string `json:"-"`
+
+	// Permissions contains the permissions granted directly to the user.
+	Permissions []string `json:"-"`
+
+	// ExpiresAt is the time at which the access token expires, if it is
+	// known.
+	ExpiresAt time.Time `json:"-"`
 }
 
 // Config contains the information required to configure a validator to make
@@ -22,6 +38,12 @@ type UserInfo struct {
 type Config struct {
 	// Domain represents the domain where the user info endpoint is hosted.
 	Domain string
+
+	// RolesClaim represents the name of the namespaced claim that contains
+	// the user's roles.
+	//
+	// An empty name means DefaultRolesClaim.
+	RolesClaim string
 }
 
 // Validate looks at the configuration's contents to ensure it has all the
@@ -70,28 +92,47 @@ func NewTokenValidator(conf *Config) (Validator, error) {
 func (validator *TokenValidator) Validate(authHeader string) (*UserInfo, error) {
 	req, err := http.NewRequest("GET", "https://"+validator.conf.Domain+"/userinfo", nil)
 	if err != nil {
-		return nil, UnauthorizedError{fmt.Sprintf("auth.TokenValidator: failed to create request (%s)", err)}
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.TokenValidator: failed to create request (%s)", err))}
 	}
 
 	req.Header.Set("Authorization", authHeader)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, UnauthorizedError{fmt.Sprintf("auth: failed to make request (%s)", err)}
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth: failed to make request (%s)", err))}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, UnauthorizedError{fmt.Sprintf("auth: failed to validate token")}
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth: failed to validate token"))}
+	}
+
+	var claims map[string]json.RawMessage
+	err = json.NewDecoder(resp.Body).Decode(&claims)
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth: failed to decode user info (%s)", err))}
 	}
 
 	var userInfo UserInfo
-	err = json.NewDecoder(resp.Body).Decode(&userInfo)
+	err = decodeClaims(claims, &userInfo)
 	if err != nil {
-		return nil, UnauthorizedError{fmt.Sprintf("auth: failed to decode user info (%s)", err)}
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth: failed to decode user info (%s)", err))}
 	}
+	userInfo.setAuthorizationClaims(claims, validator.conf.RolesClaim)
+
 	return &userInfo, nil
 }
 
+// decodeClaims decodes raw claims into a value, as if they were decoded from
+// their JSON object.
+func decodeClaims(claims map[string]json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
 type contextKey string
 
 func (c contextKey) String() string {
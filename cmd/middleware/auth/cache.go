@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheConfig contains the information required to configure a caching
+// validator.
+type CacheConfig struct {
+	// TTL specifies how long a successful validation is kept in the cache.
+	// An entry never outlives the token's expiration time, when it is known.
+	//
+	// A zero TTL means DefaultCacheTTL.
+	TTL time.Duration
+
+	// NegativeTTL specifies how long a failed validation is kept in the
+	// cache.
+	//
+	// A zero TTL means DefaultCacheNegativeTTL.
+	NegativeTTL time.Duration
+
+	// MaxEntries specifies the maximum number of entries kept in the cache.
+	// When the cache is full, the least recently used entry is evicted.
+	//
+	// Zero means DefaultCacheMaxEntries.
+	MaxEntries int
+}
+
+const (
+	// DefaultCacheTTL represents the default amount of time a successful
+	// validation is kept in the cache.
+	DefaultCacheTTL = 5 * time.Minute
+
+	// DefaultCacheNegativeTTL represents the default amount of time a failed
+	// validation is kept in the cache.
+	DefaultCacheNegativeTTL = 10 * time.Second
+
+	// DefaultCacheMaxEntries represents the default maximum number of entries
+	// kept in the cache.
+	DefaultCacheMaxEntries = 10000
+)
+
+// A CachingValidator is a validator that caches the result of another
+// validator, keyed by a hash of the authorization header. Concurrent
+// validations of the same authorization header result in a single call to
+// the other validator.
+type CachingValidator struct {
+	next Validator
+	conf CacheConfig
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+
+	group singleflight.Group
+}
+
+type cacheEntry struct {
+	key       string
+	userInfo  *UserInfo
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingValidator creates a new validator that caches the results of the
+// given validator using the given configuration.
+func NewCachingValidator(next Validator, conf *CacheConfig) (Validator, error) {
+	if next == nil {
+		return nil, fmt.Errorf("auth: missing validator to cache")
+	}
+
+	if conf == nil {
+		conf = &CacheConfig{}
+	}
+
+	c := *conf
+	if c.TTL == 0 {
+		c.TTL = DefaultCacheTTL
+	}
+	if c.NegativeTTL == 0 {
+		c.NegativeTTL = DefaultCacheNegativeTTL
+	}
+	if c.MaxEntries == 0 {
+		c.MaxEntries = DefaultCacheMaxEntries
+	}
+
+	return &CachingValidator{
+		next:    next,
+		conf:    c,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}, nil
+}
+
+// Validate returns the cached result of the validation of the authorization
+// header, if there is one that has not expired. Otherwise, the authorization
+// header is validated by the cached validator and the result is cached.
+func (validator *CachingValidator) Validate(authHeader string) (*UserInfo, error) {
+	key := cacheKey(authHeader)
+
+	if entry, ok := validator.get(key); ok {
+		return entry.result()
+	}
+
+	v, _, _ := validator.group.Do(key, func() (interface{}, error) {
+		if entry, ok := validator.get(key); ok {
+			return entry, nil
+		}
+
+		userInfo, err := validator.next.Validate(authHeader)
+
+		entry := &cacheEntry{key: key, userInfo: userInfo, err: err}
+		if err != nil {
+			entry.expiresAt = validator.now().Add(validator.conf.NegativeTTL)
+		} else {
+			entry.expiresAt = validator.now().Add(validator.conf.TTL)
+			if !userInfo.ExpiresAt.IsZero() && userInfo.ExpiresAt.Before(entry.expiresAt) {
+				entry.expiresAt = userInfo.ExpiresAt
+			}
+		}
+		validator.put(entry)
+
+		return entry, nil
+	})
+
+	return v.(*cacheEntry).result()
+}
+
+// cacheKey hashes the authorization header so that tokens are never kept in
+// memory as is.
+func cacheKey(authHeader string) string {
+	hash := sha256.Sum256([]byte(authHeader))
+	return hex.EncodeToString(hash[:])
+}
+
+func (entry *cacheEntry) result() (*UserInfo, error) {
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	userInfo := *entry.userInfo
+	userInfo.Roles = slices.Clone(userInfo.Roles)
+	userInfo.Permissions = slices.Clone(userInfo.Permissions)
+	return &userInfo, nil
+}
+
+func (validator *CachingValidator) get(key string) (*cacheEntry, bool) {
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	elem, ok := validator.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !validator.now().Before(entry.expiresAt) {
+		validator.lru.Remove(elem)
+		delete(validator.entries, key)
+		return nil, false
+	}
+
+	validator.lru.MoveToFront(elem)
+
+	return entry, true
+}
+
+func (validator *CachingValidator) put(entry *cacheEntry) {
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	if elem, ok := validator.entries[entry.key]; ok {
+		elem.Value = entry
+		validator.lru.MoveToFront(elem)
+		return
+	}
+
+	validator.entries[entry.key] = validator.lru.PushFront(entry)
+
+	for validator.lru.Len() > validator.conf.MaxEntries {
+		oldest := validator.lru.Back()
+		validator.lru.Remove(oldest)
+		delete(validator.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingValidator struct {
+	calls    int32
+	delay    time.Duration
+	userInfo *UserInfo
+	err      error
+}
+
+func (v *countingValidator) Validate(authHeader string) (*UserInfo, error) {
+	atomic.AddInt32(&v.calls, 1)
+	time.Sleep(v.delay)
+
+	if v.err != nil {
+		return nil, v.err
+	}
+
+	userInfo := *v.userInfo
+	userInfo.SubID = authHeader
+	return &userInfo, nil
+}
+
+func newTestCachingValidator(t *testing.T, next Validator, conf *CacheConfig) (*CachingValidator, *time.Time) {
+	validator, err := NewCachingValidator(next, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	cachingValidator := validator.(*CachingValidator)
+	cachingValidator.now = func() time.Time { return now }
+
+	return cachingValidator, &now
+}
+
+func TestCachingValidator(t *testing.T) {
+	t.Run("Should only validate a token once until it expires from the cache", func(t *testing.T) {
+		next := &countingValidator{userInfo: &UserInfo{}}
+		validator, now := newTestCachingValidator(t, next, &CacheConfig{TTL: time.Minute})
+
+		for i := 0; i < 3; i++ {
+			if _, err := validator.Validate("Bearer harold"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if next.calls != 1 {
+			t.Errorf("expected 1 call, got %d", next.calls)
+		}
+
+		*now = now.Add(time.Minute)
+		if _, err := validator.Validate("Bearer harold"); err != nil {
+			t.Fatal(err)
+		}
+		if next.calls != 2 {
+			t.Errorf("expected 2 calls, got %d", next.calls)
+		}
+	})
+
+	t.Run("Should not cache a token past its expiration time", func(t *testing.T) {
+		next := &countingValidator{userInfo: &UserInfo{}}
+		validator, now := newTestCachingValidator(t, next, &CacheConfig{TTL: time.Hour})
+		next.userInfo.ExpiresAt = now.Add(time.Second)
+
+		validator.Validate("Bearer harold")
+		*now = now.Add(time.Second)
+		validator.Validate("Bearer harold")
+
+		if next.calls != 2 {
+			t.Errorf("expected 2 calls, got %d", next.calls)
+		}
+	})
+
+	t.Run("Should cache failures for the negative TTL", func(t *testing.T) {
+		next := &countingValidator{err: UnauthorizedError{unauthorized.New("nope")}}
+		validator, now := newTestCachingValidator(t, next, &CacheConfig{TTL: time.Hour, NegativeTTL: time.Second})
+
+		for i := 0; i < 2; i++ {
+			if _, ok := errOf(validator.Validate("Bearer harold")).(UnauthorizedError); !ok {
+				t.Fatal("expected unauthorized error")
+			}
+		}
+		if next.calls != 1 {
+			t.Errorf("expected 1 call, got %d", next.calls)
+		}
+
+		*now = now.Add(time.Second)
+		validator.Validate("Bearer harold")
+		if next.calls != 2 {
+			t.Errorf("expected 2 calls, got %d", next.calls)
+		}
+	})
+
+	t.Run("Should evict the least recently used entry when full", func(t *testing.T) {
+		next := &countingValidator{userInfo: &UserInfo{}}
+		validator, _ := newTestCachingValidator(t, next, &CacheConfig{MaxEntries: 2})
+
+		validator.Validate("Bearer a")
+		validator.Validate("Bearer b")
+		validator.Validate("Bearer a")
+		validator.Validate("Bearer c")
+
+		if _, ok := validator.get(cacheKey("Bearer b")); ok {
+			t.Error("expected b to be evicted")
+		}
+		if _, ok := validator.get(cacheKey("Bearer a")); !ok {
+			t.Error("expected a to be cached")
+		}
+	})
+
+	t.Run("Should return copies of the cached user info", func(t *testing.T) {
+		next := &countingValidator{userInfo: &UserInfo{
+			Email:       "harold@hide-the-pain.meme",
+			Roles:       []string{"driver"},
+			Permissions: []string{"read:users"},
+		}}
+		validator, _ := newTestCachingValidator(t, next, nil)
+
+		userInfo, _ := validator.Validate("Bearer harold")
+		userInfo.Email = "maurice@hide-the-pain.meme"
+		userInfo.Roles[0] = "admin"
+		userInfo.Permissions[0] = "write:users"
+
+		userInfo, _ = validator.Validate("Bearer harold")
+		if userInfo.Email != "harold@hide-the-pain.meme" || userInfo.Roles[0] != "driver" || userInfo.Permissions[0] != "read:users" {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should collapse concurrent validations of the same token", func(t *testing.T) {
+		next := &countingValidator{userInfo: &UserInfo{}, delay: 50 * time.Millisecond}
+		validator, _ := newTestCachingValidator(t, next, nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				if _, err := validator.Validate("Bearer harold"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if calls := atomic.LoadInt32(&next.calls); calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
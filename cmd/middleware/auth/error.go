@@ -1,11 +1,27 @@
 package auth
 
+import "azure.com/ecovo/user-service/pkg/entity"
+
 // An UnauthorizedError is an error that occurs when the user's authorization
 // could not be validated.
 type UnauthorizedError struct {
-	msg string
+	entity.BaseError
+}
+
+var unauthorized = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindUnauthorized,
+	Code:   "unauthorized",
+	Detail: "unauthorized",
+}
+
+// A ForbiddenError is an error that occurs when the authenticated user was
+// not granted the permission required to perform an operation.
+type ForbiddenError struct {
+	entity.BaseError
 }
 
-func (e UnauthorizedError) Error() string {
-	return e.msg
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "missingPermission",
+	Detail: "forbidden",
 }
@@ -0,0 +1,494 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// JWKSConfig contains the information required to configure a validator that
+// verifies bearer tokens locally using the keys found in a JSON Web Key Set.
+type JWKSConfig struct {
+	// URL specifies where the JSON Web Key Set can be fetched from (ex.
+	// https://my.domain.com/.well-known/jwks.json).
+	URL string
+
+	// File specifies the path to a file containing the JSON Web Key Set. It
+	// is used instead of the URL when it is set.
+	File string
+
+	// Issuer specifies the expected value of the token's "iss" claim.
+	Issuer string
+
+	// Audience specifies a value that must be present in the token's "aud"
+	// claim.
+	Audience string
+
+	// RolesClaim represents the name of the namespaced claim that contains
+	// the user's roles.
+	//
+	// An empty name means DefaultRolesClaim.
+	RolesClaim string
+
+	// Leeway specifies how much clock skew is tolerated when validating the
+	// token's "exp" and "nbf" claims.
+	Leeway time.Duration
+
+	// RefreshInterval specifies the minimum amount of time to wait between
+	// two reloads of the key set triggered by a token signed with an unknown
+	// key.
+	//
+	// A zero interval means DefaultJWKSRefreshInterval.
+	RefreshInterval time.Duration
+
+	// Client specifies the HTTP client used to fetch the key set.
+	//
+	// A nil client means http.DefaultClient.
+	Client *http.Client
+}
+
+// DefaultJWKSRefreshInterval represents the default minimum amount of time to
+// wait between two reloads of a key set.
+const DefaultJWKSRefreshInterval = 5 * time.Minute
+
+// Validate looks at the configuration's contents to ensure it has all the
+// required fields.
+func (conf *JWKSConfig) validate() error {
+	if conf.URL == "" && conf.File == "" {
+		return errors.New("missing key set URL or file")
+	}
+
+	if conf.Issuer == "" {
+		return errors.New("missing issuer")
+	}
+
+	if conf.Audience == "" {
+		return errors.New("missing audience")
+	}
+
+	return nil
+}
+
+// A JWKSValidator is a validator that verifies the signature and the claims
+// of a bearer token in an authorization header locally, using the keys found
+// in a JSON Web Key Set. Only RS256 and ES256 signatures are supported.
+//
+// When a token is signed with a key that is not in the key set, the key set
+// is reloaded to support key rotation. Concurrent reloads are merged into one.
+type JWKSValidator struct {
+	conf      *JWKSConfig
+	now       func() time.Time
+	refreshes singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+}
+
+// NewJWKSValidator creates a new JSON Web Key Set validator with the given
+// configuration and loads the key set.
+func NewJWKSValidator(conf *JWKSConfig) (Validator, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("auth: missing configuration")
+	}
+
+	err := conf.validate()
+	if err != nil {
+		return nil, fmt.Errorf("auth: configuration %s", err)
+	}
+
+	validator := &JWKSValidator{conf: conf, now: time.Now}
+
+	err = validator.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	return validator, nil
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type jwtAudience []string
+
+func (aud *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = jwtAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*aud = jwtAudience(multiple)
+
+	return nil
+}
+
+type jwtClaims struct {
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *int64      `json:"exp"`
+	NotBefore *int64      `json:"nbf"`
+	UserInfo
+}
+
+// Validate verifies the signature of the bearer token present in the
+// authorization header, validates its claims and returns the authenticated
+// user's information.
+func (validator *JWKSValidator) Validate(authHeader string) (*UserInfo, error) {
+	token, err := bearerToken(authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, UnauthorizedError{unauthorized.New("auth.JWKSValidator: malformed token")}
+	}
+
+	var header jwtHeader
+	err = decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: failed to decode token header (%s)", err))}
+	}
+
+	key, err := validator.key(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: failed to decode token signature (%s)", err))}
+	}
+
+	err = verifySignature(header.Algorithm, key, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawClaims map[string]json.RawMessage
+	err = decodeSegment(parts[1], &rawClaims)
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: failed to decode token claims (%s)", err))}
+	}
+
+	var claims jwtClaims
+	err = decodeClaims(rawClaims, &claims)
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: failed to decode token claims (%s)", err))}
+	}
+
+	err = validator.validateClaims(&claims)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := claims.UserInfo
+	userInfo.ExpiresAt = time.Unix(*claims.ExpiresAt, 0)
+	userInfo.setAuthorizationClaims(rawClaims, validator.conf.RolesClaim)
+
+	return &userInfo, nil
+}
+
+func (validator *JWKSValidator) validateClaims(claims *jwtClaims) error {
+	now := validator.now()
+
+	if claims.Issuer != validator.conf.Issuer {
+		return UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: unexpected issuer \"%s\"", claims.Issuer))}
+	}
+
+	audienceFound := false
+	for _, aud := range claims.Audience {
+		if aud == validator.conf.Audience {
+			audienceFound = true
+			break
+		}
+	}
+	if !audienceFound {
+		return UnauthorizedError{unauthorized.New("auth.JWKSValidator: token was not issued for this audience")}
+	}
+
+	if claims.ExpiresAt == nil {
+		return UnauthorizedError{unauthorized.New("auth.JWKSValidator: token has no expiration time")}
+	}
+
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(validator.conf.Leeway)) {
+		return UnauthorizedError{unauthorized.New("auth.JWKSValidator: token is expired")}
+	}
+
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0).Add(-validator.conf.Leeway)) {
+		return UnauthorizedError{unauthorized.New("auth.JWKSValidator: token is not valid yet")}
+	}
+
+	if claims.SubID == "" {
+		return UnauthorizedError{unauthorized.New("auth.JWKSValidator: token has no subject")}
+	}
+
+	return nil
+}
+
+// key returns the public key with the given key ID. The key set is reloaded
+// when the key is unknown, unless it was reloaded too recently. Concurrent
+// requests for unknown keys wait for the same reload.
+func (validator *JWKSValidator) key(kid string) (crypto.PublicKey, error) {
+	validator.mu.Lock()
+	key, ok := validator.keys[kid]
+	validator.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	_, err, _ := validator.refreshes.Do("", func() (interface{}, error) {
+		if !validator.canRefresh() {
+			return nil, nil
+		}
+
+		return nil, validator.refresh()
+	})
+	if err != nil {
+		return nil, UnauthorizedError{unauthorized.New(err.Error())}
+	}
+
+	validator.mu.Lock()
+	key, ok = validator.keys[kid]
+	validator.mu.Unlock()
+
+	if !ok {
+		return nil, UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: no key found with ID \"%s\"", kid))}
+	}
+
+	return key, nil
+}
+
+// canRefresh returns whether the refresh interval elapsed since the key set
+// was last reloaded.
+func (validator *JWKSValidator) canRefresh() bool {
+	refreshInterval := validator.conf.RefreshInterval
+	if refreshInterval == 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	return validator.now().Sub(validator.lastRefresh) >= refreshInterval
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// refresh loads the key set from the configured file or URL and replaces the
+// validator's keys. The keys that cannot be used to verify signatures, such
+// as keys of an unsupported type, are skipped, so that adding such a key to
+// the key set does not prevent validating tokens signed with the other keys.
+func (validator *JWKSValidator) refresh() error {
+	var data []byte
+	var err error
+	if validator.conf.File != "" {
+		data, err = ioutil.ReadFile(validator.conf.File)
+		if err != nil {
+			return fmt.Errorf("auth.JWKSValidator: failed to read key set (%s)", err)
+		}
+	} else {
+		data, err = validator.fetch()
+		if err != nil {
+			return err
+		}
+	}
+
+	var set jsonWebKeySet
+	err = json.Unmarshal(data, &set)
+	if err != nil {
+		return fmt.Errorf("auth.JWKSValidator: failed to decode key set (%s)", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("auth.JWKSValidator: skipping key \"%s\" in key set (%s)", jwk.KeyID, err)
+			continue
+		}
+
+		keys[jwk.KeyID] = key
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("auth.JWKSValidator: no usable key in key set")
+	}
+
+	validator.mu.Lock()
+	validator.keys = keys
+	validator.lastRefresh = validator.now()
+	validator.mu.Unlock()
+
+	return nil
+}
+
+func (validator *JWKSValidator) fetch() ([]byte, error) {
+	client := validator.conf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(validator.conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("auth.JWKSValidator: failed to fetch key set (%s)", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth.JWKSValidator: failed to fetch key set (status %d)", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("auth.JWKSValidator: failed to read key set (%s)", err)
+	}
+
+	return data, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus (%s)", err)
+		}
+
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent (%s)", err)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve \"%s\"", jwk.Curve)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate (%s)", err)
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate (%s)", err)
+		}
+
+		curve := elliptic.P256()
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type \"%s\"", jwk.KeyType)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	hash := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return UnauthorizedError{unauthorized.New("auth.JWKSValidator: key cannot be used with RS256")}
+		}
+
+		err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], signature)
+		if err != nil {
+			return UnauthorizedError{unauthorized.New("auth.JWKSValidator: invalid token signature")}
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return UnauthorizedError{unauthorized.New("auth.JWKSValidator: key cannot be used with ES256")}
+		}
+
+		if len(signature) != 64 {
+			return UnauthorizedError{unauthorized.New("auth.JWKSValidator: invalid token signature")}
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, hash[:], r, s) {
+			return UnauthorizedError{unauthorized.New("auth.JWKSValidator: invalid token signature")}
+		}
+	default:
+		return UnauthorizedError{unauthorized.New(fmt.Sprintf("auth.JWKSValidator: unsupported signing algorithm \"%s\"", alg))}
+	}
+
+	return nil
+}
+
+// bearerToken extracts the token from an authorization header using the
+// bearer scheme.
+func bearerToken(authHeader string) (string, error) {
+	const prefix = "bearer "
+	if len(authHeader) <= len(prefix) || !strings.EqualFold(authHeader[:len(prefix)], prefix) {
+		return "", UnauthorizedError{unauthorized.New("auth: missing bearer token")}
+	}
+
+	return strings.TrimSpace(authHeader[len(prefix):]), nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
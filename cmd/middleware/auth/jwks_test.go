@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://ecovo.auth0.com/"
+	testAudience = "https://api.ecovo.ca"
+)
+
+type testKey struct {
+	kid string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSATestKey(t *testing.T, kid string) *testKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKey{kid: kid, rsa: key}
+}
+
+func newECTestKey(t *testing.T, kid string) *testKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKey{kid: kid, ec: key}
+}
+
+// newUnsupportedTestKey returns a key of a type that the validator does not
+// support. It cannot sign tokens.
+func newUnsupportedTestKey(kid string) *testKey {
+	return &testKey{kid: kid}
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func (k *testKey) jwk() map[string]string {
+	if k.rsa != nil {
+		return map[string]string{
+			"kty": "RSA",
+			"kid": k.kid,
+			"use": "sig",
+			"n":   encodeBigInt(k.rsa.N),
+			"e":   encodeBigInt(big.NewInt(int64(k.rsa.E))),
+		}
+	}
+
+	if k.ec == nil {
+		return map[string]string{
+			"kty": "OKP",
+			"kid": k.kid,
+			"crv": "Ed25519",
+			"x":   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+		}
+	}
+
+	return map[string]string{
+		"kty": "EC",
+		"kid": k.kid,
+		"crv": "P-256",
+		"x":   encodeBigInt(k.ec.X),
+		"y":   encodeBigInt(k.ec.Y),
+	}
+}
+
+func keySet(keys ...*testKey) []byte {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+
+	data, _ := json.Marshal(set)
+	return data
+}
+
+func (k *testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	alg := "RS256"
+	if k.ec != nil {
+		alg = "ES256"
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": k.kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if k.rsa != nil {
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return "Bearer " + signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            testIssuer,
+		"aud":            []string{testAudience, "https://ecovo.auth0.com/userinfo"},
+		"sub":            "auth0|harold",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"given_name":     "Harold",
+		"family_name":    "The Great",
+		"email":          "harold@hide-the-pain.meme",
+		"email_verified": true,
+	}
+}
+
+type testKeyServer struct {
+	mu       sync.Mutex
+	keys     []*testKey
+	requests int
+}
+
+func (s *testKeyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	w.Write(keySet(s.keys...))
+}
+
+func newTestJWKSValidator(t *testing.T, keys ...*testKey) (*JWKSValidator, *testKeyServer, func()) {
+	keyServer := &testKeyServer{keys: keys}
+	server := httptest.NewServer(keyServer)
+
+	validator, err := NewJWKSValidator(&JWKSConfig{
+		URL:             server.URL,
+		Issuer:          testIssuer,
+		Audience:        testAudience,
+		RefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+
+	return validator.(*JWKSValidator), keyServer, server.Close
+}
+
+func TestJWKSValidator(t *testing.T) {
+	rsaKey := newRSATestKey(t, "rsa")
+	ecKey := newECTestKey(t, "ec")
+
+	validator, _, closeServer := newTestJWKSValidator(t, rsaKey, ecKey)
+	defer closeServer()
+
+	t.Run("Should succeed when token is signed with RS256", func(t *testing.T) {
+		userInfo, err := validator.Validate(rsaKey.sign(t, validClaims()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if userInfo.SubID != "auth0|harold" || userInfo.FirstName != "Harold" || userInfo.Email != "harold@hide-the-pain.meme" || !userInfo.EmailVerified {
+			t.Errorf("unexpected user info %+v", userInfo)
+		}
+	})
+
+	t.Run("Should succeed when token is signed with ES256", func(t *testing.T) {
+		_, err := validator.Validate(ecKey.sign(t, validClaims()))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Should succeed when audience is a single string", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = testAudience
+
+		_, err := validator.Validate(rsaKey.sign(t, claims))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	invalidClaims := map[string]func(claims map[string]interface{}){
+		"issuer is wrong":        func(claims map[string]interface{}) { claims["iss"] = "https://evil.auth0.com/" },
+		"audience is wrong":      func(claims map[string]interface{}) { claims["aud"] = "https://evil.ca" },
+		"token is expired":       func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"expiration is missing":  func(claims map[string]interface{}) { delete(claims, "exp") },
+		"token is not valid yet": func(claims map[string]interface{}) { claims["nbf"] = time.Now().Add(time.Minute).Unix() },
+		"subject is missing":     func(claims map[string]interface{}) { delete(claims, "sub") },
+	}
+	for name, modify := range invalidClaims {
+		modify := modify
+		t.Run("Should fail when "+name, func(t *testing.T) {
+			claims := validClaims()
+			modify(claims)
+
+			if _, ok := errOf(validator.Validate(rsaKey.sign(t, claims))).(UnauthorizedError); !ok {
+				t.Fail()
+			}
+		})
+	}
+
+	t.Run("Should fail when signature does not match", func(t *testing.T) {
+		token := rsaKey.sign(t, validClaims())
+		tampered := rsaKey.sign(t, map[string]interface{}{"sub": "auth0|maurice"})
+
+		if _, ok := errOf(validator.Validate(token[:len(token)-10] + tampered[len(tampered)-10:])).(UnauthorizedError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when algorithm is none", func(t *testing.T) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`))
+		payload, _ := json.Marshal(validClaims())
+
+		token := "Bearer " + header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+		if _, ok := errOf(validator.Validate(token)).(UnauthorizedError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when authorization header is not a bearer token", func(t *testing.T) {
+		if _, ok := errOf(validator.Validate("Basic aGFyb2xkOnBhaW4=")).(UnauthorizedError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestJWKSValidatorKeyRotation(t *testing.T) {
+	oldKey := newRSATestKey(t, "old")
+	newKey := newRSATestKey(t, "new")
+
+	validator, keyServer, closeServer := newTestJWKSValidator(t, oldKey)
+	defer closeServer()
+
+	keyServer.mu.Lock()
+	keyServer.keys = []*testKey{newKey}
+	keyServer.mu.Unlock()
+
+	_, err := validator.Validate(newKey.sign(t, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should reload key set once for concurrent unknown keys", func(t *testing.T) {
+		validator.mu.Lock()
+		validator.conf.RefreshInterval = time.Hour
+		validator.lastRefresh = time.Time{}
+		validator.mu.Unlock()
+
+		keyServer.mu.Lock()
+		requests := keyServer.requests
+		keyServer.mu.Unlock()
+
+		unknownKey := newRSATestKey(t, "unknown")
+		token := unknownKey.sign(t, validClaims())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				validator.Validate(token)
+			}()
+		}
+		wg.Wait()
+
+		keyServer.mu.Lock()
+		defer keyServer.mu.Unlock()
+		if keyServer.requests != requests+1 {
+			t.Errorf("expected key set to be reloaded once, got %d reloads", keyServer.requests-requests)
+		}
+	})
+
+	t.Run("Should not reload key set too often", func(t *testing.T) {
+		validator.conf.RefreshInterval = time.Hour
+
+		requests := keyServer.requests
+		unknownKey := newRSATestKey(t, "unknown")
+		_, err := validator.Validate(unknownKey.sign(t, validClaims()))
+		if _, ok := err.(UnauthorizedError); !ok {
+			t.Error("expected token signed with unknown key to be rejected")
+		}
+
+		if keyServer.requests != requests {
+			t.Error("expected key set not to be reloaded")
+		}
+	})
+}
+
+func TestJWKSValidatorUnsupportedKey(t *testing.T) {
+	t.Run("Should skip keys that are not supported", func(t *testing.T) {
+		key := newECTestKey(t, "ec")
+
+		validator, _, closeServer := newTestJWKSValidator(t, newUnsupportedTestKey("okp"), key)
+		defer closeServer()
+
+		_, err := validator.Validate(key.sign(t, validClaims()))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Should fail when no key is supported", func(t *testing.T) {
+		server := httptest.NewServer(&testKeyServer{keys: []*testKey{newUnsupportedTestKey("okp")}})
+		defer server.Close()
+
+		_, err := NewJWKSValidator(&JWKSConfig{URL: server.URL, Issuer: testIssuer, Audience: testAudience})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestJWKSValidatorFromFile(t *testing.T) {
+	key := newECTestKey(t, "file")
+
+	f, err := ioutil.TempFile("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(keySet(key))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := NewJWKSValidator(&JWKSConfig{File: f.Name(), Issuer: testIssuer, Audience: testAudience})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = validator.Validate(key.sign(t, validClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func errOf(_ *UserInfo, err error) error {
+	return err
+}
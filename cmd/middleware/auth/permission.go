@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// RoleAdmin represents the role given to administrators, which are
+	// granted every permission.
+	RoleAdmin = "admin"
+
+	// RoleService represents the role given to other services, such as the
+	// trip service, which act on behalf of users.
+	RoleService = "service"
+)
+
+const (
+	// PermissionReadUsers allows looking up any user.
+	PermissionReadUsers = "read:users"
+
+	// PermissionUpdateUsers allows modifying any user, including the fields
+	// that are managed by the system, such as ratings.
+	PermissionUpdateUsers = "update:users"
+
+	// PermissionDeleteUsers allows deleting any user.
+	PermissionDeleteUsers = "delete:users"
+
+	// PermissionReviewDocuments allows seeing the documents of any user and
+	// approving or rejecting them.
+	PermissionReviewDocuments = "review:documents"
+)
+
+// DefaultRolesClaim represents the default name of the namespaced claim that
+// contains the user's roles.
+const DefaultRolesClaim = "https://ecovo.ca/roles"
+
+// permissionsClaim represents the name of the claim in which Auth0 places the
+// permissions granted to the user when role-based access control is enabled.
+const permissionsClaim = "permissions"
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionReadUsers,
+		PermissionUpdateUsers,
+		PermissionDeleteUsers,
+		PermissionReviewDocuments,
+	},
+	RoleService: {
+		PermissionReadUsers,
+		PermissionUpdateUsers,
+	},
+}
+
+// HasPermission returns whether the user was granted the given permission,
+// either directly or through one of its roles.
+func (userInfo *UserInfo) HasPermission(permission string) bool {
+	for _, p := range userInfo.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	for _, role := range userInfo.Roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RequirePermission returns a forbidden error when the user was not granted
+// the given permission.
+func (userInfo *UserInfo) RequirePermission(permission string) error {
+	if !userInfo.HasPermission(permission) {
+		return ForbiddenError{forbidden.New(fmt.Sprintf("auth: missing permission \"%s\"", permission))}
+	}
+
+	return nil
+}
+
+// setAuthorizationClaims extracts the user's roles and permissions from the
+// raw claims. Both claims can either be an array of strings or a string of
+// space separated values.
+func (userInfo *UserInfo) setAuthorizationClaims(claims map[string]json.RawMessage, rolesClaim string) {
+	if rolesClaim == "" {
+		rolesClaim = DefaultRolesClaim
+	}
+
+	userInfo.Roles = decodeStringList(claims[rolesClaim])
+	userInfo.Permissions = decodeStringList(claims[permissionsClaim])
+}
+
+func decodeStringList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.Fields(s)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUserInfoHasPermission(t *testing.T) {
+	t.Run("Should grant permissions given directly", func(t *testing.T) {
+		userInfo := UserInfo{Permissions: []string{PermissionReadUsers}}
+
+		if !userInfo.HasPermission(PermissionReadUsers) {
+			t.Fail()
+		}
+
+		if userInfo.HasPermission(PermissionDeleteUsers) {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should grant permissions given through roles", func(t *testing.T) {
+		admin := UserInfo{Roles: []string{RoleAdmin}}
+		if !admin.HasPermission(PermissionDeleteUsers) {
+			t.Error("expected admin to be able to delete users")
+		}
+
+		service := UserInfo{Roles: []string{RoleService}}
+		if !service.HasPermission(PermissionUpdateUsers) {
+			t.Error("expected service to be able to update users")
+		}
+		if service.HasPermission(PermissionDeleteUsers) {
+			t.Error("expected service not to be able to delete users")
+		}
+	})
+
+	t.Run("Should not grant any permission to ordinary users", func(t *testing.T) {
+		rider := UserInfo{Roles: []string{"rider"}}
+
+		if _, ok := rider.RequirePermission(PermissionReadUsers).(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestUserInfoAuthorizationClaims(t *testing.T) {
+	t.Run("Should extract roles and permissions from arrays", func(t *testing.T) {
+		var claims map[string]json.RawMessage
+		json.Unmarshal([]byte(`{"https://ecovo.ca/roles":["admin"],"permissions":["read:users"]}`), &claims)
+
+		var userInfo UserInfo
+		userInfo.setAuthorizationClaims(claims, "")
+
+		if len(userInfo.Roles) != 1 || userInfo.Roles[0] != RoleAdmin {
+			t.Errorf("unexpected roles %v", userInfo.Roles)
+		}
+		if len(userInfo.Permissions) != 1 || userInfo.Permissions[0] != PermissionReadUsers {
+			t.Errorf("unexpected permissions %v", userInfo.Permissions)
+		}
+	})
+
+	t.Run("Should extract roles from a custom claim with space separated values", func(t *testing.T) {
+		var claims map[string]json.RawMessage
+		json.Unmarshal([]byte(`{"https://trips.ecovo.ca/roles":"service admin"}`), &claims)
+
+		var userInfo UserInfo
+		userInfo.setAuthorizationClaims(claims, "https://trips.ecovo.ca/roles")
+
+		if len(userInfo.Roles) != 2 || userInfo.Roles[0] != RoleService || userInfo.Roles[1] != RoleAdmin {
+			t.Errorf("unexpected roles %v", userInfo.Roles)
+		}
+	})
+
+	t.Run("Should extract roles from verified tokens", func(t *testing.T) {
+		key := newRSATestKey(t, "rsa")
+		validator, _, closeServer := newTestJWKSValidator(t, key)
+		defer closeServer()
+
+		claims := validClaims()
+		claims[DefaultRolesClaim] = []string{RoleService}
+
+		userInfo, err := validator.Validate(key.sign(t, claims))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !userInfo.HasPermission(PermissionReadUsers) {
+			t.Fail()
+		}
+	})
+}
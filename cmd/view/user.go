@@ -0,0 +1,148 @@
+// Package view contains the representations of the entities returned by the
+// service's endpoints.
+package view
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// NewUser creates the representation of a user as seen by the caller. The user
+// itself and the callers allowed to read any user see the private profile,
+// while anyone else only sees the public profile.
+func NewUser(u *entity.User, vehicules []*entity.Vehicule, userInfo *auth.UserInfo) interface{} {
+	if CanSeePrivateProfile(u, userInfo) {
+		return NewPrivateUser(u, vehicules)
+	}
+
+	p := NewPublicUser(u)
+	p.Vehicules = vehicules
+
+	return p
+}
+
+// CanSeePrivateProfile returns whether the caller can see the private profile
+// of a user.
+func CanSeePrivateProfile(u *entity.User, userInfo *auth.UserInfo) bool {
+	if userInfo == nil {
+		return false
+	}
+
+	if userInfo.SubID != "" && userInfo.SubID == u.SubID {
+		return true
+	}
+
+	return userInfo.HasPermission(auth.PermissionReadUsers)
+}
+
+// A PrivateUser is the representation of a user's entire profile, including
+// its contact information, which can only be seen by the user itself and by
+// privileged callers.
+type PrivateUser struct {
+	*entity.User
+	Vehicules []*entity.Vehicule `json:"vehicules"`
+}
+
+// NewPrivateUser creates the private profile of a user.
+func NewPrivateUser(u *entity.User, vehicules []*entity.Vehicule) *PrivateUser {
+	if vehicules == nil {
+		vehicules = make([]*entity.Vehicule, 0)
+	}
+
+	return &PrivateUser{User: u, Vehicules: vehicules}
+}
+
+// A PublicUser is the representation of a user's public profile, which can be
+// seen by anyone. It never contains the user's contact information or exact
+// date of birth. The user's vehicules are only part of the profile when it is
+// looked up on its own, not when searching the user directory.
+type PublicUser struct {
+	ID             entity.ID             `json:"id"`
+	FirstName      string                `json:"firstName"`
+	LastInitial    string                `json:"lastInitial"`
+	Photo          string                `json:"photo"`
+	Description    string                `json:"description"`
+	Preferences    *entity.Preferences   `json:"preferences"`
+	AgeBracket     string                `json:"ageBracket"`
+	UserRating     *entity.RatingSummary `json:"userRating"`
+	DriverRating   *entity.RatingSummary `json:"driverRating"`
+	VerifiedDriver bool                  `json:"verifiedDriver"`
+	Vehicules      []*entity.Vehicule    `json:"vehicules,omitempty"`
+}
+
+// NewPublicUser creates the public profile of a user.
+func NewPublicUser(u *entity.User) *PublicUser {
+	lastInitial := ""
+	if r, _ := utf8.DecodeRuneInString(u.LastName); r != utf8.RuneError {
+		lastInitial = string(r) + "."
+	}
+
+	return &PublicUser{
+		ID:             u.ID,
+		FirstName:      u.FirstName,
+		LastInitial:    lastInitial,
+		Photo:          u.Photo,
+		Description:    u.Description,
+		Preferences:    u.Preferences,
+		AgeBracket:     AgeBracket(u.DateOfBirth, time.Now()),
+		UserRating:     u.UserRating,
+		DriverRating:   u.DriverRating,
+		VerifiedDriver: u.VerifiedDriver,
+	}
+}
+
+// NewPublicUsers creates the public profiles of multiple users.
+func NewPublicUsers(users []*entity.User) []*PublicUser {
+	publicUsers := make([]*PublicUser, 0, len(users))
+	for _, u := range users {
+		publicUsers = append(publicUsers, NewPublicUser(u))
+	}
+
+	return publicUsers
+}
+
+// ageBrackets contains the lower bound of each age bracket, from the oldest to
+// the youngest.
+var ageBrackets = []struct {
+	minimum int
+	name    string
+}{
+	{65, "65+"},
+	{55, "55-64"},
+	{45, "45-54"},
+	{35, "35-44"},
+	{25, "25-34"},
+	{18, "18-24"},
+}
+
+// AgeBracket returns the age bracket (ex. 25-34) of a person born on the given
+// date at the given time. An empty bracket is returned when the date of birth
+// is unknown or the person is younger than 18.
+func AgeBracket(dateOfBirth time.Time, now time.Time) string {
+	if dateOfBirth.IsZero() {
+		return ""
+	}
+
+	age := now.Year() - dateOfBirth.Year()
+	if now.Month() < dateOfBirth.Month() || (now.Month() == dateOfBirth.Month() && now.Day() < dateOfBirth.Day()) {
+		age--
+	}
+
+	for _, bracket := range ageBrackets {
+		if age >= bracket.minimum {
+			return bracket.name
+		}
+	}
+
+	return ""
+}
+
+// A UserPage is the representation of a page of users found in the user
+// directory.
+type UserPage struct {
+	Users []*PublicUser `json:"users"`
+	Next  string        `json:"next,omitempty"`
+}
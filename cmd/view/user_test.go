@@ -0,0 +1,92 @@
+package view
+
+import (
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/cmd/middleware/auth"
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+func TestAgeBracket(t *testing.T) {
+	now := time.Date(2019, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		dateOfBirth time.Time
+		expected    string
+	}{
+		{time.Time{}, ""},
+		{time.Date(2002, time.March, 16, 0, 0, 0, 0, time.UTC), ""},
+		{time.Date(2001, time.March, 15, 0, 0, 0, 0, time.UTC), "18-24"},
+		{time.Date(1994, time.March, 16, 0, 0, 0, 0, time.UTC), "18-24"},
+		{time.Date(1994, time.March, 15, 0, 0, 0, 0, time.UTC), "25-34"},
+		{time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC), "35-44"},
+		{time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC), "45-54"},
+		{time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC), "55-64"},
+		{time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC), "65+"},
+	}
+	for _, test := range tests {
+		if bracket := AgeBracket(test.dateOfBirth, now); bracket != test.expected {
+			t.Errorf("expected %q for %s, got %q", test.expected, test.dateOfBirth.Format("2006-01-02"), bracket)
+		}
+	}
+}
+
+func TestNewPublicUser(t *testing.T) {
+	u := NewPublicUser(&entity.User{
+		FirstName:   "Harold",
+		LastName:    "Émile",
+		Email:       "harold@hide-the-pain.meme",
+		PhoneNumber: "(450) 123-4567",
+		DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+	})
+
+	if u.LastInitial != "É." {
+		t.Errorf("expected last initial %q, got %q", "É.", u.LastInitial)
+	}
+
+	if u.AgeBracket != "65+" {
+		t.Errorf("expected age bracket %q, got %q", "65+", u.AgeBracket)
+	}
+}
+
+func TestNewUser(t *testing.T) {
+	u := &entity.User{SubID: "harold|1", FirstName: "Harold", Email: "harold@hide-the-pain.meme"}
+	vehicules := []*entity.Vehicule{{Make: "Audi"}}
+
+	tests := []struct {
+		name     string
+		userInfo *auth.UserInfo
+		private  bool
+	}{
+		{"Should show the private profile to the user itself", &auth.UserInfo{SubID: "harold|1"}, true},
+		{"Should show the private profile to privileged callers", &auth.UserInfo{SubID: "trip|1", Roles: []string{auth.RoleService}}, true},
+		{"Should show the public profile to other users", &auth.UserInfo{SubID: "harold|2"}, false},
+		{"Should show the public profile to anonymous callers", nil, false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			switch v := NewUser(u, vehicules, test.userInfo).(type) {
+			case *PrivateUser:
+				if !test.private {
+					t.Fatal("expected public profile, got private profile")
+				}
+
+				if v.Email != u.Email || len(v.Vehicules) != 1 {
+					t.Errorf("unexpected private profile %+v", v)
+				}
+			case *PublicUser:
+				if test.private {
+					t.Fatal("expected private profile, got public profile")
+				}
+
+				if v.FirstName != u.FirstName || len(v.Vehicules) != 1 {
+					t.Errorf("unexpected public profile %+v", v)
+				}
+			default:
+				t.Fatalf("unexpected representation %T", v)
+			}
+		})
+	}
+}
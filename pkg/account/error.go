@@ -0,0 +1,28 @@
+package account
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to delete the account of another user.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "accountOfAnotherUser",
+	Detail: "not allowed to delete the account of another user",
+}
+
+// A RecentlyDeletedError is an error that represents that the account of a
+// subscription ID was deleted too recently for a new user to be registered
+// with it.
+type RecentlyDeletedError struct {
+	entity.BaseError
+}
+
+var recentlyDeleted = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "accountRecentlyDeleted",
+	Detail: "account was recently deleted, try again later",
+}
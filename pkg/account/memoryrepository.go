@@ -0,0 +1,52 @@
+package account
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on
+// tombstones kept in memory. It is safe for concurrent use and is meant to be
+// used in tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	tombstones map[string]*Tombstone
+}
+
+// NewMemoryRepository creates an empty in-memory tombstone repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{tombstones: make(map[string]*Tombstone)}
+}
+
+// FindBySubID retrieves the tombstone of the given subscription ID, if there
+// is one.
+func (r *MemoryRepository) FindBySubID(subID string) (*Tombstone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tombstones[subID]
+	if !ok {
+		return nil, fmt.Errorf("account.MemoryRepository: no tombstone found for subscription ID \"%s\" (%w)", subID, mongo.ErrNoDocuments)
+	}
+
+	c := *t
+	return &c, nil
+}
+
+// Save stores the tombstone in memory, replacing the tombstone of the same
+// subscription ID, if any.
+func (r *MemoryRepository) Save(t *Tombstone) error {
+	if t == nil {
+		return fmt.Errorf("account.MemoryRepository: failed to save tombstone (tombstone is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *t
+	r.tombstones[t.SubID] = &c
+
+	return nil
+}
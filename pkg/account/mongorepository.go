@@ -0,0 +1,100 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on
+// tombstones in a MongoDB collection. The tombstones are identified by their
+// subscription ID.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	SubID     string    `bson:"_id"`
+	UserID    string    `bson:"userId"`
+	DeletedAt time.Time `bson:"deletedAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+func newDocumentFromEntity(t *Tombstone) (*document, error) {
+	if t == nil {
+		return nil, fmt.Errorf("account.MongoRepository: entity is nil")
+	}
+
+	return &document{
+		t.SubID,
+		t.UserID.Hex(),
+		t.DeletedAt,
+		t.ExpiresAt,
+	}, nil
+}
+
+func (d document) Entity() *Tombstone {
+	return &Tombstone{
+		SubID:     d.SubID,
+		UserID:    entity.NewIDFromHex(d.UserID),
+		DeletedAt: d.DeletedAt,
+		ExpiresAt: d.ExpiresAt,
+	}
+}
+
+// NewMongoRepository creates a tombstone repository for a MongoDB collection
+// and makes sure expired tombstones are eventually removed.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("account.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("account.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindBySubID retrieves the tombstone of the given subscription ID, if there
+// is one.
+func (r *MongoRepository) FindBySubID(subID string) (*Tombstone, error) {
+	filter := bson.D{{Key: "_id", Value: subID}}
+	var d document
+	err := r.collection.FindOne(context.TODO(), filter).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("account.MongoRepository: no tombstone found for subscription ID \"%s\" (%w)", subID, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("account.MongoRepository: failed to find tombstone for subscription ID \"%s\" (%s)", subID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// Save stores the tombstone in the collection, replacing the tombstone of the
+// same subscription ID, if any.
+func (r *MongoRepository) Save(t *Tombstone) error {
+	d, err := newDocumentFromEntity(t)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: d.SubID}}
+	_, err = r.collection.ReplaceOne(context.TODO(), filter, d, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("account.MongoRepository: failed to save tombstone of subscription ID \"%s\" (%s)", t.SubID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package account
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on tombstones in a database. A subscription ID has at most one
+// tombstone. When a subscription ID has no tombstone, the error of its lookup
+// wraps mongo.ErrNoDocuments.
+type Repository interface {
+	FindBySubID(subID string) (*Tombstone, error)
+	Save(t *Tombstone) error
+}
@@ -0,0 +1,143 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves the lifecycle of user accounts.
+type UseCase interface {
+	Register(u *entity.User) (*entity.User, error)
+	Delete(ID entity.ID, caller *user.Caller) error
+}
+
+// Config contains the rules of the account deletions.
+type Config struct {
+	// GracePeriod specifies how long after an account is deleted its
+	// subscription ID cannot be used to register a new user.
+	//
+	// Zero means DefaultGracePeriod.
+	GracePeriod time.Duration
+}
+
+// DefaultGracePeriod represents the default amount of time during which the
+// subscription ID of a deleted account cannot be reused (30 days).
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+// A Service handles the business logic related to the lifecycle of user
+// accounts.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+	vRepo    vehicule.Repository
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates an account service that keeps the tombstones of deleted
+// accounts in a repository. Vehicules are removed directly from their
+// repository, since they may no longer be reached through their user once it
+// is deleted. A nil configuration means the default one.
+func NewService(repo Repository, uService user.UseCase, vRepo vehicule.Repository, config *Config) *Service {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+
+	if c.GracePeriod == 0 {
+		c.GracePeriod = DefaultGracePeriod
+	}
+
+	return &Service{repo, uService, vRepo, c, time.Now}
+}
+
+// Register registers a new user, unless the account of its subscription ID
+// was deleted during the grace period (see user.Service.Register). The user is
+// not registered when its tombstone cannot be looked up.
+func (s *Service) Register(u *entity.User) (*entity.User, error) {
+	if u == nil {
+		return nil, fmt.Errorf("account.Service: user is nil")
+	}
+
+	t, err := s.repo.FindBySubID(u.SubID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if err == nil && s.now().Before(t.ExpiresAt) {
+		return nil, RecentlyDeletedError{recentlyDeleted.New(fmt.Sprintf("account.Service: account of \"%s\" was deleted at %s and cannot be recreated before %s", u.SubID, t.DeletedAt.Format(time.RFC3339), t.ExpiresAt.Format(time.RFC3339)))}
+	}
+
+	return s.uService.Register(u)
+}
+
+// Delete deletes the account of the user with the given ID, even if it is
+// deactivated or suspended, along with its vehicules, and records a tombstone
+// so that its subscription ID cannot be used to register a new user during the
+// grace period. Unless the caller is privileged, it can only delete its own
+// account.
+//
+// The tombstone is recorded first and the user is deleted last, so that a
+// deletion that fails midway can safely be tried again.
+func (s *Service) Delete(ID entity.ID, caller *user.Caller) error {
+	if caller == nil {
+		return fmt.Errorf("account.Service: caller is nil")
+	}
+
+	u, err := s.uService.FindByID(ID, user.AccountStatuses...)
+	if err != nil {
+		return err
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return ForbiddenError{forbidden.New(fmt.Sprintf("account.Service: cannot delete the account of another user \"%s\"", ID))}
+	}
+
+	now := s.now()
+	err = s.repo.Save(&Tombstone{
+		SubID:     u.SubID,
+		UserID:    u.ID,
+		DeletedAt: now,
+		ExpiresAt: now.Add(s.config.GracePeriod),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteVehicules(u.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.uService.Delete(u.ID)
+}
+
+// deleteVehicules removes every vehicule of the user with the given ID. The
+// first page is listed until it is empty, since deleting its vehicules makes
+// the next ones move up.
+func (s *Service) deleteVehicules(userID entity.ID) error {
+	for {
+		p, err := s.vRepo.FindByUserID(userID, &vehicule.Query{Limit: vehicule.MaxLimit})
+		if err != nil {
+			return err
+		}
+
+		for _, v := range p.Vehicules {
+			err = s.vRepo.Delete(v.ID, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if p.Next == "" {
+			return nil
+		}
+	}
+}
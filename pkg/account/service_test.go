@@ -0,0 +1,193 @@
+package account
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+)
+
+type testServices struct {
+	s        *Service
+	uService *user.Service
+	vRepo    vehicule.Repository
+	now      time.Time
+}
+
+func newTestServices(t *testing.T) *testServices {
+	ts := &testServices{
+		uService: user.NewService(user.NewMemoryRepository()),
+		vRepo:    vehicule.NewMemoryRepository(),
+		now:      time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	ts.s = NewService(NewMemoryRepository(), ts.uService, ts.vRepo, &Config{GracePeriod: 24 * time.Hour})
+	ts.s.now = func() time.Time { return ts.now }
+
+	return ts
+}
+
+func newTestUser(subID string) *entity.User {
+	return &entity.User{
+		SubID:       subID,
+		FirstName:   "Harold",
+		LastName:    "The Great",
+		DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+		Gender:      entity.GenderMale,
+	}
+}
+
+// register registers a user with the given number of vehicules.
+func (ts *testServices) register(t *testing.T, subID string, vehicules int) *entity.User {
+	u, err := ts.s.Register(newTestUser(subID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < vehicules; i++ {
+		_, err = ts.vRepo.Create(&entity.Vehicule{UserID: u.ID, Year: 2015, Make: "Audi", Model: "A4", Color: "red", Seats: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return u
+}
+
+// countVehicules returns the number of vehicules of the user with the given
+// ID.
+func (ts *testServices) countVehicules(t *testing.T, userID entity.ID) int {
+	p, err := ts.vRepo.FindByUserID(userID, &vehicule.Query{Limit: vehicule.MaxLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return len(p.Vehicules)
+}
+
+func TestServiceDelete(t *testing.T) {
+	t.Run("Should delete the user and every one of its vehicules", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1", vehicule.MaxLimit+5)
+		maurice := ts.register(t, "maurice|1", 1)
+
+		err := ts.s.Delete(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ts.uService.FindByID(harold.ID); err == nil {
+			t.Error("expected user to be deleted")
+		}
+
+		if n := ts.countVehicules(t, harold.ID); n != 0 {
+			t.Errorf("expected every vehicule to be deleted, %d are left", n)
+		}
+
+		if n := ts.countVehicules(t, maurice.ID); n != 1 {
+			t.Errorf("expected the vehicules of other users to be kept, %d are left", n)
+		}
+	})
+
+	t.Run("Should let privileged callers delete any user", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1", 0)
+
+		err := ts.s.Delete(harold.ID, &user.Caller{SubID: "admin|1", Privileged: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when deleting another user", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1", 1)
+
+		err := ts.s.Delete(harold.ID, &user.Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+
+		if n := ts.countVehicules(t, harold.ID); n != 1 {
+			t.Errorf("expected vehicules to be kept, %d are left", n)
+		}
+
+		if _, err := ts.s.Register(newTestUser("harold|2")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should fail with not found error when user does not exist", func(t *testing.T) {
+		ts := newTestServices(t)
+
+		err := ts.s.Delete(entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3"), &user.Caller{SubID: "harold|1"})
+		if _, ok := err.(user.NotFoundError); !ok {
+			t.Errorf("expected user.NotFoundError, got %v", err)
+		}
+	})
+}
+
+func TestServiceRegister(t *testing.T) {
+	t.Run("Should fail with recently deleted error during the grace period", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1", 0)
+
+		err := ts.s.Delete(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ts.now = ts.now.Add(23 * time.Hour)
+		_, err = ts.s.Register(newTestUser("harold|1"))
+		if _, ok := err.(RecentlyDeletedError); !ok {
+			t.Errorf("expected RecentlyDeletedError, got %v", err)
+		}
+	})
+
+	t.Run("Should register the subscription ID again after the grace period", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1", 0)
+
+		err := ts.s.Delete(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ts.now = ts.now.Add(24 * time.Hour)
+		u, err := ts.s.Register(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID == harold.ID {
+			t.Error("expected a new user to be registered")
+		}
+	})
+
+	t.Run("Should not register the user when its tombstone cannot be looked up", func(t *testing.T) {
+		ts := newTestServices(t)
+		repoErr := errors.New("account.MongoRepository: server selection timeout")
+		ts.s.repo = &failingRepository{ts.s.repo, repoErr}
+
+		_, err := ts.s.Register(newTestUser("harold|1"))
+		if err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+
+		if _, err := ts.uService.FindBySubID("harold|1"); err == nil {
+			t.Error("expected user not to be registered")
+		}
+	})
+}
+
+// failingRepository is a repository whose lookups always fail.
+type failingRepository struct {
+	Repository
+	err error
+}
+
+func (r *failingRepository) FindBySubID(subID string) (*Tombstone, error) {
+	return nil, r.err
+}
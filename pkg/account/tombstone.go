@@ -0,0 +1,18 @@
+// Package account deletes user accounts along with everything that belongs to
+// them, and keeps deleted accounts from being silently recreated.
+package account
+
+import (
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A Tombstone records that the account of a subscription ID was deleted. Until
+// it expires, the subscription ID cannot be used to register a new user.
+type Tombstone struct {
+	SubID     string
+	UserID    entity.ID
+	DeletedAt time.Time
+	ExpiresAt time.Time
+}
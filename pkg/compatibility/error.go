@@ -0,0 +1,27 @@
+package compatibility
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to compute the compatibility of another user.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "compatibilityOfAnotherUser",
+	Detail: "not allowed to compute the compatibility of another user",
+}
+
+// An InvalidCandidatesError is an error that represents that the candidates
+// whose compatibility is requested are missing or too many.
+type InvalidCandidatesError struct {
+	entity.BaseError
+}
+
+var invalidCandidates = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "invalidQuery",
+	Field: "candidates",
+}
@@ -0,0 +1,257 @@
+// Package compatibility scores how well two users would get along during a
+// ride, from their preferences, their gender comfort and their ratings. The
+// scoring itself only depends on the users, so that any service matching
+// drivers with riders can rely on it.
+package compatibility
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A Result is the compatibility of a user with another one. The score goes
+// from 0 (incompatible) to 100 (perfect match) and is the weighted average of
+// the scores of the dimensions on which the users were compared.
+type Result struct {
+	UserID     entity.ID    `json:"userId"`
+	OtherID    entity.ID    `json:"otherId"`
+	Score      int          `json:"score"`
+	Compatible bool         `json:"compatible"`
+	Dimensions []*Dimension `json:"dimensions"`
+}
+
+// A Dimension is one of the aspects on which two users are compared, such as
+// one of their preferences. Its score goes from 0 (opposite) to 1
+// (identical), and its explanation is meant to be displayed.
+type Dimension struct {
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+	Weight      float64 `json:"weight"`
+	Explanation string  `json:"explanation"`
+}
+
+const (
+	// DimensionGender is the name of the dimension that compares the users'
+	// genders with their gender comfort.
+	DimensionGender = "gender"
+
+	// DimensionRatings is the name of the dimension that compares the
+	// ratings of the users.
+	DimensionRatings = "ratings"
+)
+
+const (
+	// preferenceWeight represents the weight of a preference whose weight is
+	// not in preferenceWeights.
+	preferenceWeight = 1
+
+	// genderWeight represents the weight of the gender dimension.
+	genderWeight = 2
+
+	// ratingsWeight represents the weight of the ratings dimension.
+	ratingsWeight = 2
+
+	// unratedScore represents the score of a user that was never reviewed,
+	// which is neither trusted nor distrusted.
+	unratedScore = 0.5
+)
+
+// preferenceWeights contains the weights of the preferences that matter more
+// than the others when sharing a ride.
+var preferenceWeights = map[entity.PreferenceKey]float64{
+	entity.PreferenceSmoking: 3,
+	entity.PreferencePets:    2,
+}
+
+// Score computes the compatibility of a user with another one. The users are
+// compared on every preference of the catalog that both of them expressed, on
+// their gender comfort and on their ratings. Users are incompatible, with a
+// score of 0, when one of them is only comfortable riding with users of its
+// own gender and the other one is of another gender.
+func Score(u *entity.User, other *entity.User) *Result {
+	r := &Result{UserID: u.ID, OtherID: other.ID, Compatible: true}
+
+	r.Dimensions = append(r.Dimensions, preferenceDimensions(u.Preferences, other.Preferences)...)
+
+	gender := genderDimension(u, other)
+	r.Compatible = gender.Score > 0
+	r.Dimensions = append(r.Dimensions, gender, ratingsDimension(u, other))
+
+	if !r.Compatible {
+		return r
+	}
+
+	var total, weights float64
+	for _, d := range r.Dimensions {
+		total += d.Score * d.Weight
+		weights += d.Weight
+	}
+	r.Score = int(math.Round(100 * total / weights))
+
+	return r
+}
+
+// preferenceDimensions compares the preferences of the catalog that both
+// users expressed. Preferences that are on a scale are scored on how far
+// apart they are, while the others are either identical or not. The gender
+// comfort is left to the gender dimension.
+func preferenceDimensions(p *entity.Preferences, other *entity.Preferences) []*Dimension {
+	if p == nil || other == nil {
+		return nil
+	}
+
+	var dimensions []*Dimension
+	for i := range entity.PreferenceCatalog {
+		d := &entity.PreferenceCatalog[i]
+		if d.Key == entity.PreferenceSameGender {
+			continue
+		}
+
+		value, ok := p.Get(d.Key)
+		otherValue, otherOK := other.Get(d.Key)
+		if !ok || !otherOK {
+			continue
+		}
+
+		weight, ok := preferenceWeights[d.Key]
+		if !ok {
+			weight = preferenceWeight
+		}
+
+		dimension := &Dimension{Name: string(d.Key), Weight: weight}
+		if value == otherValue {
+			dimension.Score = 1
+			dimension.Explanation = fmt.Sprintf("Both: %s", valueName(d, value))
+		} else {
+			if span := valueSpan(d); d.Kind == entity.PreferenceKindScale && span > 0 {
+				dimension.Score = 1 - math.Abs(float64(value-otherValue))/float64(span)
+			}
+			dimension.Explanation = fmt.Sprintf("%s vs %s", valueName(d, value), valueName(d, otherValue))
+		}
+
+		dimensions = append(dimensions, dimension)
+	}
+
+	return dimensions
+}
+
+// valueName returns the name of a value of a preference, or the value itself
+// when it is not part of the catalog.
+func valueName(d *entity.PreferenceDefinition, value int) string {
+	for _, v := range d.Values {
+		if v.Value == value {
+			return v.Name
+		}
+	}
+
+	return fmt.Sprint(value)
+}
+
+// valueSpan returns the difference between the largest and the smallest
+// values of a preference.
+func valueSpan(d *entity.PreferenceDefinition) int {
+	if len(d.Values) == 0 {
+		return 0
+	}
+
+	lowest, highest := d.Values[0].Value, d.Values[0].Value
+	for _, v := range d.Values[1:] {
+		lowest = min(lowest, v.Value)
+		highest = max(highest, v.Value)
+	}
+
+	return highest - lowest
+}
+
+// genderDimension makes sure that neither user is uncomfortable with the
+// gender of the other one.
+func genderDimension(u *entity.User, other *entity.User) *Dimension {
+	dimension := &Dimension{Name: DimensionGender, Score: 1, Weight: genderWeight}
+
+	var uncomfortable []string
+	for _, pair := range [][2]*entity.User{{u, other}, {other, u}} {
+		if wantsSameGender(pair[0]) && pair[0].Gender != pair[1].Gender {
+			uncomfortable = append(uncomfortable, pair[0].FirstName)
+		}
+	}
+
+	switch {
+	case len(uncomfortable) > 0:
+		verb := "rides"
+		if len(uncomfortable) > 1 {
+			verb = "ride"
+		}
+
+		dimension.Score = 0
+		dimension.Explanation = fmt.Sprintf("%s only %s with users of the same gender", strings.Join(uncomfortable, " and "), verb)
+	case wantsSameGender(u) || wantsSameGender(other):
+		dimension.Explanation = "Same gender"
+	default:
+		dimension.Explanation = "No gender preference"
+	}
+
+	return dimension
+}
+
+// wantsSameGender returns whether the user is only comfortable riding with
+// users of its own gender.
+func wantsSameGender(u *entity.User) bool {
+	if u.Preferences == nil {
+		return false
+	}
+
+	value, ok := u.Preferences.Get(entity.PreferenceSameGender)
+
+	return ok && value == 1
+}
+
+// ratingsDimension scores the users on the reviews they received, both as a
+// user and as a driver. A ride is only as pleasant as its least appreciated
+// user, so the dimension's score is the one of the user with the lowest
+// rating.
+func ratingsDimension(u *entity.User, other *entity.User) *Dimension {
+	dimension := &Dimension{Name: DimensionRatings, Score: 1, Weight: ratingsWeight}
+
+	var explanations []string
+	for _, rated := range []*entity.User{u, other} {
+		rating := overallRating(rated)
+
+		score := unratedScore
+		if rating.Count == 0 {
+			explanations = append(explanations, fmt.Sprintf("%s: not reviewed yet", rated.FirstName))
+		} else {
+			score = (rating.Average - entity.StarsMinimum) / (entity.StarsMaximum - entity.StarsMinimum)
+			reviews := "reviews"
+			if rating.Count == 1 {
+				reviews = "review"
+			}
+
+			explanations = append(explanations, fmt.Sprintf("%s: %.1f stars (%d %s)", rated.FirstName, rating.Average, rating.Count, reviews))
+		}
+
+		dimension.Score = math.Min(dimension.Score, score)
+	}
+	dimension.Explanation = strings.Join(explanations, ", ")
+
+	return dimension
+}
+
+// overallRating combines the reviews that a user received as a user and as a
+// driver.
+func overallRating(u *entity.User) *entity.RatingSummary {
+	var histogram [entity.StarsMaximum]int
+	for _, rating := range []*entity.RatingSummary{u.UserRating, u.DriverRating} {
+		if rating == nil {
+			continue
+		}
+
+		for i, count := range rating.Histogram {
+			histogram[i] += count
+		}
+	}
+
+	return entity.NewRatingSummary(histogram)
+}
@@ -0,0 +1,196 @@
+package compatibility
+
+import (
+	"math"
+	"testing"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+func newTestUser(firstName string, gender string, p *entity.Preferences) *entity.User {
+	return &entity.User{
+		FirstName:   firstName,
+		Gender:      gender,
+		Preferences: p,
+	}
+}
+
+// dimension returns the dimension of a result with the given name.
+func dimension(t *testing.T, r *Result, name string) *Dimension {
+	for _, d := range r.Dimensions {
+		if d.Name == name {
+			return d
+		}
+	}
+
+	t.Fatalf("expected dimension %s, got %+v", name, r.Dimensions)
+
+	return nil
+}
+
+func TestScorePreferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         entity.PreferenceKey
+		value       int
+		otherValue  int
+		score       float64
+		explanation string
+	}{
+		{"Should score identical preferences as 1", entity.PreferenceSmoking, entity.PreferenceNever, entity.PreferenceNever, 1, "Both: Never"},
+		{"Should score opposite preferences as 0", entity.PreferenceSmoking, entity.PreferenceNever, entity.PreferenceRegularly, 0, "Never vs Regularly"},
+		{"Should score close preferences by their distance", entity.PreferenceMusic, entity.PreferenceNever, entity.PreferenceOccasionally, 0.5, "Never vs Occasionally"},
+		{"Should score preferences on the span of their values", entity.PreferenceLuggage, 0, 1, 1 - 1.0/3, "No luggage vs Small bag"},
+		{"Should score different boolean preferences as 0", entity.PreferenceQuietRide, 0, 1, 0, "No vs Yes"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, otherP := &entity.Preferences{}, &entity.Preferences{}
+			p.Set(test.key, test.value)
+			otherP.Set(test.key, test.otherValue)
+
+			r := Score(newTestUser("Harold", entity.GenderMale, p), newTestUser("Maurice", entity.GenderMale, otherP))
+
+			d := dimension(t, r, string(test.key))
+			if math.Abs(d.Score-test.score) > 1e-9 || d.Explanation != test.explanation {
+				t.Errorf("expected score %v (%q), got %v (%q)", test.score, test.explanation, d.Score, d.Explanation)
+			}
+		})
+	}
+
+	t.Run("Should only compare the preferences that both users expressed", func(t *testing.T) {
+		p := &entity.Preferences{Others: map[string]int{"pets": 1, "food": 1}}
+		otherP := &entity.Preferences{Others: map[string]int{"pets": 2}}
+
+		r := Score(newTestUser("Harold", entity.GenderMale, p), newTestUser("Maurice", entity.GenderMale, otherP))
+
+		var names []string
+		for _, d := range r.Dimensions {
+			names = append(names, d.Name)
+		}
+
+		expected := []string{"smoking", "conversation", "music", "pets", DimensionGender, DimensionRatings}
+		if len(names) != len(expected) {
+			t.Fatalf("expected dimensions %v, got %v", expected, names)
+		}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Fatalf("expected dimensions %v, got %v", expected, names)
+			}
+		}
+	})
+
+	t.Run("Should skip preferences when a user has none", func(t *testing.T) {
+		r := Score(newTestUser("Harold", entity.GenderMale, nil), newTestUser("Maurice", entity.GenderMale, &entity.Preferences{}))
+
+		if len(r.Dimensions) != 2 {
+			t.Errorf("expected only the gender and ratings dimensions, got %+v", r.Dimensions)
+		}
+	})
+}
+
+func TestScoreGender(t *testing.T) {
+	sameGender := func() *entity.Preferences {
+		return &entity.Preferences{Others: map[string]int{string(entity.PreferenceSameGender): 1}}
+	}
+
+	tests := []struct {
+		name        string
+		u           *entity.User
+		other       *entity.User
+		compatible  bool
+		explanation string
+	}{
+		{"Should be compatible without gender preference", newTestUser("Harold", entity.GenderMale, nil), newTestUser("Lisa", entity.GenderFemale, nil), true, "No gender preference"},
+		{"Should be compatible when genders are the same", newTestUser("Lisa", entity.GenderFemale, sameGender()), newTestUser("Maude", entity.GenderFemale, nil), true, "Same gender"},
+		{"Should be incompatible when the user is uncomfortable", newTestUser("Lisa", entity.GenderFemale, sameGender()), newTestUser("Harold", entity.GenderMale, nil), false, "Lisa only rides with users of the same gender"},
+		{"Should be incompatible when the other user is uncomfortable", newTestUser("Harold", entity.GenderMale, nil), newTestUser("Lisa", entity.GenderFemale, sameGender()), false, "Lisa only rides with users of the same gender"},
+		{"Should name both users when both are uncomfortable", newTestUser("Harold", entity.GenderMale, sameGender()), newTestUser("Lisa", entity.GenderFemale, sameGender()), false, "Harold and Lisa only ride with users of the same gender"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := Score(test.u, test.other)
+
+			if r.Compatible != test.compatible {
+				t.Errorf("expected compatible to be %v", test.compatible)
+			}
+
+			if !r.Compatible && r.Score != 0 {
+				t.Errorf("expected incompatible users to have a score of 0, got %d", r.Score)
+			}
+
+			if d := dimension(t, r, DimensionGender); d.Explanation != test.explanation {
+				t.Errorf("expected explanation %q, got %q", test.explanation, d.Explanation)
+			}
+		})
+	}
+}
+
+func TestScoreRatings(t *testing.T) {
+	rated := func(firstName string, userHistogram [entity.StarsMaximum]int, driverHistogram [entity.StarsMaximum]int) *entity.User {
+		u := newTestUser(firstName, entity.GenderMale, nil)
+		u.UserRating = entity.NewRatingSummary(userHistogram)
+		u.DriverRating = entity.NewRatingSummary(driverHistogram)
+
+		return u
+	}
+
+	tests := []struct {
+		name        string
+		u           *entity.User
+		other       *entity.User
+		score       float64
+		explanation string
+	}{
+		{"Should score unrated users as neutral", newTestUser("Harold", entity.GenderMale, nil), newTestUser("Maurice", entity.GenderMale, nil), unratedScore, "Harold: not reviewed yet, Maurice: not reviewed yet"},
+		{"Should combine user and driver reviews", rated("Harold", [5]int{0, 0, 0, 0, 1}, [5]int{0, 0, 1, 0, 0}), rated("Maurice", [5]int{0, 0, 0, 0, 2}, [5]int{}), 0.75, "Harold: 4.0 stars (2 reviews), Maurice: 5.0 stars (2 reviews)"},
+		{"Should score the least appreciated user", rated("Harold", [5]int{1, 0, 0, 0, 0}, [5]int{}), newTestUser("Maurice", entity.GenderMale, nil), 0, "Harold: 1.0 stars (1 review), Maurice: not reviewed yet"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			d := dimension(t, Score(test.u, test.other), DimensionRatings)
+
+			if d.Score != test.score || d.Explanation != test.explanation {
+				t.Errorf("expected score %v (%q), got %v (%q)", test.score, test.explanation, d.Score, d.Explanation)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	t.Run("Should be the weighted average of the dimensions", func(t *testing.T) {
+		p := &entity.Preferences{Smoking: entity.PreferenceNever, Conversation: entity.PreferenceRegularly, Music: entity.PreferenceOccasionally}
+		otherP := &entity.Preferences{Smoking: entity.PreferenceRegularly, Conversation: entity.PreferenceRegularly, Music: entity.PreferenceOccasionally}
+
+		r := Score(newTestUser("Harold", entity.GenderMale, p), newTestUser("Maurice", entity.GenderMale, otherP))
+
+		// smoking 0*3, conversation 1*1, music 1*1, gender 1*2, ratings 0.5*2
+		expected := int(math.Round(100 * 5 / 9.0))
+		if r.Score != expected {
+			t.Errorf("expected score %d, got %d", expected, r.Score)
+		}
+	})
+
+	t.Run("Should be symmetric", func(t *testing.T) {
+		u := newTestUser("Harold", entity.GenderMale, &entity.Preferences{Smoking: 1, Others: map[string]int{"luggage": 3}})
+		other := newTestUser("Maurice", entity.GenderMale, &entity.Preferences{Music: 2, Others: map[string]int{"luggage": 0}})
+
+		if Score(u, other).Score != Score(other, u).Score {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should be 100 for a perfect match", func(t *testing.T) {
+		u := newTestUser("Harold", entity.GenderMale, &entity.Preferences{})
+		u.UserRating = entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 0, 3})
+		other := newTestUser("Maurice", entity.GenderMale, &entity.Preferences{})
+		other.DriverRating = entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 0, 1})
+
+		if r := Score(u, other); r.Score != 100 || !r.Compatible {
+			t.Errorf("expected a perfect match, got %+v", r)
+		}
+	})
+}
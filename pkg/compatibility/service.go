@@ -0,0 +1,115 @@
+package compatibility
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// UseCase is an interface representing the ability to compute the
+// compatibility of users.
+type UseCase interface {
+	Score(userID entity.ID, otherID entity.ID, caller *user.Caller) (*Result, error)
+	ScoreCandidates(userID entity.ID, candidateIDs []entity.ID, caller *user.Caller) (*Ranking, error)
+}
+
+// A Ranking contains the compatibility of a user with each of the candidates
+// that exist, from the most to the least compatible, as well as the
+// candidates that do not exist.
+type Ranking struct {
+	Results  []*Result   `json:"results"`
+	NotFound []entity.ID `json:"notFound"`
+}
+
+// MaxCandidates represents the maximum number of candidates whose
+// compatibility can be computed at once.
+const MaxCandidates = 100
+
+// A Service computes the compatibility of the users of a user service.
+type Service struct {
+	uService user.UseCase
+}
+
+// NewService creates a compatibility service that looks up users through a
+// user service.
+func NewService(uService user.UseCase) *Service {
+	return &Service{uService}
+}
+
+// Score computes the compatibility of a user with another one. Unless the
+// caller is privileged, it can only compute its own compatibility.
+func (s *Service) Score(userID entity.ID, otherID entity.ID, caller *user.Caller) (*Result, error) {
+	u, err := s.findUser(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	other, err := s.uService.FindByID(otherID)
+	if err != nil {
+		return nil, err
+	}
+
+	return Score(u, other), nil
+}
+
+// ScoreCandidates computes the compatibility of a user with each of the
+// candidates, and ranks them from the most to the least compatible. Unless
+// the caller is privileged, it can only compute its own compatibility.
+func (s *Service) ScoreCandidates(userID entity.ID, candidateIDs []entity.ID, caller *user.Caller) (*Ranking, error) {
+	if len(candidateIDs) == 0 {
+		return nil, InvalidCandidatesError{invalidCandidates.New("compatibility.Service: candidates are missing")}
+	}
+
+	if len(candidateIDs) > MaxCandidates {
+		return nil, InvalidCandidatesError{invalidCandidates.New(fmt.Sprintf("compatibility.Service: at most %d candidates can be scored at once", MaxCandidates))}
+	}
+
+	u, err := s.findUser(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking := &Ranking{
+		Results:  make([]*Result, 0, len(candidateIDs)),
+		NotFound: make([]entity.ID, 0),
+	}
+	for _, candidateID := range candidateIDs {
+		candidate, err := s.uService.FindByID(candidateID)
+		if errors.As(err, &user.NotFoundError{}) {
+			ranking.NotFound = append(ranking.NotFound, candidateID)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		ranking.Results = append(ranking.Results, Score(u, candidate))
+	}
+
+	sort.SliceStable(ranking.Results, func(i, j int) bool {
+		return ranking.Results[i].Score > ranking.Results[j].Score
+	})
+
+	return ranking, nil
+}
+
+// findUser retrieves the user whose compatibility is computed, making sure
+// that the caller is allowed to compute it.
+func (s *Service) findUser(userID entity.ID, caller *user.Caller) (*entity.User, error) {
+	if caller == nil {
+		return nil, fmt.Errorf("compatibility.Service: caller is nil")
+	}
+
+	u, err := s.uService.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("compatibility.Service: cannot compute the compatibility of another user \"%s\"", userID))}
+	}
+
+	return u, nil
+}
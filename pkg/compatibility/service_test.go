@@ -0,0 +1,122 @@
+package compatibility
+
+import (
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+func newTestService(t *testing.T) (*Service, []*entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+
+	var users []*entity.User
+	for _, u := range []struct {
+		subID       string
+		gender      string
+		preferences *entity.Preferences
+	}{
+		{"harold|1", entity.GenderMale, &entity.Preferences{Smoking: entity.PreferenceNever}},
+		{"maurice|1", entity.GenderMale, &entity.Preferences{Smoking: entity.PreferenceRegularly}},
+		{"lisa|1", entity.GenderFemale, &entity.Preferences{Others: map[string]int{string(entity.PreferenceSameGender): 1}}},
+		{"maude|1", entity.GenderFemale, &entity.Preferences{Smoking: entity.PreferenceNever}},
+	} {
+		registered, err := uService.Register(&entity.User{
+			SubID:       u.subID,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      u.gender,
+			Preferences: u.preferences,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users = append(users, registered)
+	}
+
+	return NewService(uService), users
+}
+
+func TestServiceScore(t *testing.T) {
+	s, users := newTestService(t)
+	harold, maurice := users[0], users[1]
+
+	t.Run("Should score the compatibility with another user", func(t *testing.T) {
+		r, err := s.Score(harold.ID, maurice.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.UserID != harold.ID || r.OtherID != maurice.ID || !r.Compatible {
+			t.Errorf("unexpected result %+v", r)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when scoring another user", func(t *testing.T) {
+		_, err := s.Score(harold.ID, maurice.ID, &user.Caller{SubID: maurice.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+
+	t.Run("Should let privileged callers score any user", func(t *testing.T) {
+		_, err := s.Score(harold.ID, maurice.ID, &user.Caller{SubID: "matching|1", Privileged: true})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should fail with not found error when other user does not exist", func(t *testing.T) {
+		_, err := s.Score(harold.ID, entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3"), &user.Caller{SubID: harold.SubID})
+		if _, ok := err.(user.NotFoundError); !ok {
+			t.Errorf("expected user.NotFoundError, got %v", err)
+		}
+	})
+}
+
+func TestServiceScoreCandidates(t *testing.T) {
+	s, users := newTestService(t)
+	harold, maurice, lisa, maude := users[0], users[1], users[2], users[3]
+	missing := entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3")
+
+	t.Run("Should rank the candidates from the most to the least compatible", func(t *testing.T) {
+		ranking, err := s.ScoreCandidates(harold.ID, []entity.ID{lisa.ID, maurice.ID, missing, maude.ID}, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []entity.ID{maude.ID, maurice.ID, lisa.ID}
+		if len(ranking.Results) != len(expected) {
+			t.Fatalf("expected %d results, got %d", len(expected), len(ranking.Results))
+		}
+		for i, r := range ranking.Results {
+			if r.OtherID != expected[i] {
+				t.Errorf("expected %s at rank %d, got %s", expected[i], i, r.OtherID)
+			}
+		}
+
+		if len(ranking.NotFound) != 1 || ranking.NotFound[0] != missing {
+			t.Errorf("expected %s to be reported as not found, got %v", missing, ranking.NotFound)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		candidates []entity.ID
+	}{
+		{"Should fail when candidates are missing", nil},
+		{"Should fail when there are too many candidates", make([]entity.ID, MaxCandidates+1)},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := s.ScoreCandidates(harold.ID, test.candidates, &user.Caller{SubID: harold.SubID})
+			if _, ok := err.(InvalidCandidatesError); !ok {
+				t.Errorf("expected InvalidCandidatesError, got %v", err)
+			}
+		})
+	}
+}
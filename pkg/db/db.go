@@ -64,11 +64,25 @@ type DB struct {
 	client    *mongo.Client
 	Users     *mongo.Collection
 	Vehicules *mongo.Collection
+	Reviews   *mongo.Collection
+
+	PhoneVerifications *mongo.Collection
+	EmailChanges       *mongo.Collection
+	Documents          *mongo.Collection
+	Tombstones         *mongo.Collection
+	Exports            *mongo.Collection
 }
 
 const (
 	userCollectionName     = "users"
 	vehiculeCollectionName = "vehicules"
+	reviewCollectionName   = "reviews"
+
+	phoneVerificationCollectionName = "phoneVerifications"
+	emailChangeCollectionName       = "emailChanges"
+	documentCollectionName          = "documents"
+	tombstoneCollectionName         = "tombstones"
+	exportCollectionName            = "exports"
 )
 
 // New creates a database by establishing a connection to the database server
@@ -117,5 +131,35 @@ func New(conf *Config) (*DB, error) {
 		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", vehiculeCollectionName)
 	}
 
-	return &DB{client, users, vehicules}, nil
+	reviews := db.Collection(reviewCollectionName)
+	if reviews == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", reviewCollectionName)
+	}
+
+	phoneVerifications := db.Collection(phoneVerificationCollectionName)
+	if phoneVerifications == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", phoneVerificationCollectionName)
+	}
+
+	emailChanges := db.Collection(emailChangeCollectionName)
+	if emailChanges == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", emailChangeCollectionName)
+	}
+
+	documents := db.Collection(documentCollectionName)
+	if documents == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", documentCollectionName)
+	}
+
+	tombstones := db.Collection(tombstoneCollectionName)
+	if tombstones == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", tombstoneCollectionName)
+	}
+
+	exports := db.Collection(exportCollectionName)
+	if exports == nil {
+		return nil, fmt.Errorf("db: no collection found with name \"%s\" in database", exportCollectionName)
+	}
+
+	return &DB{client, users, vehicules, reviews, phoneVerifications, emailChanges, documents, tombstones, exports}, nil
 }
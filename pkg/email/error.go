@@ -0,0 +1,64 @@
+package email
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// An InvalidAddressError is an error that represents that the new email is not
+// a valid email address.
+type InvalidAddressError struct {
+	entity.BaseError
+}
+
+var invalidAddress = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  entity.CodeMalformed,
+	Field: "email",
+}
+
+// An AlreadyVerifiedError is an error that represents that the user already
+// uses the email and it is verified.
+type AlreadyVerifiedError struct {
+	entity.BaseError
+}
+
+var alreadyVerified = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "emailAlreadyVerified",
+	Detail: "email is already verified",
+}
+
+// An InvalidTokenError is an error that represents that a confirmation token
+// is malformed, was not signed by the service, or was issued for another user
+// or email.
+type InvalidTokenError struct {
+	entity.BaseError
+}
+
+var invalidToken = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "emailTokenInvalid",
+	Field: "token",
+}
+
+// An ExpiredTokenError is an error that represents that a confirmation token
+// expired.
+type ExpiredTokenError struct {
+	entity.BaseError
+}
+
+var expiredToken = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "emailTokenExpired",
+	Field: "token",
+}
+
+// A TooSoonError is an error that represents that the user requested a change
+// too recently to send another confirmation token.
+type TooSoonError struct {
+	entity.BaseError
+}
+
+var tooSoon = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindTooManyRequests,
+	Code:   "emailChangeRecentlyRequested",
+	Detail: "a change was requested recently, wait before requesting another one",
+}
@@ -0,0 +1,30 @@
+package email
+
+import (
+	"io"
+	"log"
+)
+
+// A Mailer sends emails.
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// A LogMailer is a mailer that writes the emails to a log instead of sending
+// them. It is meant to be used in tests and when running the service locally,
+// where the log can be written to a file.
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer creates a mailer that writes the emails to the given writer.
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{log.New(w, "mail: ", log.LstdFlags)}
+}
+
+// Send writes the email to the log.
+func (m *LogMailer) Send(to string, subject string, body string) error {
+	m.logger.Printf("to=%s subject=%q body=%q", to, subject, body)
+
+	return nil
+}
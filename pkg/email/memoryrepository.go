@@ -0,0 +1,63 @@
+package email
+
+import (
+	"fmt"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on pending
+// email changes kept in memory. It is safe for concurrent use and is meant to
+// be used in tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	changes map[entity.ID]*PendingChange
+}
+
+// NewMemoryRepository creates an empty in-memory pending change repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{changes: make(map[entity.ID]*PendingChange)}
+}
+
+// FindByUserID retrieves the pending change of the user with the given ID, if
+// there is one.
+func (r *MemoryRepository) FindByUserID(userID entity.ID) (*PendingChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.changes[userID]
+	if !ok {
+		return nil, fmt.Errorf("email.MemoryRepository: no pending change found for user \"%s\"", userID)
+	}
+
+	copied := *c
+	return &copied, nil
+}
+
+// Save stores the pending change in memory, replacing the pending change of
+// the same user, if any.
+func (r *MemoryRepository) Save(c *PendingChange) error {
+	if c == nil {
+		return fmt.Errorf("email.MemoryRepository: failed to save pending change (pending change is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *c
+	r.changes[c.UserID] = &copied
+
+	return nil
+}
+
+// Delete removes the pending change of the user with the given ID from
+// memory.
+func (r *MemoryRepository) Delete(userID entity.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.changes, userID)
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on pending
+// email changes in a MongoDB collection. The pending changes are identified by
+// the unique identifier of their user.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	UserID      primitive.ObjectID `bson:"_id"`
+	Email       string             `bson:"email"`
+	Nonce       string             `bson:"nonce"`
+	RequestedAt time.Time          `bson:"requestedAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt"`
+}
+
+func newDocumentFromEntity(c *PendingChange) (*document, error) {
+	if c == nil {
+		return nil, fmt.Errorf("email.MongoRepository: entity is nil")
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.UserID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("email.MongoRepository: failed to create user object ID")
+	}
+
+	return &document{
+		userID,
+		c.Email,
+		c.Nonce,
+		c.RequestedAt,
+		c.ExpiresAt,
+	}, nil
+}
+
+func (d document) Entity() *PendingChange {
+	return &PendingChange{
+		UserID:      entity.NewIDFromHex(d.UserID.Hex()),
+		Email:       d.Email,
+		Nonce:       d.Nonce,
+		RequestedAt: d.RequestedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
+
+// changeRetention represents how long expired pending changes are kept before
+// MongoDB removes them.
+const changeRetention = 24 * time.Hour
+
+// NewMongoRepository creates a pending change repository for a MongoDB
+// collection and makes sure expired pending changes are eventually removed.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("email.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(int32(changeRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("email.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindByUserID retrieves the pending change of the user with the given ID, if
+// there is one.
+func (r *MongoRepository) FindByUserID(userID entity.ID) (*PendingChange, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("email.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	var d document
+	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
+	if err != nil {
+		return nil, fmt.Errorf("email.MongoRepository: no pending change found for user \"%s\" (%s)", userID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// Save stores the pending change in the collection, replacing the pending
+// change of the same user, if any.
+func (r *MongoRepository) Save(c *PendingChange) error {
+	d, err := newDocumentFromEntity(c)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: d.UserID}}
+	_, err = r.collection.ReplaceOne(context.TODO(), filter, d, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("email.MongoRepository: failed to save pending change of user \"%s\" (%s)", c.UserID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the pending change of the user with the given ID from the
+// collection.
+func (r *MongoRepository) Delete(userID entity.ID) error {
+	objectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return fmt.Errorf("email.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	_, err = r.collection.DeleteOne(context.TODO(), filter)
+	if err != nil {
+		return fmt.Errorf("email.MongoRepository: failed to delete pending change of user \"%s\" (%s)", userID, err)
+	}
+
+	return nil
+}
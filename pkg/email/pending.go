@@ -0,0 +1,18 @@
+package email
+
+import (
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A PendingChange is the last change of email that a user requested. Only the
+// token that was sent for it can be confirmed, so that requesting a new change
+// invalidates the tokens sent before.
+type PendingChange struct {
+	UserID      entity.ID
+	Email       string
+	Nonce       string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+}
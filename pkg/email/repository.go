@@ -0,0 +1,12 @@
+package email
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on pending email changes in a database. A user has at most one
+// pending change.
+type Repository interface {
+	FindByUserID(userID entity.ID) (*PendingChange, error)
+	Save(c *PendingChange) error
+	Delete(userID entity.ID) error
+}
@@ -0,0 +1,245 @@
+// Package email lets users change their email by confirming that they own the
+// new one with a signed token sent to it.
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/signed"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves changing emails.
+type UseCase interface {
+	RequestChange(subID string, email string) (*Change, error)
+	ConfirmChange(subID string, token string) error
+}
+
+// A Change is a pending change of a user's email, which must be confirmed
+// before it expires.
+type Change struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Config contains the information required to sign and send confirmation
+// tokens.
+type Config struct {
+	// Secret specifies the key used to sign the tokens.
+	Secret []byte
+
+	// TTL specifies how long a token can be used after it was sent.
+	//
+	// Zero means DefaultTTL.
+	TTL time.Duration
+
+	// ConfirmationURL specifies the URL of the page where users confirm
+	// their new email. The token is added to its query as the "token"
+	// parameter.
+	//
+	// An empty URL means that the token is sent on its own.
+	ConfirmationURL string
+
+	// ResendInterval specifies how long to wait before sending another token
+	// to the same user, whatever the email it wants to use.
+	//
+	// Zero means DefaultResendInterval.
+	ResendInterval time.Duration
+}
+
+const (
+	// DefaultTTL represents the default amount of time during which a token
+	// can be used.
+	DefaultTTL = 24 * time.Hour
+
+	// DefaultResendInterval represents the default amount of time to wait
+	// before sending another token.
+	DefaultResendInterval = time.Minute
+)
+
+// validate looks at the configuration's contents to ensure it has all the
+// required fields.
+func (conf *Config) validate() error {
+	if len(conf.Secret) == 0 {
+		return errors.New("missing secret")
+	}
+
+	if conf.ConfirmationURL != "" {
+		if _, err := url.Parse(conf.ConfirmationURL); err != nil {
+			return fmt.Errorf("malformed confirmation URL (%s)", err)
+		}
+	}
+
+	return nil
+}
+
+// A Service handles the business logic related to changing emails.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+	mailer   Mailer
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates an email service that keeps the pending changes in a
+// repository and sends the confirmation tokens with a mailer.
+func NewService(repo Repository, uService user.UseCase, mailer Mailer, config *Config) (*Service, error) {
+	if config == nil {
+		return nil, fmt.Errorf("email: missing configuration")
+	}
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("email: configuration %s", err)
+	}
+
+	c := *config
+	if c.TTL == 0 {
+		c.TTL = DefaultTTL
+	}
+
+	if c.ResendInterval == 0 {
+		c.ResendInterval = DefaultResendInterval
+	}
+
+	return &Service{repo, uService, mailer, c, time.Now}, nil
+}
+
+// RequestChange sends a confirmation token to the email that the user with the
+// given subscription ID wants to use. The user's email is only replaced once
+// the token is confirmed. Requesting a change to the user's current email
+// verifies it. Tokens are sent at most once per resend interval to a user,
+// even when it wants to use another email, and a new request invalidates the
+// tokens sent before.
+func (s *Service) RequestChange(subID string, email string) (*Change, error) {
+	if !entity.IsEmail(email) {
+		return nil, InvalidAddressError{invalidAddress.New(fmt.Sprintf("email \"%s\" is not a valid email address", email))}
+	}
+
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email == u.Email && u.EmailVerified {
+		return nil, AlreadyVerifiedError{alreadyVerified.New(fmt.Sprintf("email: user \"%s\" already uses verified email \"%s\"", u.ID, email))}
+	}
+
+	now := s.now()
+	previous, err := s.repo.FindByUserID(u.ID)
+	if err == nil && now.Before(previous.RequestedAt.Add(s.config.ResendInterval)) {
+		return nil, TooSoonError{tooSoon.New(fmt.Sprintf("email.Service: a change was requested by user \"%s\" less than %s ago", u.ID, s.config.ResendInterval))}
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.config.TTL)
+	token, err := signed.Encode(&changeToken{
+		UserID:    u.ID,
+		From:      u.Email,
+		To:        email,
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Unix(),
+	}, s.config.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("email.Service: failed to sign token of user \"%s\" (%s)", u.ID, err)
+	}
+
+	err = s.repo.Save(&PendingChange{
+		UserID:      u.ID,
+		Email:       email,
+		Nonce:       nonce,
+		RequestedAt: now,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(email, "Confirm your email address", s.confirmationMessage(token))
+	if err != nil {
+		_ = s.repo.Delete(u.ID)
+
+		return nil, fmt.Errorf("email.Service: failed to send token to user \"%s\" (%s)", u.ID, err)
+	}
+
+	return &Change{email, time.Unix(expiresAt.Unix(), 0).UTC()}, nil
+}
+
+// ConfirmChange replaces the email of the user with the given subscription ID
+// with the email the token was sent to and marks it as verified. The token
+// must have been sent to the same user for its last requested change, and the
+// user's email must not have changed since then.
+func (s *Service) ConfirmChange(subID string, token string) error {
+	var t changeToken
+	err := signed.Decode(token, s.config.Secret, &t)
+	if err != nil {
+		return InvalidTokenError{invalidToken.New(fmt.Sprintf("email: %s", err))}
+	}
+
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return err
+	}
+
+	if t.UserID != u.ID {
+		return InvalidTokenError{invalidToken.New(fmt.Sprintf("email: token was issued for another user \"%s\"", t.UserID))}
+	}
+
+	if t.From != u.Email {
+		return InvalidTokenError{invalidToken.New("email: token was issued before the user's email last changed")}
+	}
+
+	if signed.Expired(t.ExpiresAt, s.now()) {
+		return ExpiredTokenError{expiredToken.New("token expired, a new token must be sent")}
+	}
+
+	pending, err := s.repo.FindByUserID(u.ID)
+	if err != nil || pending.Nonce != t.Nonce {
+		return InvalidTokenError{invalidToken.New("email: token was replaced by a more recent change")}
+	}
+
+	err = s.uService.UpdateEmail(u.ID, t.To)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(u.ID)
+}
+
+// newNonce generates a random value that identifies the token sent for a
+// change.
+func newNonce() (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("email.Service: failed to generate nonce (%s)", err)
+	}
+
+	return hex.EncodeToString(nonce), nil
+}
+
+// confirmationMessage returns the body of the email that contains the token.
+func (s *Service) confirmationMessage(token string) string {
+	if s.config.ConfirmationURL == "" {
+		return fmt.Sprintf("Use the following code to confirm your email address: %s", token)
+	}
+
+	link, _ := url.Parse(s.config.ConfirmationURL)
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return fmt.Sprintf("Confirm your email address by following this link: %s", link)
+}
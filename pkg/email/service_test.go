@@ -0,0 +1,227 @@
+package email
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/signed"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// A recordingMailer is a mailer that keeps the last token it sent.
+type recordingMailer struct {
+	to    string
+	token string
+}
+
+func (m *recordingMailer) Send(to string, subject string, body string) error {
+	m.to = to
+
+	link, err := url.Parse(body[strings.LastIndex(body, " ")+1:])
+	if err != nil {
+		return err
+	}
+	m.token = link.Query().Get("token")
+
+	return nil
+}
+
+type testServices struct {
+	s        *Service
+	uService *user.Service
+	mailer   *recordingMailer
+	now      time.Time
+}
+
+func newTestServices(t *testing.T) (*testServices, *entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+	register := func(subID string, email string) *entity.User {
+		u, err := uService.Register(&entity.User{
+			SubID:       subID,
+			Email:       email,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	ts := &testServices{
+		uService: uService,
+		mailer:   &recordingMailer{},
+		now:      time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	var err error
+	ts.s, err = NewService(NewMemoryRepository(), uService, ts.mailer, &Config{
+		Secret:          []byte("hide the pain"),
+		ConfirmationURL: "https://ecovo.ca/confirm-email",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.s.now = func() time.Time { return ts.now }
+
+	register("maurice|1", "maurice@hide-the-pain.meme")
+
+	return ts, register("harold|1", "harold@hide-the-pain.meme")
+}
+
+func TestNewService(t *testing.T) {
+	_, err := NewService(NewMemoryRepository(), nil, &recordingMailer{}, &Config{})
+	if err == nil {
+		t.Error("expected an error when the secret is missing")
+	}
+}
+
+func TestServiceChange(t *testing.T) {
+	t.Run("Should replace the email once the token is confirmed", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		c, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ts.mailer.to != "harold@ecovo.ca" || !c.ExpiresAt.Equal(ts.now.Add(DefaultTTL)) {
+			t.Errorf("unexpected change %+v sent to %q", c, ts.mailer.to)
+		}
+
+		u, err := ts.uService.FindByID(harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Email != harold.Email {
+			t.Errorf("expected email to be unchanged until it is confirmed, got %q", u.Email)
+		}
+
+		err = ts.s.ConfirmChange(harold.SubID, ts.mailer.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err = ts.uService.FindByID(harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Email != "harold@ecovo.ca" || !u.EmailVerified {
+			t.Errorf("expected email to be replaced and verified, got %q (verified: %t)", u.Email, u.EmailVerified)
+		}
+
+		err = ts.s.ConfirmChange(harold.SubID, ts.mailer.token)
+		if _, ok := err.(InvalidTokenError); !ok {
+			t.Errorf("expected token not to be reusable, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when email is malformed", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		_, err := ts.s.RequestChange(harold.SubID, "harold")
+		if _, ok := err.(InvalidAddressError); !ok {
+			t.Errorf("expected InvalidAddressError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when token expired", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca"); err != nil {
+			t.Fatal(err)
+		}
+
+		ts.now = ts.now.Add(DefaultTTL)
+		err := ts.s.ConfirmChange(harold.SubID, ts.mailer.token)
+		if _, ok := err.(ExpiredTokenError); !ok {
+			t.Errorf("expected ExpiredTokenError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when token was issued for another user", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca"); err != nil {
+			t.Fatal(err)
+		}
+
+		err := ts.s.ConfirmChange("maurice|1", ts.mailer.token)
+		if _, ok := err.(InvalidTokenError); !ok {
+			t.Errorf("expected InvalidTokenError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when token was tampered with", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca"); err != nil {
+			t.Fatal(err)
+		}
+
+		forged, err := signed.Encode(&changeToken{
+			UserID:    harold.ID,
+			From:      harold.Email,
+			To:        "maurice@hide-the-pain.meme",
+			ExpiresAt: ts.now.Add(time.Hour).Unix(),
+		}, []byte("no pain"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ts.s.ConfirmChange(harold.SubID, forged)
+		if _, ok := err.(InvalidTokenError); !ok {
+			t.Errorf("expected InvalidTokenError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when a change was requested too recently", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca"); err != nil {
+			t.Fatal(err)
+		}
+
+		ts.now = ts.now.Add(DefaultResendInterval - time.Second)
+		_, err := ts.s.RequestChange(harold.SubID, "harold@hide-the-pain.ca")
+		if _, ok := err.(TooSoonError); !ok {
+			t.Errorf("expected TooSoonError, got %v", err)
+		}
+
+		if ts.mailer.to != "harold@ecovo.ca" {
+			t.Errorf("expected no token to be sent to %q", ts.mailer.to)
+		}
+	})
+
+	t.Run("Should fail when a more recent change was requested", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@ecovo.ca"); err != nil {
+			t.Fatal(err)
+		}
+		previous := ts.mailer.token
+
+		ts.now = ts.now.Add(DefaultResendInterval)
+		if _, err := ts.s.RequestChange(harold.SubID, "harold@hide-the-pain.ca"); err != nil {
+			t.Fatal(err)
+		}
+
+		err := ts.s.ConfirmChange(harold.SubID, previous)
+		if _, ok := err.(InvalidTokenError); !ok {
+			t.Errorf("expected InvalidTokenError, got %v", err)
+		}
+
+		err = ts.s.ConfirmChange(harold.SubID, ts.mailer.token)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package email
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A changeToken is a token that lets a user confirm that it owns the email it
+// wants to use. It is signed, so that only its nonce needs to be stored, and
+// only valid as long as the user's email is the one it was issued for and no
+// other change was requested since.
+type changeToken struct {
+	UserID    entity.ID `json:"uid"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Nonce     string    `json:"nonce"`
+	ExpiresAt int64     `json:"exp"`
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Document is a proof that a user is allowed to drive, such as its driver's
+// license, which is reviewed before the user is considered a verified driver.
+// A document can also be about one of the user's vehicules, such as the
+// vehicule's insurance certificate.
+type Document struct {
+	ID              ID        `json:"id" bson:"_id,omitempty"`
+	UserID          ID        `json:"userId" bson:"userId"`
+	VehiculeID      ID        `json:"vehiculeId,omitempty" bson:"vehiculeId,omitempty"`
+	Type            string    `json:"type" bson:"type"`
+	Status          string    `json:"status" bson:"status"`
+	ExpiresAt       time.Time `json:"expiresAt" bson:"expiresAt"`
+	ContentType     string    `json:"contentType" bson:"contentType"`
+	Size            int64     `json:"size" bson:"size"`
+	FileKey         string    `json:"-" bson:"fileKey"`
+	RejectionReason string    `json:"rejectionReason,omitempty" bson:"rejectionReason,omitempty"`
+	UploadedAt      time.Time `json:"uploadedAt" bson:"uploadedAt"`
+	ReviewedAt      time.Time `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+}
+
+const (
+	// DocumentTypeDriversLicense represents a driver's license.
+	DocumentTypeDriversLicense = "driversLicense"
+
+	// DocumentTypeInsurance represents an insurance certificate.
+	DocumentTypeInsurance = "insurance"
+
+	// DocumentStatusPending means that the document was not reviewed yet.
+	DocumentStatusPending = "pending"
+
+	// DocumentStatusApproved means that the document was reviewed and proves
+	// what it is meant to prove.
+	DocumentStatusApproved = "approved"
+
+	// DocumentStatusRejected means that the document was reviewed and does
+	// not prove what it is meant to prove.
+	DocumentStatusRejected = "rejected"
+
+	// DocumentStatusExpired means that the document, whether it was reviewed
+	// or not, is past its expiration date.
+	DocumentStatusExpired = "expired"
+
+	// RejectionReasonMaximumLength represents the maximum number of characters
+	// of the reason why a document was rejected.
+	RejectionReasonMaximumLength = 500
+)
+
+// DriverDocumentTypes contains the types of documents that must be approved
+// for a user to be a verified driver.
+var DriverDocumentTypes = []string{DocumentTypeDriversLicense, DocumentTypeInsurance}
+
+// Expire marks the document as expired if it is past its expiration date at
+// the given time. Rejected documents stay rejected.
+func (d *Document) Expire(now time.Time) {
+	if d.Status != DocumentStatusRejected && !now.Before(d.ExpiresAt) {
+		d.Status = DocumentStatusExpired
+	}
+}
+
+// Validate validates that the document's required fields are filled out
+// correctly.
+func (d *Document) Validate() error {
+	var errs ValidationErrors
+
+	if d.UserID.IsZero() {
+		errs.add("userId", CodeMissing, "user ID is missing")
+	}
+
+	if d.Type != DocumentTypeDriversLicense && d.Type != DocumentTypeInsurance {
+		errs.add("type", CodeUnknownValue, fmt.Sprintf("type must be %s or %s", DocumentTypeDriversLicense, DocumentTypeInsurance))
+	}
+
+	switch d.Status {
+	case DocumentStatusPending, DocumentStatusApproved, DocumentStatusRejected, DocumentStatusExpired:
+	default:
+		errs.add("status", CodeUnknownValue, fmt.Sprintf("status must be %s, %s, %s or %s", DocumentStatusPending, DocumentStatusApproved, DocumentStatusRejected, DocumentStatusExpired))
+	}
+
+	if d.ExpiresAt.IsZero() {
+		errs.add("expiresAt", CodeMissing, "expiration date is missing")
+	}
+
+	if d.FileKey == "" {
+		errs.add("file", CodeMissing, "file is missing")
+	}
+
+	if d.Status == DocumentStatusRejected && strings.TrimSpace(d.RejectionReason) == "" {
+		errs.add("rejectionReason", CodeMissing, "rejection reason is missing")
+	} else if len([]rune(d.RejectionReason)) > RejectionReasonMaximumLength {
+		errs.add("rejectionReason", CodeTooLong, fmt.Sprintf("rejection reason is longer than %d characters", RejectionReasonMaximumLength))
+	}
+
+	return errs.err()
+}
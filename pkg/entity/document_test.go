@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentValidation(t *testing.T) {
+	var document = Document{
+		UserID:     NewIDFromHex("5c8f9ddfdc5bda1a3c2a2f1b"),
+		Type:       DocumentTypeDriversLicense,
+		Status:     DocumentStatusPending,
+		ExpiresAt:  time.Date(2025, time.February, 12, 0, 0, 0, 0, time.UTC),
+		FileKey:    "documents/5c8f9ddfdc5bda1a3c2a2f1b/license",
+		UploadedAt: time.Date(2019, time.February, 12, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(d *Document)
+		field  string
+	}{
+		{"Should succeed when document is valid", func(d *Document) {}, ""},
+		{"Should fail when user ID is empty", func(d *Document) { d.UserID = "" }, "userId"},
+		{"Should fail when type is unknown", func(d *Document) { d.Type = "passport" }, "type"},
+		{"Should fail when status is unknown", func(d *Document) { d.Status = "lost" }, "status"},
+		{"Should fail when expiration date is missing", func(d *Document) { d.ExpiresAt = time.Time{} }, "expiresAt"},
+		{"Should fail when file is missing", func(d *Document) { d.FileKey = "" }, "file"},
+		{"Should fail when rejected document has no reason", func(d *Document) { d.Status = DocumentStatusRejected }, "rejectionReason"},
+		{"Should fail when rejection reason is too long", func(d *Document) {
+			d.RejectionReason = strings.Repeat("a", RejectionReasonMaximumLength+1)
+		}, "rejectionReason"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			d := document
+			test.modify(&d)
+
+			err := d.Validate()
+			if test.field == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok || len(errs) != 1 || errs[0].Field() != test.field {
+				t.Errorf("expected an error on %q, got %v", test.field, err)
+			}
+		})
+	}
+}
+
+func TestDocumentExpire(t *testing.T) {
+	expiresAt := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		status   string
+		now      time.Time
+		expected string
+	}{
+		{"Should keep status before expiration date", DocumentStatusApproved, expiresAt.Add(-time.Second), DocumentStatusApproved},
+		{"Should expire approved document", DocumentStatusApproved, expiresAt, DocumentStatusExpired},
+		{"Should expire pending document", DocumentStatusPending, expiresAt, DocumentStatusExpired},
+		{"Should keep rejected document rejected", DocumentStatusRejected, expiresAt, DocumentStatusRejected},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			d := Document{Status: test.status, ExpiresAt: expiresAt}
+			d.Expire(test.now)
+
+			if d.Status != test.expected {
+				t.Errorf("expected status %s, got %s", test.expected, d.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "net/mail"
+
+// IsEmail returns whether a string is a bare email address (ex.
+// harold@hide-the-pain.meme), without a display name or angle brackets.
+func IsEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return false
+	}
+
+	return address.Address == email && address.Name == ""
+}
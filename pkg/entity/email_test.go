@@ -0,0 +1,22 @@
+package entity
+
+import "testing"
+
+func TestIsEmail(t *testing.T) {
+	tests := []struct {
+		email    string
+		expected bool
+	}{
+		{"harold@hide-the-pain.meme", true},
+		{"harold.the.great+rides@hide-the-pain.meme", true},
+		{"Harold <harold@hide-the-pain.meme>", false},
+		{"harold", false},
+		{"harold@", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if IsEmail(test.email) != test.expected {
+			t.Errorf("expected IsEmail(%q) to be %t", test.email, test.expected)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package entity
 
-import "strings"
+import (
+	"encoding/hex"
+	"strings"
+)
 
 // An ID is an entity's unique identifier.
 type ID string
@@ -23,3 +26,13 @@ func (id ID) Hex() string {
 func (id ID) IsZero() bool {
 	return strings.Compare(string(id), string(NilID)) == 0
 }
+
+// idLength represents the number of bytes in a unique identifier.
+const idLength = 12
+
+// IsValid returns whether the unique identifier is well formed, which means
+// that it is made of 12 bytes encoded as hexadecimal.
+func (id ID) IsValid() bool {
+	b, err := hex.DecodeString(string(id))
+	return err == nil && len(b) == idLength
+}
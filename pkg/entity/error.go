@@ -1,12 +1,256 @@
 package entity
 
-// A ValidationError is an error that occurs when validating an entity fails.
-// This can happen when, for example, an entity is missing a required field a
-// value is incorrect or out of bounds.
+import "strings"
+
+// An ErrorKind is the category of an error. It tells what went wrong
+// regardless of the package that returned the error, so that errors can be
+// reported to callers uniformly.
+type ErrorKind string
+
+const (
+	// ErrorKindInvalid means that the input is malformed or does not satisfy
+	// the validation rules.
+	ErrorKindInvalid ErrorKind = "invalid"
+
+	// ErrorKindUnauthorized means that the caller could not be authenticated.
+	ErrorKindUnauthorized ErrorKind = "unauthorized"
+
+	// ErrorKindForbidden means that the caller is not allowed to perform the
+	// operation.
+	ErrorKindForbidden ErrorKind = "forbidden"
+
+	// ErrorKindNotFound means that the resource does not exist.
+	ErrorKindNotFound ErrorKind = "notFound"
+
+	// ErrorKindAlreadyExists means that the resource already exists.
+	ErrorKindAlreadyExists ErrorKind = "alreadyExists"
+
+	// ErrorKindTooManyRequests means that the caller performed the operation
+	// too many times and must wait before trying again.
+	ErrorKindTooManyRequests ErrorKind = "tooManyRequests"
+
+	// ErrorKindTooLarge means that the request is larger than what the
+	// service accepts.
+	ErrorKindTooLarge ErrorKind = "tooLarge"
+)
+
+// An Error is an error that describes what went wrong in a structured way.
+// The errors returned by the service's packages implement it, so that they can
+// be matched with errors.As without knowing their concrete type.
+type Error interface {
+	error
+
+	// Kind returns the category of the error.
+	Kind() ErrorKind
+
+	// Code returns an identifier of the error that, unlike its message, never
+	// changes (ex. userNotFound).
+	Code() string
+
+	// Field returns the path of the field the error is about (ex.
+	// preferences.music), or an empty path when it is not about a field.
+	Field() string
+}
+
+// A DetailedError is an Error whose message is meant for logs. It describes
+// what went wrong to callers with a detail instead.
+type DetailedError interface {
+	Error
+
+	// Detail returns what went wrong, in words meant for callers.
+	Detail() string
+}
+
+// An ErrorDescription describes a type of error: its kind, its code, the
+// field it is about, if any, and the detail returned to callers instead of its
+// message, if any.
+type ErrorDescription struct {
+	Kind   ErrorKind
+	Code   string
+	Field  string
+	Detail string
+}
+
+// New creates the base of an error of the described type with the given
+// message.
+func (d *ErrorDescription) New(msg string) BaseError {
+	return BaseError{d, d.Field, msg}
+}
+
+// NewForField creates the base of an error of the described type about the
+// given field, for the types of errors whose field varies.
+func (d *ErrorDescription) NewForField(field string, msg string) BaseError {
+	return BaseError{d, field, msg}
+}
+
+// A BaseError implements DetailedError for the errors of the service's
+// packages. They embed it, so that they can be told apart with errors.As, and
+// create it from the ErrorDescription of their type.
+type BaseError struct {
+	description *ErrorDescription
+	field       string
+	msg         string
+}
+
+func (e BaseError) Error() string {
+	return e.msg
+}
+
+// Kind returns the kind of the error's type.
+func (e BaseError) Kind() ErrorKind {
+	if e.description == nil {
+		return ""
+	}
+
+	return e.description.Kind
+}
+
+// Code returns the code of the error's type.
+func (e BaseError) Code() string {
+	if e.description == nil {
+		return ""
+	}
+
+	return e.description.Code
+}
+
+// Field returns the path of the field the error is about, if any.
+func (e BaseError) Field() string {
+	return e.field
+}
+
+// Detail returns the detail of the error's type, or the error's message when
+// its type has none.
+func (e BaseError) Detail() string {
+	if e.description == nil || e.description.Detail == "" {
+		return e.msg
+	}
+
+	return e.description.Detail
+}
+
+const (
+	// CodeMissing means that a required field is missing.
+	CodeMissing = "missing"
+
+	// CodeOutOfBounds means that a value is lower than its minimum or greater
+	// than its maximum.
+	CodeOutOfBounds = "outOfBounds"
+
+	// CodeUnknownValue means that a value is not one of the allowed values.
+	CodeUnknownValue = "unknownValue"
+
+	// CodeMalformed means that a value is not in the expected format.
+	CodeMalformed = "malformed"
+
+	// CodeTooLong means that a value is longer than its maximum length.
+	CodeTooLong = "tooLong"
+
+	// CodeTooYoung means that a user is younger than the minimum age.
+	CodeTooYoung = "tooYoung"
+
+	// CodeSelfReview means that a user tried to review itself.
+	CodeSelfReview = "selfReview"
+
+	// CodeInconsistent means that a value does not match the values it is
+	// derived from.
+	CodeInconsistent = "inconsistent"
+
+	// CodeValidationFailed means that an entity has one or more validation
+	// errors.
+	CodeValidationFailed = "validationFailed"
+)
+
+// A ValidationError is an error that occurs when validating a field of an
+// entity fails. This can happen when, for example, an entity is missing a
+// required field a value is incorrect or out of bounds.
 type ValidationError struct {
-	msg string
+	field string
+	code  string
+	msg   string
 }
 
 func (e ValidationError) Error() string {
 	return e.msg
 }
+
+// Kind returns ErrorKindInvalid.
+func (e ValidationError) Kind() ErrorKind {
+	return ErrorKindInvalid
+}
+
+// Code returns the rule that the field does not satisfy (ex. missing).
+func (e ValidationError) Code() string {
+	return e.code
+}
+
+// Field returns the path of the field that is not valid.
+func (e ValidationError) Field() string {
+	return e.field
+}
+
+// ValidationErrors contains every validation error found when validating an
+// entity, so that all of them can be fixed at once.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.msg)
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Kind returns ErrorKindInvalid.
+func (e ValidationErrors) Kind() ErrorKind {
+	return ErrorKindInvalid
+}
+
+// Code returns CodeValidationFailed.
+func (e ValidationErrors) Code() string {
+	return CodeValidationFailed
+}
+
+// Field returns an empty path, since the errors are about multiple fields.
+func (e ValidationErrors) Field() string {
+	return ""
+}
+
+// add adds a validation error about a field.
+func (e *ValidationErrors) add(field string, code string, msg string) {
+	*e = append(*e, ValidationError{field, code, msg})
+}
+
+// addNested adds the validation errors of a nested entity, relative to the
+// field of the parent entity that contains it. The label, if any, is
+// prepended to their message.
+func (e *ValidationErrors) addNested(err error, field string, label string) {
+	nested, ok := err.(ValidationErrors)
+	if !ok {
+		return
+	}
+
+	for _, n := range nested {
+		if n.field == "" {
+			n.field = field
+		} else {
+			n.field = field + "." + n.field
+		}
+
+		if label != "" {
+			n.msg = label + " " + n.msg
+		}
+
+		*e = append(*e, n)
+	}
+}
+
+// err returns the validation errors as an error, or nil when there are none.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
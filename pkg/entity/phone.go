@@ -0,0 +1,74 @@
+package entity
+
+import "strings"
+
+const (
+	// DefaultCountryCallingCode represents the country calling code of the
+	// phone numbers that are entered without one (North America).
+	DefaultCountryCallingCode = "1"
+
+	// phoneNumberMinimumDigits and phoneNumberMaximumDigits represent the
+	// number of digits, including the country calling code, that a phone
+	// number in the E.164 format has.
+	phoneNumberMinimumDigits = 7
+	phoneNumberMaximumDigits = 15
+
+	// nationalNumberDigits represents the number of digits of a North
+	// American phone number without its country calling code.
+	nationalNumberDigits = 10
+)
+
+// NormalizePhoneNumber parses a phone number written in a common format (ex.
+// (450) 123-4567, +33 1 23 45 67 89 or 0033123456789) and returns it in the
+// E.164 format (ex. +14501234567). Phone numbers that are entered without a
+// country calling code are assumed to be North American. It returns false if
+// the phone number cannot be parsed.
+func NormalizePhoneNumber(phoneNumber string) (string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '/':
+			return -1
+		}
+		return r
+	}, phoneNumber)
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case len(digits) == nationalNumberDigits:
+		digits = DefaultCountryCallingCode + digits
+	case len(digits) == nationalNumberDigits+1 && strings.HasPrefix(digits, DefaultCountryCallingCode):
+	default:
+		return "", false
+	}
+
+	normalized := "+" + digits
+	if !IsE164(normalized) {
+		return "", false
+	}
+
+	return normalized, true
+}
+
+// IsE164 returns whether a phone number is in the E.164 format, which is a
+// "+" followed by up to 15 digits, the first of which cannot be 0.
+func IsE164(phoneNumber string) bool {
+	if !strings.HasPrefix(phoneNumber, "+") {
+		return false
+	}
+
+	digits := phoneNumber[1:]
+	if len(digits) < phoneNumberMinimumDigits || len(digits) > phoneNumberMaximumDigits || digits[0] == '0' {
+		return false
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "testing"
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		expected    string
+		ok          bool
+	}{
+		{"Should add the default country calling code", "(450) 123-4567", "+14501234567", true},
+		{"Should keep a North American country calling code", "1-450-123-4567", "+14501234567", true},
+		{"Should keep a phone number in E.164 format", "+14501234567", "+14501234567", true},
+		{"Should remove separators of an international phone number", "+33 1 23 45 67 89", "+33123456789", true},
+		{"Should replace the international call prefix", "0033 1 23 45 67 89", "+33123456789", true},
+		{"Should fail when phone number has letters", "450-HAROLD1", "", false},
+		{"Should fail when phone number is too short", "123-4567", "", false},
+		{"Should fail when phone number is too long", "+1234567890123456", "", false},
+		{"Should fail when country calling code starts with 0", "+04501234567", "", false},
+		{"Should fail when phone number is empty", "", "", false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			normalized, ok := NormalizePhoneNumber(test.phoneNumber)
+			if normalized != test.expected || ok != test.ok {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.expected, test.ok, normalized, ok)
+			}
+		})
+	}
+}
@@ -1,13 +1,26 @@
 package entity
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
 
 // Preferences contains a user's preferences when it comes to smoking,
-// conversation and music.
+// conversation and music, as well as the other preferences of the catalog
+// that it expressed, such as whether it travels with pets.
+//
+// Preferences are serialized as a single object whose fields are the keys of
+// the preferences (ex. {"smoking": 0, "conversation": 1, "music": 2,
+// "pets": 1}).
 type Preferences struct {
 	Smoking      int `json:"smoking" bson:"smoking"`
 	Conversation int `json:"conversation" bson:"conversation"`
 	Music        int `json:"music" bson:"music"`
+
+	// Others contains the values of the other preferences, by key. A missing
+	// key means that the user did not express that preference.
+	Others map[string]int `json:"-" bson:",inline"`
 }
 
 const (
@@ -24,20 +37,234 @@ const (
 	PreferenceRegularly = 2
 )
 
-// Validate validates that the preferences' required fields are filled out
-// correctly.
-func (p *Preferences) Validate() error {
-	if p.Smoking < PreferenceNever || p.Smoking > PreferenceRegularly {
-		return ValidationError{fmt.Sprintf("smoking preference is out of bounds \"%d\"", p.Smoking)}
+// A PreferenceKey identifies a preference of the catalog.
+type PreferenceKey string
+
+const (
+	// PreferenceSmoking represents how often a user smokes.
+	PreferenceSmoking PreferenceKey = "smoking"
+
+	// PreferenceConversation represents how often a user talks.
+	PreferenceConversation PreferenceKey = "conversation"
+
+	// PreferenceMusic represents how often a user listens to music.
+	PreferenceMusic PreferenceKey = "music"
+
+	// PreferencePets represents which pets a user travels with.
+	PreferencePets PreferenceKey = "pets"
+
+	// PreferenceLuggage represents how much luggage a user travels with.
+	PreferenceLuggage PreferenceKey = "luggage"
+
+	// PreferenceAirConditioning represents whether a user wants the air
+	// conditioning on.
+	PreferenceAirConditioning PreferenceKey = "airConditioning"
+
+	// PreferenceChildSeat represents whether a user travels with a child that
+	// needs a child seat.
+	PreferenceChildSeat PreferenceKey = "childSeat"
+
+	// PreferenceFood represents what a user eats during rides.
+	PreferenceFood PreferenceKey = "food"
+
+	// PreferenceQuietRide represents whether a user wants a quiet ride.
+	PreferenceQuietRide PreferenceKey = "quietRide"
+
+	// PreferenceSameGender represents whether a user is only comfortable
+	// riding with users of its own gender.
+	PreferenceSameGender PreferenceKey = "sameGender"
+)
+
+// A PreferenceKind describes how the values of a preference relate to each
+// other, so that clients know how to render it.
+type PreferenceKind string
+
+const (
+	// PreferenceKindScale means that the values are ordered from the least to
+	// the most (ex. never, occasionally, regularly).
+	PreferenceKindScale PreferenceKind = "scale"
+
+	// PreferenceKindBoolean means that the values are no (0) and yes (1).
+	PreferenceKindBoolean PreferenceKind = "boolean"
+)
+
+// A PreferenceValue is a value that a preference can take. Users refer to
+// the value by number, while the name is meant to be displayed.
+type PreferenceValue struct {
+	Value int    `json:"value"`
+	Name  string `json:"name"`
+}
+
+// A PreferenceDefinition declares a preference of the catalog and the values
+// that it can take.
+type PreferenceDefinition struct {
+	Key    PreferenceKey     `json:"key"`
+	Name   string            `json:"name"`
+	Kind   PreferenceKind    `json:"kind"`
+	Values []PreferenceValue `json:"values"`
+}
+
+// Allows returns whether the preference can take the given value.
+func (d *PreferenceDefinition) Allows(value int) bool {
+	for _, v := range d.Values {
+		if v.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	frequencyValues = []PreferenceValue{
+		{PreferenceNever, "Never"},
+		{PreferenceOccasionally, "Occasionally"},
+		{PreferenceRegularly, "Regularly"},
+	}
+
+	booleanValues = []PreferenceValue{
+		{0, "No"},
+		{1, "Yes"},
+	}
+)
+
+// PreferenceCatalog contains the preferences that users can express. The
+// smoking, conversation and music preferences are always expressed, while the
+// others are optional.
+var PreferenceCatalog = []PreferenceDefinition{
+	{PreferenceSmoking, "Smoking", PreferenceKindScale, frequencyValues},
+	{PreferenceConversation, "Conversation", PreferenceKindScale, frequencyValues},
+	{PreferenceMusic, "Music", PreferenceKindScale, frequencyValues},
+	{PreferencePets, "Pets", PreferenceKindScale, []PreferenceValue{
+		{0, "No pets"},
+		{1, "Small pets"},
+		{2, "Any pets"},
+	}},
+	{PreferenceLuggage, "Luggage", PreferenceKindScale, []PreferenceValue{
+		{0, "No luggage"},
+		{1, "Small bag"},
+		{2, "Medium suitcase"},
+		{3, "Large suitcase"},
+	}},
+	{PreferenceAirConditioning, "Air conditioning", PreferenceKindBoolean, booleanValues},
+	{PreferenceChildSeat, "Child seat", PreferenceKindBoolean, booleanValues},
+	{PreferenceFood, "Food", PreferenceKindScale, []PreferenceValue{
+		{0, "No food"},
+		{1, "Snacks"},
+		{2, "Meals"},
+	}},
+	{PreferenceQuietRide, "Quiet ride", PreferenceKindBoolean, booleanValues},
+	{PreferenceSameGender, "Same gender only", PreferenceKindBoolean, booleanValues},
+}
+
+// LookupPreference returns the definition of the preference with the given
+// key in the catalog, if it exists.
+func LookupPreference(key PreferenceKey) (*PreferenceDefinition, bool) {
+	for i := range PreferenceCatalog {
+		if PreferenceCatalog[i].Key == key {
+			return &PreferenceCatalog[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Get returns the value of the preference with the given key, and whether the
+// user expressed it.
+func (p *Preferences) Get(key PreferenceKey) (int, bool) {
+	switch key {
+	case PreferenceSmoking:
+		return p.Smoking, true
+	case PreferenceConversation:
+		return p.Conversation, true
+	case PreferenceMusic:
+		return p.Music, true
+	}
+
+	value, ok := p.Others[string(key)]
+
+	return value, ok
+}
+
+// Set modifies the value of the preference with the given key.
+func (p *Preferences) Set(key PreferenceKey, value int) {
+	switch key {
+	case PreferenceSmoking:
+		p.Smoking = value
+	case PreferenceConversation:
+		p.Conversation = value
+	case PreferenceMusic:
+		p.Music = value
+	default:
+		if p.Others == nil {
+			p.Others = make(map[string]int)
+		}
+		p.Others[string(key)] = value
+	}
+}
+
+// Keys returns the keys of the preferences that the user expressed, starting
+// with smoking, conversation and music, followed by the others sorted by key.
+func (p *Preferences) Keys() []PreferenceKey {
+	keys := []PreferenceKey{PreferenceSmoking, PreferenceConversation, PreferenceMusic}
+
+	others := make([]string, 0, len(p.Others))
+	for key := range p.Others {
+		others = append(others, key)
+	}
+	sort.Strings(others)
+
+	for _, key := range others {
+		keys = append(keys, PreferenceKey(key))
+	}
+
+	return keys
+}
+
+// MarshalJSON encodes the preferences as a single object whose fields are the
+// keys of the preferences.
+func (p Preferences) MarshalJSON() ([]byte, error) {
+	values := make(map[string]int, len(p.Others)+3)
+	for _, key := range p.Keys() {
+		values[string(key)], _ = p.Get(key)
 	}
 
-	if p.Conversation < PreferenceNever || p.Conversation > PreferenceRegularly {
-		return ValidationError{fmt.Sprintf("conversation preference is out of bounds \"%d\"", p.Conversation)}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON decodes the preferences from a single object whose fields are
+// the keys of the preferences. Smoking, conversation and music default to
+// zero when they are missing.
+func (p *Preferences) UnmarshalJSON(data []byte) error {
+	var values map[string]int
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return err
 	}
 
-	if p.Music < PreferenceNever || p.Music > PreferenceRegularly {
-		return ValidationError{fmt.Sprintf("music preference is out of bounds \"%d\"", p.Music)}
+	*p = Preferences{}
+	for key, value := range values {
+		p.Set(PreferenceKey(key), value)
 	}
 
 	return nil
 }
+
+// Validate validates that the preferences are part of the catalog and that
+// their values are among the ones that the catalog declares.
+func (p *Preferences) Validate() error {
+	var errs ValidationErrors
+
+	for _, key := range p.Keys() {
+		value, _ := p.Get(key)
+
+		d, ok := LookupPreference(key)
+		if !ok {
+			errs.add(string(key), CodeUnknownValue, fmt.Sprintf("preference \"%s\" does not exist", key))
+		} else if !d.Allows(value) {
+			errs.add(string(key), CodeOutOfBounds, fmt.Sprintf("%s preference is out of bounds \"%d\"", key, value))
+		}
+	}
+
+	return errs.err()
+}
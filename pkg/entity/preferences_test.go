@@ -1,6 +1,9 @@
 package entity
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestPreferencesValidation(t *testing.T) {
 	preferences := Preferences{
@@ -13,7 +16,7 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Smoking = PreferenceNever - 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -22,7 +25,7 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Smoking = PreferenceRegularly + 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -31,7 +34,7 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Music = PreferenceNever - 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -40,7 +43,7 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Music = PreferenceRegularly + 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -49,7 +52,7 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Conversation = PreferenceNever - 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -58,7 +61,82 @@ func TestPreferencesValidation(t *testing.T) {
 		p := preferences
 		p.Conversation = PreferenceRegularly + 1
 
-		if _, ok := p.Validate().(ValidationError); !ok {
+		if _, ok := p.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestPreferencesCatalogValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		others map[string]int
+		field  string
+		code   string
+	}{
+		{"Should accept preferences of the catalog", map[string]int{"pets": 2, "luggage": 3, "quietRide": 1}, "", ""},
+		{"Should fail when preference is not in the catalog", map[string]int{"karaoke": 1}, "karaoke", CodeUnknownValue},
+		{"Should fail when value is not in the preference's domain", map[string]int{"airConditioning": 2}, "airConditioning", CodeOutOfBounds},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p := Preferences{Others: test.others}
+
+			err := p.Validate()
+			if test.code == "" {
+				if err != nil {
+					t.Errorf("expected preferences to be valid, got %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok || len(errs) != 1 || errs[0].Field() != test.field || errs[0].Code() != test.code {
+				t.Errorf("expected %s error on %s, got %v", test.code, test.field, err)
+			}
+		})
+	}
+}
+
+func TestPreferencesJSON(t *testing.T) {
+	t.Run("Should encode every preference as a field", func(t *testing.T) {
+		p := Preferences{Music: PreferenceRegularly, Others: map[string]int{"pets": 1}}
+
+		b, err := json.Marshal(&p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != `{"conversation":0,"music":2,"pets":1,"smoking":0}` {
+			t.Errorf("unexpected JSON %s", b)
+		}
+	})
+
+	t.Run("Should decode the original preferences as fields", func(t *testing.T) {
+		var p Preferences
+		err := json.Unmarshal([]byte(`{"smoking": 1, "conversation": 2, "childSeat": 1}`), &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Smoking != PreferenceOccasionally || p.Conversation != PreferenceRegularly || p.Music != PreferenceNever {
+			t.Errorf("unexpected preferences %+v", p)
+		}
+
+		if value, ok := p.Get(PreferenceChildSeat); !ok || value != 1 {
+			t.Errorf("expected child seat preference to be 1, got %d", value)
+		}
+
+		if _, ok := p.Get(PreferencePets); ok {
+			t.Error("expected pets preference to be missing")
+		}
+	})
+
+	t.Run("Should fail when a value is not a number", func(t *testing.T) {
+		var p Preferences
+		err := json.Unmarshal([]byte(`{"smoking": "never"}`), &p)
+		if err == nil {
 			t.Fail()
 		}
 	})
@@ -0,0 +1,109 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+)
+
+// RatingSummary contains the average number of stars of the reviews a user
+// received for a role, the number of reviews, and how many of them gave each
+// number of stars.
+type RatingSummary struct {
+	Average float64 `json:"average" bson:"average"`
+	Count   int     `json:"count" bson:"count"`
+
+	// Histogram contains the number of reviews that gave each number of
+	// stars, starting with one star.
+	Histogram [StarsMaximum]int `json:"histogram" bson:"histogram"`
+
+	// Legacy contains the number of reviews that gave each number of stars
+	// before reviews were stored individually, starting with one star. They
+	// are counted in the histogram, but cannot be recomputed from reviews.
+	Legacy [StarsMaximum]int `json:"-" bson:"legacy"`
+}
+
+// averageTolerance represents how far the average of a rating summary can be
+// from the one computed from its histogram, to account for rounding.
+const averageTolerance = 1e-6
+
+// NewRatingSummary creates the rating summary of reviews from the number of
+// reviews that gave each number of stars, starting with one star.
+func NewRatingSummary(histogram [StarsMaximum]int) *RatingSummary {
+	s := RatingSummary{Histogram: histogram}
+
+	stars := 0
+	for i, count := range histogram {
+		stars += (i + StarsMinimum) * count
+		s.Count += count
+	}
+
+	if s.Count > 0 {
+		s.Average = float64(stars) / float64(s.Count)
+	}
+
+	return &s
+}
+
+// NewLegacyRatingSummary creates the rating summary of a rating that was
+// stored as a number of stars. The rating counts as a single legacy review,
+// except for a rating of zero, which is the rating users had before being
+// reviewed.
+func NewLegacyRatingSummary(stars float64) *RatingSummary {
+	var histogram [StarsMaximum]int
+
+	rounded := int(math.Round(stars))
+	if rounded > StarsMaximum {
+		rounded = StarsMaximum
+	}
+
+	if rounded >= StarsMinimum {
+		histogram[rounded-StarsMinimum] = 1
+	}
+
+	s := NewRatingSummary(histogram)
+	s.Legacy = histogram
+
+	return s
+}
+
+// WithLegacy creates the rating summary of the reviews counted in the rating
+// summary along with the given legacy reviews.
+func (s *RatingSummary) WithLegacy(legacy [StarsMaximum]int) *RatingSummary {
+	histogram := s.Histogram
+	for i, count := range legacy {
+		histogram[i] += count
+	}
+
+	merged := NewRatingSummary(histogram)
+	merged.Legacy = legacy
+
+	return merged
+}
+
+// Validate validates that the rating summary's average and count are within
+// bounds and consistent with its histogram.
+func (s *RatingSummary) Validate() error {
+	var errs ValidationErrors
+
+	if s.Average < RatingMinimum || s.Average > RatingMaximum {
+		errs.add("average", CodeOutOfBounds, fmt.Sprintf("average is not between (%d) and (%d)", RatingMinimum, RatingMaximum))
+	}
+
+	for _, count := range s.Histogram {
+		if count < 0 {
+			errs.add("histogram", CodeOutOfBounds, "histogram must not contain negative counts")
+			return errs.err()
+		}
+	}
+
+	expected := NewRatingSummary(s.Histogram)
+	if s.Count != expected.Count {
+		errs.add("count", CodeInconsistent, fmt.Sprintf("count (%d) does not match the histogram (%d)", s.Count, expected.Count))
+	}
+
+	if len(errs) == 0 && math.Abs(s.Average-expected.Average) > averageTolerance {
+		errs.add("average", CodeInconsistent, fmt.Sprintf("average (%g) does not match the histogram (%g)", s.Average, expected.Average))
+	}
+
+	return errs.err()
+}
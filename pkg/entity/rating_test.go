@@ -0,0 +1,83 @@
+package entity
+
+import "testing"
+
+func TestNewRatingSummary(t *testing.T) {
+	tests := []struct {
+		histogram [StarsMaximum]int
+		average   float64
+		count     int
+	}{
+		{[StarsMaximum]int{}, 0, 0},
+		{[StarsMaximum]int{0, 0, 0, 0, 1}, 5, 1},
+		{[StarsMaximum]int{0, 0, 0, 1, 1}, 4.5, 2},
+		{[StarsMaximum]int{1, 0, 0, 2, 7}, 4.4, 10},
+	}
+	for _, test := range tests {
+		s := NewRatingSummary(test.histogram)
+		if s.Average != test.average || s.Count != test.count {
+			t.Errorf("expected average %g and count %d for %v, got %g and %d", test.average, test.count, test.histogram, s.Average, s.Count)
+		}
+
+		if err := s.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRatingSummaryValidation(t *testing.T) {
+	t.Run("Should fail when count does not match the histogram", func(t *testing.T) {
+		s := RatingSummary{Average: 5, Count: 2, Histogram: [StarsMaximum]int{0, 0, 0, 0, 1}}
+
+		if _, ok := s.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when histogram contains negative counts", func(t *testing.T) {
+		s := RatingSummary{Histogram: [StarsMaximum]int{-1, 0, 0, 0, 1}}
+
+		if _, ok := s.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should tolerate rounded averages", func(t *testing.T) {
+		s := RatingSummary{Average: 4.6666667, Count: 3, Histogram: [StarsMaximum]int{0, 0, 0, 1, 2}}
+
+		if err := s.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestNewLegacyRatingSummary(t *testing.T) {
+	tests := []struct {
+		stars    float64
+		expected [StarsMaximum]int
+	}{
+		{0, [StarsMaximum]int{}},
+		{1, [StarsMaximum]int{1, 0, 0, 0, 0}},
+		{4, [StarsMaximum]int{0, 0, 0, 1, 0}},
+		{4.6, [StarsMaximum]int{0, 0, 0, 0, 1}},
+		{7, [StarsMaximum]int{0, 0, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		s := NewLegacyRatingSummary(test.stars)
+		if s.Histogram != test.expected || s.Legacy != test.expected {
+			t.Errorf("expected histogram and legacy %v for %g stars, got %v and %v", test.expected, test.stars, s.Histogram, s.Legacy)
+		}
+
+		if err := s.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRatingSummaryWithLegacy(t *testing.T) {
+	s := NewRatingSummary([StarsMaximum]int{0, 0, 0, 0, 1}).WithLegacy([StarsMaximum]int{0, 0, 1, 0, 0})
+
+	if s.Average != 4 || s.Count != 2 || s.Histogram != [StarsMaximum]int{0, 0, 1, 0, 1} || s.Legacy != [StarsMaximum]int{0, 0, 1, 0, 0} {
+		t.Errorf("unexpected rating summary %+v", s)
+	}
+}
@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Review contains the stars and comment that a user gave to another user it
+// shared a trip with.
+type Review struct {
+	ID        ID        `json:"id" bson:"_id,omitempty"`
+	AuthorID  ID        `json:"authorId" bson:"authorId"`
+	SubjectID ID        `json:"subjectId" bson:"subjectId"`
+	TripID    string    `json:"tripId" bson:"tripId"`
+	Role      string    `json:"role" bson:"role"`
+	Stars     int       `json:"stars" bson:"stars"`
+	Comment   string    `json:"comment" bson:"comment"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+const (
+	// ReviewRoleRider represents that the subject of a review was a rider
+	// during the trip. It counts towards the subject's user rating.
+	ReviewRoleRider = "rider"
+
+	// ReviewRoleDriver represents that the subject of a review was the driver
+	// during the trip. It counts towards the subject's driver rating.
+	ReviewRoleDriver = "driver"
+
+	// StarsMinimum represents the minimum number of stars a review can give.
+	StarsMinimum = 1
+
+	// StarsMaximum represents the maximum number of stars a review can give.
+	StarsMaximum = 5
+
+	// CommentMaximumLength represents the maximum number of characters in a
+	// review's comment.
+	CommentMaximumLength = 1000
+)
+
+// Validate validates that the review's required fields are filled out
+// correctly.
+func (r *Review) Validate() error {
+	var errs ValidationErrors
+
+	if r.AuthorID.IsZero() {
+		errs.add("authorId", CodeMissing, "author ID is missing")
+	}
+
+	if r.SubjectID.IsZero() {
+		errs.add("subjectId", CodeMissing, "subject ID is missing")
+	} else if r.AuthorID == r.SubjectID {
+		errs.add("subjectId", CodeSelfReview, "users cannot review themselves")
+	}
+
+	if r.TripID == "" {
+		errs.add("tripId", CodeMissing, "trip ID is missing")
+	}
+
+	if r.Role != ReviewRoleRider && r.Role != ReviewRoleDriver {
+		errs.add("role", CodeUnknownValue, fmt.Sprintf("role must be %s or %s", ReviewRoleRider, ReviewRoleDriver))
+	}
+
+	if r.Stars < StarsMinimum || r.Stars > StarsMaximum {
+		errs.add("stars", CodeOutOfBounds, fmt.Sprintf("stars are not between (%d) and (%d)", StarsMinimum, StarsMaximum))
+	}
+
+	if utf8.RuneCountInString(r.Comment) > CommentMaximumLength {
+		errs.add("comment", CodeTooLong, fmt.Sprintf("comment is longer than %d characters", CommentMaximumLength))
+	}
+
+	return errs.err()
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReviewValidation(t *testing.T) {
+	review := Review{
+		AuthorID:  NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3"),
+		SubjectID: NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c4"),
+		TripID:    "5c6d9a0b4f0e8a0001a1b2c5",
+		Role:      ReviewRoleDriver,
+		Stars:     StarsMaximum,
+		Comment:   "Smooth ride, great playlist.",
+		CreatedAt: time.Now(),
+	}
+
+	t.Run("Should pass when review is valid", func(t *testing.T) {
+		r := review
+
+		if err := r.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should fail when author reviews itself", func(t *testing.T) {
+		r := review
+		r.SubjectID = r.AuthorID
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when trip ID is empty", func(t *testing.T) {
+		r := review
+		r.TripID = ""
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when role is unknown", func(t *testing.T) {
+		r := review
+		r.Role = "passenger"
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when stars are below lower bound", func(t *testing.T) {
+		r := review
+		r.Stars = StarsMinimum - 1
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when stars are above upper bound", func(t *testing.T) {
+		r := review
+		r.Stars = StarsMaximum + 1
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when comment is too long", func(t *testing.T) {
+		r := review
+		r.Comment = strings.Repeat("é", CommentMaximumLength+1)
+
+		if _, ok := r.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+}
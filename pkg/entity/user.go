@@ -8,20 +8,33 @@ import (
 
 // User contains a user's profile.
 type User struct {
-	ID           ID           `json:"id" bson:"_id,omitempty"`
-	SubID        string       `json:"-" bson:"subId"`
-	Email        string       `json:"email" bson:"email"`
-	FirstName    string       `json:"firstName" bson:"firstName"`
-	LastName     string       `json:"lastName" bson:"lastName"`
-	DateOfBirth  time.Time    `json:"dateOfBirth" bson:"dateOfBirth"`
-	PhoneNumber  string       `json:"phoneNumber" bson:"phoneNumber"`
-	Gender       string       `json:"gender" bson:"gender"`
-	Photo        string       `json:"photo" bson:"photo"`
-	Description  string       `json:"description" bson:"description"`
-	Preferences  *Preferences `json:"preferences" bson:"preferences"`
-	SignUpPhase  string       `json:"signUpPhase" bson:"signUpPhase"`
-	UserRating   *int         `json:"userRating" bson:"userRating,ommitempty"`
-	DriverRating *int         `json:"driverRating" bson:"driverRating,ommitempty"`
+	ID            ID             `json:"id" bson:"_id,omitempty"`
+	SubID         string         `json:"-" bson:"subId"`
+	Email         string         `json:"email" bson:"email"`
+	EmailVerified bool           `json:"emailVerified" bson:"emailVerified"`
+	IdentityEmail string         `json:"-" bson:"identityEmail"`
+	FirstName     string         `json:"firstName" bson:"firstName"`
+	LastName      string         `json:"lastName" bson:"lastName"`
+	DateOfBirth   time.Time      `json:"dateOfBirth" bson:"dateOfBirth"`
+	PhoneNumber   string         `json:"phoneNumber" bson:"phoneNumber"`
+	PhoneVerified bool           `json:"phoneVerified" bson:"phoneVerified"`
+	Gender        string         `json:"gender" bson:"gender"`
+	Photo         string         `json:"photo" bson:"photo"`
+	Description   string         `json:"description" bson:"description"`
+	Preferences   *Preferences   `json:"preferences" bson:"preferences"`
+	SignUpPhase   string         `json:"signUpPhase" bson:"signUpPhase"`
+	UserRating    *RatingSummary `json:"userRating" bson:"userRating,omitempty"`
+	DriverRating  *RatingSummary `json:"driverRating" bson:"driverRating,omitempty"`
+
+	// VerifiedDriver is derived from VerifiedDriverUntil, which is the time
+	// at which the first of the user's approved driver documents expires.
+	VerifiedDriver      bool      `json:"verifiedDriver" bson:"-"`
+	VerifiedDriverUntil time.Time `json:"-" bson:"verifiedDriverUntil"`
+
+	// Status tells whether the user's account can be used. StatusChangedAt is
+	// the time at which the account entered its current status.
+	Status          string    `json:"status" bson:"status"`
+	StatusChangedAt time.Time `json:"statusChangedAt" bson:"statusChangedAt"`
 }
 
 const (
@@ -49,65 +62,118 @@ const (
 	// SignUpPhaseDone means that the user has completed all sign up phases.
 	SignUpPhaseDone = "done"
 
-	// RatingMinimum represents the minimum rating than a user could be have.
+	// UserStatusActive means that the user's account can be used.
+	UserStatusActive = "active"
+
+	// UserStatusDeactivated means that the user paused its account, which it
+	// can reactivate whenever it wants.
+	UserStatusDeactivated = "deactivated"
+
+	// UserStatusSuspended means that an administrator suspended the user's
+	// account, which only an administrator can reactivate.
+	UserStatusSuspended = "suspended"
+
+	// UserStatusDeleted means that the user's account was deleted and its
+	// personal information erased.
+	UserStatusDeleted = "deleted"
+
+	// RatingMinimum represents the minimum average rating than a user could
+	// have, which is the average of a user without reviews.
 	RatingMinimum = 0
 
-	// RatingMaximum represents the maximum rating than a user could be have.
-	RatingMaximum = 5
+	// RatingMaximum represents the maximum average rating than a user could
+	// have.
+	RatingMaximum = StarsMaximum
 )
 
+// Normalize normalizes the user's information before it is validated. The
+// phone number is put in the E.164 format, unless it cannot be parsed, in
+// which case it is left as is for the validation to fail.
+func (u *User) Normalize() {
+	if phoneNumber, ok := NormalizePhoneNumber(u.PhoneNumber); ok {
+		u.PhoneNumber = phoneNumber
+	}
+}
+
+// RefreshVerifiedDriver derives whether the user is a verified driver at the
+// given time.
+func (u *User) RefreshVerifiedDriver(now time.Time) {
+	u.VerifiedDriver = now.Before(u.VerifiedDriverUntil)
+}
+
+// CurrentStatus returns the status of the user's account. Users registered
+// before accounts had a status are active.
+func (u *User) CurrentStatus() string {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+
+	return u.Status
+}
+
 // Validate validates that the user's required fields are filled out correctly.
 func (u *User) Validate() error {
+	var errs ValidationErrors
+
 	if u.SubID == "" {
-		return ValidationError{"subscription ID is missing"}
+		errs.add("subId", CodeMissing, "subscription ID is missing")
+	}
+
+	if u.Email != "" && !IsEmail(u.Email) {
+		errs.add("email", CodeMalformed, "email is not a valid email address")
 	}
 
 	if u.FirstName == "" {
-		return ValidationError{"first name is missing"}
+		errs.add("firstName", CodeMissing, "first name is missing")
 	}
 
 	if u.LastName == "" {
-		return ValidationError{"last name is missing"}
+		errs.add("lastName", CodeMissing, "last name is missing")
 	}
 
 	if u.DateOfBirth.IsZero() {
-		return ValidationError{"date of birth is missing"}
+		errs.add("dateOfBirth", CodeMissing, "date of birth is missing")
+	} else if time.Since(u.DateOfBirth) < AgeMinimum {
+		errs.add("dateOfBirth", CodeTooYoung, "must be 18 years of age or older")
 	}
 
-	if time.Since(u.DateOfBirth) < AgeMinimum {
-		return ValidationError{"must be 18 years of age or older"}
+	if u.PhoneNumber != "" && !IsE164(u.PhoneNumber) {
+		errs.add("phoneNumber", CodeMalformed, "phone number is not a valid phone number in the E.164 format (ex. +14501234567)")
 	}
 
 	if u.Gender == "" {
-		return ValidationError{"gender is missing"}
-	}
-
-	if strings.Compare(u.Gender, GenderMale) != 0 &&
+		errs.add("gender", CodeMissing, "gender is missing")
+	} else if strings.Compare(u.Gender, GenderMale) != 0 &&
 		strings.Compare(u.Gender, GenderFemale) != 0 &&
 		strings.Compare(u.Gender, GenderOther) != 0 {
-		return ValidationError{fmt.Sprintf("gender must be %s, %s or %s", GenderMale, GenderFemale, GenderOther)}
+		errs.add("gender", CodeUnknownValue, fmt.Sprintf("gender must be %s, %s or %s", GenderMale, GenderFemale, GenderOther))
 	}
 
 	if u.Preferences != nil {
-		err := u.Preferences.Validate()
-		if err != nil {
-			return err
-		}
+		errs.addNested(u.Preferences.Validate(), "preferences", "")
 	}
 
 	if strings.Compare(u.SignUpPhase, SignUpPhasePersonalInfo) != 0 &&
 		strings.Compare(u.SignUpPhase, SignUpPhasePreferences) != 0 &&
 		strings.Compare(u.SignUpPhase, SignUpPhaseDone) != 0 {
-		return ValidationError{fmt.Sprintf("sign up phase must be %s, %s or %s", SignUpPhasePersonalInfo, SignUpPhasePreferences, SignUpPhaseDone)}
+		errs.add("signUpPhase", CodeUnknownValue, fmt.Sprintf("sign up phase must be %s, %s or %s", SignUpPhasePersonalInfo, SignUpPhasePreferences, SignUpPhaseDone))
+	}
+
+	if u.Status != "" &&
+		strings.Compare(u.Status, UserStatusActive) != 0 &&
+		strings.Compare(u.Status, UserStatusDeactivated) != 0 &&
+		strings.Compare(u.Status, UserStatusSuspended) != 0 &&
+		strings.Compare(u.Status, UserStatusDeleted) != 0 {
+		errs.add("status", CodeUnknownValue, fmt.Sprintf("status must be %s, %s, %s or %s", UserStatusActive, UserStatusDeactivated, UserStatusSuspended, UserStatusDeleted))
 	}
 
-	if u.UserRating != nil && (*u.UserRating < RatingMinimum || *u.UserRating > RatingMaximum) {
-		return ValidationError{fmt.Sprintf("user rating is not between (%d) and (%d)", RatingMinimum, RatingMaximum)}
+	if u.UserRating != nil {
+		errs.addNested(u.UserRating.Validate(), "userRating", "user rating")
 	}
 
-	if u.DriverRating != nil && (*u.DriverRating < RatingMinimum || *u.DriverRating > RatingMaximum) {
-		return ValidationError{fmt.Sprintf("driver rating is not between (%d) and (%d)", RatingMinimum, RatingMaximum)}
+	if u.DriverRating != nil {
+		errs.addNested(u.DriverRating.Validate(), "driverRating", "driver rating")
 	}
 
-	return nil
+	return errs.err()
 }
@@ -5,6 +5,13 @@ import (
 	"time"
 )
 
+func newRating(stars int) *RatingSummary {
+	var histogram [StarsMaximum]int
+	histogram[stars-StarsMinimum] = 1
+
+	return NewRatingSummary(histogram)
+}
+
 func TestUserValidation(t *testing.T) {
 	var preferences = Preferences{
 		Smoking:      PreferenceNever,
@@ -20,21 +27,21 @@ func TestUserValidation(t *testing.T) {
 		FirstName:    "Harold",
 		LastName:     "The Great",
 		DateOfBirth:  time.Date(1950, time.February, 12, 0, 0, 0, 0, location),
-		PhoneNumber:  "(450) 123-4567",
+		PhoneNumber:  "+14501234567",
 		Gender:       GenderMale,
 		Photo:        "https://hide-the-pain.meme/harold.png",
 		Description:  "So much pain.",
 		Preferences:  &preferences,
 		SignUpPhase:  SignUpPhasePersonalInfo,
-		UserRating:   4,
-		DriverRating: 2,
+		UserRating:   newRating(4),
+		DriverRating: newRating(2),
 	}
 
 	t.Run("Should fail when subscription ID is empty", func(t *testing.T) {
 		u := user
 		u.SubID = ""
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -43,7 +50,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.FirstName = ""
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -52,7 +59,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.LastName = ""
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -61,7 +68,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.DateOfBirth = time.Time{}
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -70,7 +77,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.DateOfBirth = time.Now().AddDate(-17, 0, -1)
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -79,7 +86,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.Gender = ""
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -88,7 +95,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.Gender = "Harold"
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -143,6 +150,26 @@ func TestUserValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("Should fail when phone number is not in E.164 format", func(t *testing.T) {
+		u := user
+		u.PhoneNumber = "(450) 123-4567"
+
+		if _, ok := u.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should succeed when phone number is normalized", func(t *testing.T) {
+		u := user
+		u.PhoneNumber = "(450) 123-4567"
+		u.Normalize()
+
+		err := u.Validate()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
 	t.Run("Should succeed when preferences are nil", func(t *testing.T) {
 		u := user
 		u.Preferences = nil
@@ -157,7 +184,7 @@ func TestUserValidation(t *testing.T) {
 		u := user
 		u.SignUpPhase = "Harold"
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
@@ -192,39 +219,104 @@ func TestUserValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("Should fail when status is not valid", func(t *testing.T) {
+		u := user
+		u.Status = "Harold"
+
+		if _, ok := u.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	for _, status := range []string{"", UserStatusActive, UserStatusDeactivated, UserStatusSuspended, UserStatusDeleted} {
+		t.Run("Should succeed when status is \""+status+"\"", func(t *testing.T) {
+			u := user
+			u.Status = status
+
+			err := u.Validate()
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
 	t.Run("Should fail when user rating is over then 5", func(t *testing.T) {
 		u := user
-		u.UserRating = 6
+		u.UserRating = &RatingSummary{Average: 6, Count: 1, Histogram: [StarsMaximum]int{0, 0, 0, 0, 1}}
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
 
 	t.Run("Should fail when user rating is under then 0", func(t *testing.T) {
 		u := user
-		u.UserRating = -1
+		u.UserRating = &RatingSummary{Average: -1}
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
 
 	t.Run("Should fail when driver rating is over then 5", func(t *testing.T) {
 		u := user
-		u.DriverRating = 6
+		u.DriverRating = &RatingSummary{Average: 6, Count: 1, Histogram: [StarsMaximum]int{0, 0, 0, 0, 1}}
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
 
 	t.Run("Should fail when driver rating is under then 0", func(t *testing.T) {
 		u := user
-		u.DriverRating = -1
+		u.DriverRating = &RatingSummary{Average: -1}
 
-		if _, ok := u.Validate().(ValidationError); !ok {
+		if _, ok := u.Validate().(ValidationErrors); !ok {
 			t.Fail()
 		}
 	})
+
+	t.Run("Should fail when driver rating does not match its histogram", func(t *testing.T) {
+		u := user
+		u.DriverRating = &RatingSummary{Average: 4, Count: 2, Histogram: [StarsMaximum]int{0, 0, 0, 1, 0}}
+
+		if _, ok := u.Validate().(ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestUserValidationCollectsEveryError(t *testing.T) {
+	u := User{
+		SubID:       "harold|hide.the.pain",
+		FirstName:   "Harold",
+		Gender:      "Harold",
+		Preferences: &Preferences{Smoking: PreferenceNever, Conversation: -1, Music: 3},
+		SignUpPhase: SignUpPhaseDone,
+	}
+
+	errs, ok := u.Validate().(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected validation errors, got %v", u.Validate())
+	}
+
+	expected := []struct {
+		field string
+		code  string
+	}{
+		{"lastName", CodeMissing},
+		{"dateOfBirth", CodeMissing},
+		{"gender", CodeUnknownValue},
+		{"preferences.conversation", CodeOutOfBounds},
+		{"preferences.music", CodeOutOfBounds},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d (%v)", len(expected), len(errs), errs)
+	}
+
+	for i, e := range expected {
+		if errs[i].Field() != e.field || errs[i].Code() != e.code {
+			t.Errorf("expected error %d to be %s on %q, got %s on %q", i, e.code, e.field, errs[i].Code(), errs[i].Field())
+		}
+	}
 }
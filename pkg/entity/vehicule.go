@@ -2,6 +2,7 @@ package entity
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -21,29 +22,185 @@ type Vehicule struct {
 const (
 	// YearMinimum represents the minimum year of a car.
 	YearMinimum = 1900
+
+	// SeatsMinimum represents the default minimum number of seats of a car.
+	SeatsMinimum = 1
+
+	// SeatsMaximum represents the default maximum number of seats of a car.
+	SeatsMaximum = 8
 )
 
-// Validate validates that the vehicules's required fields are filled out correctly.
+// YearMaximum returns the maximum year of a car at the given time. Cars of
+// the next model year are usually sold during the current year, so they are
+// allowed.
+func YearMaximum(now time.Time) int {
+	return now.Year() + 1
+}
+
+// An Accessory is an accessory that a vehicule can have. Vehicules refer to
+// their accessories by code, while the name is meant to be displayed.
+type Accessory struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// Accessories contains the accessories that vehicules can have by default.
+var Accessories = []Accessory{
+	{"airConditioning", "Air conditioning"},
+	{"heatedSeats", "Heated seats"},
+	{"bikeRack", "Bike rack"},
+	{"skiRack", "Ski rack"},
+	{"roofBox", "Roof box"},
+	{"childSeat", "Child seat"},
+	{"usbCharger", "USB charger"},
+	{"petFriendly", "Pet friendly"},
+	{"wheelchairAccessible", "Wheelchair accessible"},
+	{"winterTires", "Winter tires"},
+}
+
+// A VehiculePolicy contains the rules that vehicules must satisfy, on top of
+// the ones that always apply.
+type VehiculePolicy struct {
+	// SeatsMinimum and SeatsMaximum specify the range of the number of seats.
+	SeatsMinimum int
+	SeatsMaximum int
+
+	// Accessories specifies the accessories that vehicules can have.
+	Accessories []Accessory
+}
+
+// DefaultVehiculePolicy is the policy used to validate vehicules when no other
+// policy is given.
+var DefaultVehiculePolicy = VehiculePolicy{
+	SeatsMinimum: SeatsMinimum,
+	SeatsMaximum: SeatsMaximum,
+	Accessories:  Accessories,
+}
+
+// allowsAccessory returns whether the policy allows the accessory with the
+// given code.
+func (p *VehiculePolicy) allowsAccessory(code string) bool {
+	for _, a := range p.Accessories {
+		if a.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// colorsByName maps the names of the common colors, in English and in French,
+// to their normalized name.
+var colorsByName = map[string]string{
+	"black":   "black",
+	"noir":    "black",
+	"noire":   "black",
+	"white":   "white",
+	"blanc":   "white",
+	"blanche": "white",
+	"grey":    "gray",
+	"gray":    "gray",
+	"gris":    "gray",
+	"grise":   "gray",
+	"silver":  "silver",
+	"argent":  "silver",
+	"red":     "red",
+	"rouge":   "red",
+	"blue":    "blue",
+	"bleu":    "blue",
+	"bleue":   "blue",
+	"green":   "green",
+	"vert":    "green",
+	"verte":   "green",
+	"yellow":  "yellow",
+	"jaune":   "yellow",
+	"orange":  "orange",
+	"brown":   "brown",
+	"brun":    "brown",
+	"brune":   "brown",
+	"beige":   "beige",
+	"gold":    "gold",
+	"or":      "gold",
+	"purple":  "purple",
+	"mauve":   "purple",
+	"violet":  "purple",
+}
+
+// NormalizeColor normalizes the name of a color, so that the same color is
+// always stored the same way. Common colors are translated to their English
+// name (ex. "Noir" becomes "black"), while other colors are only trimmed and
+// put in lower case.
+func NormalizeColor(color string) string {
+	color = strings.ToLower(strings.Join(strings.Fields(color), " "))
+
+	if name, ok := colorsByName[color]; ok {
+		return name
+	}
+
+	return color
+}
+
+// Normalize normalizes the vehicule's information before it is validated.
+// The make and model are trimmed, the color is normalized and duplicate
+// accessories are removed.
+func (v *Vehicule) Normalize() {
+	v.Make = strings.TrimSpace(v.Make)
+	v.Model = strings.TrimSpace(v.Model)
+	v.Color = NormalizeColor(v.Color)
+
+	if v.Accessories != nil {
+		accessories := make([]string, 0, len(v.Accessories))
+		seen := make(map[string]bool)
+		for _, a := range v.Accessories {
+			if !seen[a] {
+				seen[a] = true
+				accessories = append(accessories, a)
+			}
+		}
+		v.Accessories = accessories
+	}
+}
+
+// Validate validates that the vehicules's required fields are filled out
+// correctly according to the default policy.
 func (v *Vehicule) Validate() error {
+	return v.ValidatePolicy(&DefaultVehiculePolicy)
+}
+
+// ValidatePolicy validates that the vehicules's required fields are filled out
+// correctly according to the given policy.
+func (v *Vehicule) ValidatePolicy(p *VehiculePolicy) error {
+	var errs ValidationErrors
+
 	if v.UserID.IsZero() {
-		return ValidationError{fmt.Sprintf("id must not be nil")}
+		errs.add("userId", CodeMissing, "user ID must not be nil")
 	}
 
-	if v.Year <= YearMinimum && v.Year > time.Now().Year() {
-		return ValidationError{fmt.Sprintf("year must me between %d and %d", YearMinimum, time.Now().Year())}
+	if yearMaximum := YearMaximum(time.Now()); v.Year < YearMinimum || v.Year > yearMaximum {
+		errs.add("year", CodeOutOfBounds, fmt.Sprintf("year must be between %d and %d", YearMinimum, yearMaximum))
 	}
 
 	if v.Make == "" {
-		return ValidationError{"make is missing"}
+		errs.add("make", CodeMissing, "make is missing")
+	}
+
+	if v.Model == "" {
+		errs.add("model", CodeMissing, "model is missing")
 	}
 
 	if v.Color == "" {
-		return ValidationError{"color is missing"}
+		errs.add("color", CodeMissing, "color is missing")
+	}
+
+	if v.Seats < p.SeatsMinimum || v.Seats > p.SeatsMaximum {
+		errs.add("seats", CodeOutOfBounds, fmt.Sprintf("number of seats must be between %d and %d", p.SeatsMinimum, p.SeatsMaximum))
 	}
 
-	if v.Seats < 1 {
-		return ValidationError{"minimum number of seats is 1"}
+	for i, a := range v.Accessories {
+		if !p.allowsAccessory(a) {
+			errs.add(fmt.Sprintf("accessories.%d", i), CodeUnknownValue, fmt.Sprintf("accessory \"%s\" is unknown", a))
+		}
 	}
 
-	return nil
+	return errs.err()
 }
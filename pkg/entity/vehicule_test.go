@@ -2,73 +2,150 @@ package entity
 
 import (
 	"testing"
+	"time"
 )
 
 func TestVehiculeValidation(t *testing.T) {
-	var accessories = []string{"A/C", "Heating Seats"}
-
 	var vehicule = Vehicule{
+		UserID:      NewIDFromHex("5c8f9ddfdc5bda1a3c2a2f1b"),
 		Photo:       "https://hide-the-pain.meme/harold.png",
 		Year:        2018,
 		Make:        "Audi",
 		Model:       "A4",
-		Color:       "Noir",
-		Seats:       1,
-		Accessories: accessories,
+		Color:       "black",
+		Seats:       4,
+		Accessories: []string{"airConditioning", "heatedSeats"},
 	}
 
-	t.Run("Should fail when user ID is empty", func(t *testing.T) {
-		v := vehicule
-		v.UserID = ""
-
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
-
-	t.Run("Should fail when year is not valid", func(t *testing.T) {
-		v := vehicule
-		v.Year = 0
-
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
-
-	t.Run("Should fail when make is empty", func(t *testing.T) {
-		v := vehicule
-		v.Make = ""
-
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
-
-	t.Run("Should fail when model is empty", func(t *testing.T) {
-		v := vehicule
-		v.Model = ""
-
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
-
-	t.Run("Should fail when color is empty", func(t *testing.T) {
-		v := vehicule
-		v.Color = ""
-
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
+	nextYear := time.Now().Year() + 1
+
+	tests := []struct {
+		name   string
+		modify func(v *Vehicule)
+		field  string
+		code   string
+	}{
+		{"Should succeed when vehicule is valid", func(v *Vehicule) {}, "", ""},
+		{"Should fail when user ID is empty", func(v *Vehicule) { v.UserID = "" }, "userId", CodeMissing},
+		{"Should fail when year is missing", func(v *Vehicule) { v.Year = 0 }, "year", CodeOutOfBounds},
+		{"Should fail when year is before the minimum", func(v *Vehicule) { v.Year = YearMinimum - 1 }, "year", CodeOutOfBounds},
+		{"Should succeed when year is the minimum", func(v *Vehicule) { v.Year = YearMinimum }, "", ""},
+		{"Should succeed when year is the next model year", func(v *Vehicule) { v.Year = nextYear }, "", ""},
+		{"Should fail when year is after the next model year", func(v *Vehicule) { v.Year = nextYear + 1 }, "year", CodeOutOfBounds},
+		{"Should fail when make is empty", func(v *Vehicule) { v.Make = "" }, "make", CodeMissing},
+		{"Should fail when model is empty", func(v *Vehicule) { v.Model = "" }, "model", CodeMissing},
+		{"Should fail when color is empty", func(v *Vehicule) { v.Color = "" }, "color", CodeMissing},
+		{"Should fail when seats is less than 1", func(v *Vehicule) { v.Seats = 0 }, "seats", CodeOutOfBounds},
+		{"Should succeed when seats is 1", func(v *Vehicule) { v.Seats = 1 }, "", ""},
+		{"Should succeed when seats is 8", func(v *Vehicule) { v.Seats = 8 }, "", ""},
+		{"Should fail when seats is more than 8", func(v *Vehicule) { v.Seats = 9 }, "seats", CodeOutOfBounds},
+		{"Should succeed when accessories are nil", func(v *Vehicule) { v.Accessories = nil }, "", ""},
+		{"Should fail when an accessory is unknown", func(v *Vehicule) { v.Accessories = []string{"heatedSeats", "A/C"} }, "accessories.1", CodeUnknownValue},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			v := vehicule
+			test.modify(&v)
+
+			err := v.Validate()
+			if test.field == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok || len(errs) != 1 || errs[0].Field() != test.field || errs[0].Code() != test.code {
+				t.Errorf("expected %s on %q, got %v", test.code, test.field, err)
+			}
+		})
+	}
+}
 
-	t.Run("Should fail when seats is less than 1", func(t *testing.T) {
-		v := vehicule
-		v.Seats = 1
+func TestVehiculeValidatePolicy(t *testing.T) {
+	policy := VehiculePolicy{
+		SeatsMinimum: 2,
+		SeatsMaximum: 4,
+		Accessories:  []Accessory{{"bikeRack", "Bike rack"}},
+	}
 
-		if _, ok := v.Validate().(ValidationError); !ok {
-			t.Fail()
-		}
-	})
+	tests := []struct {
+		name        string
+		seats       int
+		accessories []string
+		valid       bool
+	}{
+		{"Should succeed when vehicule satisfies the policy", 3, []string{"bikeRack"}, true},
+		{"Should fail when seats are under the policy's minimum", 1, nil, false},
+		{"Should fail when seats are over the policy's maximum", 5, nil, false},
+		{"Should fail when accessory is not allowed by the policy", 3, []string{"airConditioning"}, false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			v := Vehicule{
+				UserID:      NewIDFromHex("5c8f9ddfdc5bda1a3c2a2f1b"),
+				Year:        2018,
+				Make:        "Audi",
+				Model:       "A4",
+				Color:       "black",
+				Seats:       test.seats,
+				Accessories: test.accessories,
+			}
+
+			err := v.ValidatePolicy(&policy)
+			if (err == nil) != test.valid {
+				t.Errorf("expected valid to be %t, got %v", test.valid, err)
+			}
+		})
+	}
+}
 
+func TestVehiculeNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		vehicule Vehicule
+		expected Vehicule
+	}{
+		{
+			"Should translate common colors",
+			Vehicule{Color: " Noir "},
+			Vehicule{Color: "black"},
+		},
+		{
+			"Should put other colors in lower case",
+			Vehicule{Color: "Bleu  Nuit"},
+			Vehicule{Color: "bleu nuit"},
+		},
+		{
+			"Should trim make and model",
+			Vehicule{Make: " Audi ", Model: "A4 "},
+			Vehicule{Make: "Audi", Model: "A4"},
+		},
+		{
+			"Should remove duplicate accessories",
+			Vehicule{Accessories: []string{"bikeRack", "skiRack", "bikeRack"}},
+			Vehicule{Accessories: []string{"bikeRack", "skiRack"}},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			v := test.vehicule
+			v.Normalize()
+
+			if v.Make != test.expected.Make || v.Model != test.expected.Model || v.Color != test.expected.Color ||
+				len(v.Accessories) != len(test.expected.Accessories) {
+				t.Fatalf("expected %+v, got %+v", test.expected, v)
+			}
+
+			for i := range v.Accessories {
+				if v.Accessories[i] != test.expected.Accessories[i] {
+					t.Errorf("expected %+v, got %+v", test.expected, v)
+				}
+			}
+		})
+	}
 }
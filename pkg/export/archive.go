@@ -0,0 +1,154 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+)
+
+// An Archive contains the personal data of a user, collected from the
+// repositories, and writes it as a zip archive made of JSON documents along
+// with the user's photos.
+type Archive struct {
+	User           *entity.User
+	Vehicules      []*entity.Vehicule
+	Reviews        []*entity.Review
+	WrittenReviews []*entity.Review
+	Documents      []*entity.Document
+	CreatedAt      time.Time
+
+	// photoKeys contains the keys, in the media store, of the photos that
+	// the user uploaded.
+	photoKeys []string
+	store     media.Store
+}
+
+// profile is the user's profile as exported, which includes the fields that
+// are never returned by the endpoints but are still personal data.
+type profile struct {
+	*entity.User
+	SubID         string `json:"subId"`
+	IdentityEmail string `json:"identityEmail"`
+}
+
+// ratings contains the ratings of the user along with the reviews it
+// received and the reviews it wrote.
+type ratings struct {
+	UserRating     *entity.RatingSummary `json:"userRating"`
+	DriverRating   *entity.RatingSummary `json:"driverRating"`
+	Reviews        []*entity.Review      `json:"reviews"`
+	WrittenReviews []*entity.Review      `json:"writtenReviews"`
+}
+
+// audit contains the history of the user's dealings with the administrators,
+// which is made of the driver documents it uploaded and their reviews.
+type audit struct {
+	Documents []*entity.Document `json:"documents"`
+}
+
+// FileName returns the name under which the archive is downloaded.
+func (a *Archive) FileName() string {
+	return archiveFileName(a.User.ID, a.CreatedAt)
+}
+
+// archiveFileName returns the name under which the archive of a user created
+// at the given time is downloaded.
+func archiveFileName(userID entity.ID, createdAt time.Time) string {
+	return fmt.Sprintf("ecovo-%s-%s.zip", userID, createdAt.UTC().Format("20060102"))
+}
+
+// Write writes the archive as a zip file. Photos that are no longer in the
+// media store are left out.
+func (a *Archive) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	documents := []struct {
+		name string
+		v    interface{}
+	}{
+		{"profile.json", profile{a.User, a.User.SubID, a.User.IdentityEmail}},
+		{"preferences.json", a.User.Preferences},
+		{"vehicules.json", a.Vehicules},
+		{"ratings.json", ratings{a.User.UserRating, a.User.DriverRating, a.Reviews, a.WrittenReviews}},
+		{"audit.json", audit{a.Documents}},
+	}
+	for _, d := range documents {
+		err := a.writeJSON(zw, d.name, d.v)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range a.photoKeys {
+		err := a.writePhoto(zw, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return fmt.Errorf("export.Archive: failed to write archive (%s)", err)
+	}
+
+	return nil
+}
+
+// writeJSON adds a JSON document to the archive.
+func (a *Archive) writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("export.Archive: failed to create \"%s\" (%s)", name, err)
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(v)
+	if err != nil {
+		return fmt.Errorf("export.Archive: failed to write \"%s\" (%s)", name, err)
+	}
+
+	return nil
+}
+
+// writePhoto copies a photo from the media store to the archive, under its
+// key. Photos are already compressed, so they are stored as is.
+func (a *Archive) writePhoto(zw *zip.Writer, key string) error {
+	r, err := a.store.Get(key)
+	if _, ok := err.(media.NotFoundError); ok {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: key, Method: zip.Store, Modified: a.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("export.Archive: failed to create \"%s\" (%s)", key, err)
+	}
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("export.Archive: failed to write \"%s\" (%s)", key, err)
+	}
+
+	return nil
+}
+
+// photoKey returns the key, in the media store, of a photo served under the
+// given base URL. Photos served from anywhere else, such as the one of the
+// user's identity provider, are not stored locally.
+func photoKey(photoURL string, baseURL string) (string, bool) {
+	key := strings.TrimPrefix(photoURL, baseURL+"/")
+	if key == photoURL || !strings.HasPrefix(key, "photos/") {
+		return "", false
+	}
+
+	return key, true
+}
@@ -0,0 +1,54 @@
+package export
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to export the data of another user.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "exportOfAnotherUser",
+	Detail: "not allowed to export the data of another user",
+}
+
+// A NotFoundError is an error that represents that no export job was found,
+// or that it expired.
+type NotFoundError struct {
+	entity.BaseError
+}
+
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "exportNotFound",
+	Detail: "export does not exist or expired",
+}
+
+// An InvalidLinkError is an error that represents that the token of a
+// download link is malformed, was not signed by the service, or was issued for
+// another export.
+type InvalidLinkError struct {
+	entity.BaseError
+}
+
+var invalidLink = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindInvalid,
+	Code:   "exportLinkInvalid",
+	Field:  "token",
+	Detail: "download link is not valid",
+}
+
+// An ExpiredLinkError is an error that represents that a download link
+// expired.
+type ExpiredLinkError struct {
+	entity.BaseError
+}
+
+var expiredLink = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindInvalid,
+	Code:   "exportLinkExpired",
+	Field:  "token",
+	Detail: "download link expired, request a new one",
+}
@@ -0,0 +1,55 @@
+// Package export gives users a copy of the personal data that the service
+// keeps about them, as required by Quebec's Law 25 and the GDPR. The data is
+// exported as a zip archive, either directly or by an asynchronous job whose
+// archive is downloaded through a signed link that expires.
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A Job is an export that is generated in the background. Once it is ready,
+// its archive can be downloaded until the job expires.
+type Job struct {
+	ID          entity.ID `json:"id"`
+	UserID      entity.ID `json:"userId"`
+	Status      string    `json:"status"`
+	Size        int64     `json:"size,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+
+	// DownloadURL is the signed link from which the archive is downloaded.
+	// It is generated whenever a ready job is retrieved and is never stored.
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+const (
+	// JobStatusPending means that the archive is being generated.
+	JobStatusPending = "pending"
+
+	// JobStatusReady means that the archive can be downloaded.
+	JobStatusReady = "ready"
+
+	// JobStatusFailed means that the archive could not be generated.
+	JobStatusFailed = "failed"
+)
+
+// archiveKey returns the key under which the archive of the job is kept in the
+// media store.
+func (j *Job) archiveKey() string {
+	return fmt.Sprintf("exports/%s/%s.zip", j.UserID, j.ID)
+}
+
+// FileName returns the name under which the archive of the job is downloaded.
+func (j *Job) FileName() string {
+	return archiveFileName(j.UserID, j.CreatedAt)
+}
+
+// expired returns whether the job expired at the given time.
+func (j *Job) expired(now time.Time) bool {
+	return !now.Before(j.ExpiresAt)
+}
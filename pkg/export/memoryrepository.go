@@ -0,0 +1,80 @@
+package export
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on export
+// jobs kept in memory. It is safe for concurrent use and is meant to be used
+// in tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu   sync.RWMutex
+	jobs map[entity.ID]*Job
+}
+
+// NewMemoryRepository creates an empty in-memory export job repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{jobs: make(map[entity.ID]*Job)}
+}
+
+// FindByID retrieves the export job with the given ID, if it exists.
+func (r *MemoryRepository) FindByID(ID entity.ID) (*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j, ok := r.jobs[ID]
+	if !ok {
+		return nil, fmt.Errorf("export.MemoryRepository: no job found with ID \"%s\" (%w)", ID, mongo.ErrNoDocuments)
+	}
+
+	c := *j
+	return &c, nil
+}
+
+// FindExpired retrieves the export jobs that expired at the given time.
+func (r *MemoryRepository) FindExpired(now time.Time) ([]*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []*Job
+	for _, j := range r.jobs {
+		if j.expired(now) {
+			c := *j
+			jobs = append(jobs, &c)
+		}
+	}
+
+	return jobs, nil
+}
+
+// Save stores the export job in memory, replacing the job with the same ID, if
+// any.
+func (r *MemoryRepository) Save(j *Job) error {
+	if j == nil {
+		return fmt.Errorf("export.MemoryRepository: failed to save job (job is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *j
+	c.DownloadURL = ""
+	r.jobs[j.ID] = &c
+
+	return nil
+}
+
+// Delete removes the export job with the given ID from memory.
+func (r *MemoryRepository) Delete(ID entity.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, ID)
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on export
+// jobs in a MongoDB collection.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	UserID      primitive.ObjectID `bson:"userId"`
+	Status      string             `bson:"status"`
+	Size        int64              `bson:"size"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+	CompletedAt time.Time          `bson:"completedAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt"`
+}
+
+func newDocumentFromEntity(j *Job) (*document, error) {
+	if j == nil {
+		return nil, fmt.Errorf("export.MongoRepository: entity is nil")
+	}
+
+	ID, err := primitive.ObjectIDFromHex(j.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to create object ID")
+	}
+
+	userID, err := primitive.ObjectIDFromHex(j.UserID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to create user object ID")
+	}
+
+	return &document{
+		ID,
+		userID,
+		j.Status,
+		j.Size,
+		j.CreatedAt,
+		j.CompletedAt,
+		j.ExpiresAt,
+	}, nil
+}
+
+func (d document) Entity() *Job {
+	return &Job{
+		ID:          entity.NewIDFromHex(d.ID.Hex()),
+		UserID:      entity.NewIDFromHex(d.UserID.Hex()),
+		Status:      d.Status,
+		Size:        d.Size,
+		CreatedAt:   d.CreatedAt,
+		CompletedAt: d.CompletedAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
+
+// jobRetention represents how long expired jobs are kept before MongoDB
+// removes them. Expired jobs are normally removed along with their archive by
+// the service's sweeps, so that this only applies to the jobs that the sweeps
+// kept failing to remove.
+const jobRetention = 7 * 24 * time.Hour
+
+// NewMongoRepository creates an export job repository for a MongoDB collection
+// and makes sure expired jobs are eventually removed.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("export.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(int32(jobRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindByID retrieves the export job with the given ID, if it exists.
+func (r *MongoRepository) FindByID(ID entity.ID) (*Job, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	var d document
+	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("export.MongoRepository: no job found with ID \"%s\" (%w)", ID, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to find job with ID \"%s\" (%s)", ID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// FindExpired retrieves the export jobs that expired at the given time.
+func (r *MongoRepository) FindExpired(now time.Time) ([]*Job, error) {
+	filter := bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$lte", Value: now}}}}
+	cur, err := r.collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("export.MongoRepository: failed to find expired jobs (%s)", err)
+	}
+	defer cur.Close(context.TODO())
+
+	var jobs []*Job
+	for cur.Next(context.TODO()) {
+		var d document
+		err := cur.Decode(&d)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, d.Entity())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Save stores the export job in the collection, replacing the job with the
+// same ID, if any.
+func (r *MongoRepository) Save(j *Job) error {
+	d, err := newDocumentFromEntity(j)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: d.ID}}
+	_, err = r.collection.ReplaceOne(context.TODO(), filter, d, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("export.MongoRepository: failed to save job \"%s\" (%s)", j.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the export job with the given ID from the collection.
+func (r *MongoRepository) Delete(ID entity.ID) error {
+	objectID, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return fmt.Errorf("export.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	_, err = r.collection.DeleteOne(context.TODO(), filter)
+	if err != nil {
+		return fmt.Errorf("export.MongoRepository: failed to delete job \"%s\" (%s)", ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package export
+
+import (
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on export jobs in a database. When no job is found, the error of
+// a lookup wraps mongo.ErrNoDocuments.
+type Repository interface {
+	FindByID(ID entity.ID) (*Job, error)
+	FindExpired(now time.Time) ([]*Job, error)
+	Save(j *Job) error
+	Delete(ID entity.ID) error
+}
@@ -0,0 +1,423 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/rating"
+	"azure.com/ecovo/user-service/pkg/signed"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+	"azure.com/ecovo/user-service/pkg/verification"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves exporting the data of users.
+type UseCase interface {
+	Export(userID entity.ID, caller *user.Caller) (*Archive, error)
+	Start(userID entity.ID, caller *user.Caller) (*Job, error)
+	FindJob(ID entity.ID, userID entity.ID, caller *user.Caller) (*Job, error)
+	Open(ID entity.ID, token string) (*Job, io.ReadCloser, error)
+}
+
+// Config contains the information required to generate archives and sign
+// their download links.
+type Config struct {
+	// Secret specifies the key used to sign the download links.
+	Secret []byte
+
+	// BaseURL specifies the URL under which the service's endpoints are
+	// served, used in the download links (ex. https://users.ecovo.ca).
+	BaseURL string
+
+	// MediaBaseURL specifies the URL under which the keys of the media store
+	// are served, so that the photos that were uploaded to it can be
+	// recognized and added to the archives.
+	//
+	// An empty URL means that photos are left out.
+	MediaBaseURL string
+
+	// LinkTTL specifies how long a download link can be used after it was
+	// generated. A link never outlives its job.
+	//
+	// Zero means DefaultLinkTTL.
+	LinkTTL time.Duration
+
+	// Retention specifies how long the archive of a job is kept after the
+	// job was started.
+	//
+	// Zero means DefaultRetention.
+	Retention time.Duration
+}
+
+const (
+	// DefaultLinkTTL represents the default amount of time during which a
+	// download link can be used.
+	DefaultLinkTTL = time.Hour
+
+	// DefaultRetention represents the default amount of time during which the
+	// archive of a job is kept.
+	DefaultRetention = 24 * time.Hour
+
+	// SweepInterval represents how often the expired jobs should be swept, so
+	// that their archive is not kept much longer than the retention.
+	SweepInterval = 15 * time.Minute
+)
+
+// validate looks at the configuration's contents to ensure it has all the
+// required fields.
+func (conf *Config) validate() error {
+	if len(conf.Secret) == 0 {
+		return errors.New("missing secret")
+	}
+
+	if conf.BaseURL == "" {
+		return errors.New("missing base URL")
+	}
+
+	if _, err := url.Parse(conf.BaseURL); err != nil {
+		return fmt.Errorf("malformed base URL (%s)", err)
+	}
+
+	return nil
+}
+
+// A Service handles the business logic related to exporting the data of
+// users. The data is read directly from the repositories, so that nothing is
+// left out by the rules that the other services apply to their callers.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+	vRepo    vehicule.Repository
+	rRepo    rating.Repository
+	dRepo    verification.Repository
+	store    media.Store
+	config   Config
+	now      func() time.Time
+
+	// run runs the generation of the archive of a job.
+	run func(f func())
+}
+
+// NewService creates an export service that keeps its jobs in a repository
+// and their archives in a store.
+func NewService(repo Repository, uService user.UseCase, vRepo vehicule.Repository, rRepo rating.Repository, dRepo verification.Repository, store media.Store, config *Config) (*Service, error) {
+	if config == nil {
+		return nil, fmt.Errorf("export: missing configuration")
+	}
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("export: configuration %s", err)
+	}
+
+	c := *config
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	c.MediaBaseURL = strings.TrimSuffix(c.MediaBaseURL, "/")
+	if c.LinkTTL == 0 {
+		c.LinkTTL = DefaultLinkTTL
+	}
+
+	if c.Retention == 0 {
+		c.Retention = DefaultRetention
+	}
+
+	return &Service{repo, uService, vRepo, rRepo, dRepo, store, c, time.Now, func(f func()) { go f() }}, nil
+}
+
+// Export collects the data of the user with the given ID so that it can be
+// written right away. Unless the caller is privileged, it can only export its
+// own data.
+func (s *Service) Export(userID entity.ID, caller *user.Caller) (*Archive, error) {
+	u, err := s.checkCaller(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.collect(u, s.now())
+}
+
+// Start starts a job that generates the archive of the user with the given ID
+// in the background, for exports that are too large to be written right away.
+// Unless the caller is privileged, it can only export its own data.
+func (s *Service) Start(userID entity.ID, caller *user.Caller) (*Job, error) {
+	u, err := s.checkCaller(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	j := &Job{
+		ID:        entity.NewIDFromHex(primitive.NewObjectID().Hex()),
+		UserID:    u.ID,
+		Status:    JobStatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.Retention),
+	}
+	err = s.repo.Save(j)
+	if err != nil {
+		return nil, err
+	}
+
+	started := *j
+	s.run(func() { s.generate(j, u) })
+
+	return &started, nil
+}
+
+// generate writes the archive of a job to the store and marks the job as
+// ready, or as failed when the archive could not be written.
+func (s *Service) generate(j *Job, u *entity.User) {
+	var size int64
+	a, err := s.collect(u, j.CreatedAt)
+	if err == nil {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(a.Write(pw))
+		}()
+
+		counter := &countingReader{r: pr}
+		err = s.store.Put(j.archiveKey(), "application/zip", counter)
+		pr.CloseWithError(err)
+		size = counter.n
+	}
+
+	j.CompletedAt = s.now()
+	if err != nil {
+		j.Status = JobStatusFailed
+	} else {
+		j.Status = JobStatusReady
+		j.Size = size
+	}
+
+	_ = s.repo.Save(j)
+}
+
+// FindJob retrieves the export job with the given ID of the user with the
+// given ID. A job that is ready comes with a signed link from which its archive
+// can be downloaded. Unless the caller is privileged, it can only see its own
+// jobs.
+func (s *Service) FindJob(ID entity.ID, userID entity.ID, caller *user.Caller) (*Job, error) {
+	_, err := s.checkCaller(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := s.findJob(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if j.UserID != userID {
+		return nil, NotFoundError{notFound.New(fmt.Sprintf("export.Service: no job found with ID \"%s\" for user \"%s\"", ID, userID))}
+	}
+
+	if j.Status == JobStatusReady {
+		expiresAt := s.now().Add(s.config.LinkTTL)
+		if expiresAt.After(j.ExpiresAt) {
+			expiresAt = j.ExpiresAt
+		}
+
+		token, err := signed.Encode(&linkToken{JobID: j.ID, ExpiresAt: expiresAt.Unix()}, s.config.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("export.Service: failed to sign link of job \"%s\" (%s)", j.ID, err)
+		}
+		j.DownloadURL = fmt.Sprintf("%s/exports/%s/archive?token=%s", s.config.BaseURL, j.ID, url.QueryEscape(token))
+	}
+
+	return j, nil
+}
+
+// Open opens the archive of the export job with the given ID, given the token
+// of its download link. The caller must close it.
+func (s *Service) Open(ID entity.ID, token string) (*Job, io.ReadCloser, error) {
+	var t linkToken
+	err := signed.Decode(token, s.config.Secret, &t)
+	if err != nil {
+		return nil, nil, InvalidLinkError{invalidLink.New(fmt.Sprintf("export.Service: %s", err))}
+	}
+
+	if t.JobID != ID {
+		return nil, nil, InvalidLinkError{invalidLink.New(fmt.Sprintf("export.Service: token was issued for job \"%s\", not \"%s\"", t.JobID, ID))}
+	}
+
+	if signed.Expired(t.ExpiresAt, s.now()) {
+		return nil, nil, ExpiredLinkError{expiredLink.New(fmt.Sprintf("export.Service: link of job \"%s\" expired", ID))}
+	}
+
+	j, err := s.findJob(ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if j.Status != JobStatusReady {
+		return nil, nil, NotFoundError{notFound.New(fmt.Sprintf("export.Service: archive of job \"%s\" is %s", ID, j.Status))}
+	}
+
+	r, err := s.store.Get(j.archiveKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return j, r, nil
+}
+
+// Sweep removes the expired jobs along with their archive, whether they were
+// looked up after they expired or not. The archive of a job is removed first,
+// so that a job whose archive could not be removed is swept again. It should
+// be run every SweepInterval.
+func (s *Service) Sweep() error {
+	jobs, err := s.repo.FindExpired(s.now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, j := range jobs {
+		err := s.store.Delete(j.archiveKey())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		err = s.repo.Delete(j.ID)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findJob retrieves the export job with the given ID, as long as it did not
+// expire. The archive of an expired job is removed from the store.
+func (s *Service) findJob(ID entity.ID) (*Job, error) {
+	j, err := s.repo.FindByID(ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundError{notFound.New(err.Error())}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if j.expired(s.now()) {
+		if j.Status == JobStatusReady {
+			_ = s.store.Delete(j.archiveKey())
+		}
+
+		return nil, NotFoundError{notFound.New(fmt.Sprintf("export.Service: job \"%s\" expired", ID))}
+	}
+
+	return j, nil
+}
+
+// checkCaller makes sure that the caller is allowed to export the data of the
+// user with the given ID, and returns the user. Users can export their data
+// even if their account is deactivated or suspended.
+func (s *Service) checkCaller(userID entity.ID, caller *user.Caller) (*entity.User, error) {
+	if caller == nil {
+		return nil, fmt.Errorf("export.Service: caller is nil")
+	}
+
+	u, err := s.uService.FindByID(userID, user.AccountStatuses...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("export.Service: cannot export the data of another user \"%s\"", userID))}
+	}
+
+	return u, nil
+}
+
+// collect reads the data of the user from the repositories.
+func (s *Service) collect(u *entity.User, now time.Time) (*Archive, error) {
+	a := &Archive{User: u, CreatedAt: now, store: s.store}
+
+	vq := &vehicule.Query{Limit: vehicule.MaxLimit}
+	for {
+		p, err := s.vRepo.FindByUserID(u.ID, vq)
+		if err != nil {
+			return nil, err
+		}
+		a.Vehicules = append(a.Vehicules, p.Vehicules...)
+
+		if p.Next == "" {
+			break
+		}
+		vq.After = p.Next
+	}
+
+	rq := &rating.Query{Limit: rating.MaxLimit}
+	for {
+		p, err := s.rRepo.FindBySubjectID(u.ID, rq)
+		if err != nil {
+			return nil, err
+		}
+		a.Reviews = append(a.Reviews, p.Reviews...)
+
+		if p.Next == "" {
+			break
+		}
+		rq.After = p.Next
+	}
+
+	rq = &rating.Query{Limit: rating.MaxLimit}
+	for {
+		p, err := s.rRepo.FindByAuthorID(u.ID, rq)
+		if err != nil {
+			return nil, err
+		}
+		a.WrittenReviews = append(a.WrittenReviews, p.Reviews...)
+
+		if p.Next == "" {
+			break
+		}
+		rq.After = p.Next
+	}
+
+	documents, err := s.dRepo.FindByUserID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range documents {
+		d.Expire(now)
+	}
+	a.Documents = documents
+
+	if s.config.MediaBaseURL != "" {
+		photos := []string{u.Photo}
+		for _, v := range a.Vehicules {
+			photos = append(photos, v.Photo)
+		}
+
+		for _, photo := range photos {
+			if key, ok := photoKey(photo, s.config.MediaBaseURL); ok {
+				a.photoKeys = append(a.photoKeys, key)
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// A countingReader is a reader that counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
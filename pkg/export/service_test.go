@@ -0,0 +1,460 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/rating"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+	"azure.com/ecovo/user-service/pkg/verification"
+)
+
+const testMediaBaseURL = "https://ecovo.ca/media"
+
+type testServices struct {
+	s     *Service
+	uRepo user.Repository
+	vRepo vehicule.Repository
+	rRepo rating.Repository
+	dRepo verification.Repository
+	store *media.FileStore
+	now   time.Time
+}
+
+func newTestServices(t *testing.T) *testServices {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store, err := media.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &testServices{
+		uRepo: user.NewMemoryRepository(),
+		vRepo: vehicule.NewMemoryRepository(),
+		rRepo: rating.NewMemoryRepository(),
+		dRepo: verification.NewMemoryRepository(),
+		store: store,
+		now:   time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	ts.s, err = NewService(NewMemoryRepository(), user.NewService(ts.uRepo), ts.vRepo, ts.rRepo, ts.dRepo, store, &Config{
+		Secret:       []byte("secret"),
+		BaseURL:      "https://users.ecovo.ca/",
+		MediaBaseURL: testMediaBaseURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.s.now = func() time.Time { return ts.now }
+	ts.s.run = func(f func()) { f() }
+
+	return ts
+}
+
+// register creates a user whose photo was uploaded to the store.
+func (ts *testServices) register(t *testing.T, subID string) *entity.User {
+	u := &entity.User{
+		SubID:         subID,
+		IdentityEmail: subID + "@identity.ca",
+		FirstName:     "Harold",
+		LastName:      "The Great",
+		DateOfBirth:   time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+		Gender:        entity.GenderMale,
+		Preferences:   &entity.Preferences{Smoking: entity.PreferenceNever, Others: map[string]int{"pets": 1}},
+	}
+
+	var err error
+	u.ID, err = ts.uRepo.Create(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u.Photo = ts.putPhoto(t, fmt.Sprintf("photos/users/%s/1/large.jpg", u.ID))
+	err = ts.uRepo.Update(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+// putPhoto stores a photo under the given key and returns its URL.
+func (ts *testServices) putPhoto(t *testing.T, key string) string {
+	err := ts.store.Put(key, "image/jpeg", strings.NewReader("photo of "+key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testMediaBaseURL + "/" + key
+}
+
+// readArchive returns the contents of the files of a zip archive, by name.
+func readArchive(t *testing.T, content []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+
+	return files
+}
+
+func TestServiceExport(t *testing.T) {
+	t.Run("Should export the data of the user with its local photos", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		maurice := ts.register(t, "maurice|1")
+
+		for i := 0; i < vehicule.MaxLimit+1; i++ {
+			v := &entity.Vehicule{UserID: harold.ID, Year: 2015, Make: "Audi", Model: "A4", Color: "red", Seats: 4}
+			if i == 0 {
+				v.Photo = ts.putPhoto(t, fmt.Sprintf("photos/vehicules/%s/1/large.jpg", harold.ID))
+			} else if i == 1 {
+				v.Photo = "https://cdn.example.com/car.jpg"
+			}
+
+			_, err := ts.vRepo.Create(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := ts.rRepo.Create(&entity.Review{AuthorID: maurice.ID, SubjectID: harold.ID, TripID: "1", Role: entity.ReviewRoleDriver, Stars: 5, Comment: "Great ride", CreatedAt: ts.now})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.rRepo.Create(&entity.Review{AuthorID: harold.ID, SubjectID: maurice.ID, TripID: "1", Role: entity.ReviewRoleRider, Stars: 1, CreatedAt: ts.now})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.dRepo.Create(&entity.Document{UserID: harold.ID, Type: entity.DocumentTypeDriversLicense, Status: entity.DocumentStatusRejected, RejectionReason: "Blurry", FileKey: "documents/license"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := ts.s.Export(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		err = a.Write(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := readArchive(t, buf.Bytes())
+
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		expected := []string{
+			"audit.json",
+			"photos/users/" + string(harold.ID) + "/1/large.jpg",
+			"photos/vehicules/" + string(harold.ID) + "/1/large.jpg",
+			"preferences.json",
+			"profile.json",
+			"ratings.json",
+			"vehicules.json",
+		}
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected files %v, got %v", expected, names)
+		}
+
+		var p map[string]interface{}
+		err = json.Unmarshal([]byte(files["profile.json"]), &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p["subId"] != harold.SubID || p["identityEmail"] != harold.IdentityEmail || p["firstName"] != harold.FirstName {
+			t.Errorf("expected profile with subscription ID, identity email and first name, got %v", p)
+		}
+
+		var vehicules []*entity.Vehicule
+		err = json.Unmarshal([]byte(files["vehicules.json"]), &vehicules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vehicules) != vehicule.MaxLimit+1 {
+			t.Errorf("expected %d vehicules, got %d", vehicule.MaxLimit+1, len(vehicules))
+		}
+
+		var r ratings
+		err = json.Unmarshal([]byte(files["ratings.json"]), &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Reviews) != 1 || r.Reviews[0].Comment != "Great ride" {
+			t.Errorf("expected the review the user received, got %v", r.Reviews)
+		}
+
+		if len(r.WrittenReviews) != 1 || r.WrittenReviews[0].SubjectID != maurice.ID {
+			t.Errorf("expected the review the user wrote, got %v", r.WrittenReviews)
+		}
+
+		if !strings.Contains(files["audit.json"], "Blurry") || strings.Contains(files["audit.json"], "documents/license") {
+			t.Errorf("expected the document's review without its file key, got %s", files["audit.json"])
+		}
+
+		if !strings.Contains(files["preferences.json"], `"pets": 1`) {
+			t.Errorf("expected every preference, got %s", files["preferences.json"])
+		}
+	})
+
+	t.Run("Should leave out photos that are no longer stored", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+
+		err := ts.store.Delete(strings.TrimPrefix(harold.Photo, testMediaBaseURL+"/"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, err := ts.s.Export(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		err = a.Write(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if files := readArchive(t, buf.Bytes()); len(files) != 5 {
+			t.Errorf("expected only the JSON documents, got %d files", len(files))
+		}
+	})
+
+	t.Run("Should let privileged callers export any user", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+
+		_, err := ts.s.Export(harold.ID, &user.Caller{SubID: "admin|1", Privileged: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when exporting another user", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+
+		_, err := ts.s.Export(harold.ID, &user.Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+
+		_, err = ts.s.Start(harold.ID, &user.Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+}
+
+// startJob starts an export job for the user and returns it once it is ready,
+// along with the token of its download link.
+func (ts *testServices) startJob(t *testing.T, u *entity.User) (*Job, string) {
+	caller := &user.Caller{SubID: u.SubID}
+	started, err := ts.s.Start(u.ID, caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if started.Status != JobStatusPending {
+		t.Errorf("expected job to be started as %s, got %s", JobStatusPending, started.Status)
+	}
+
+	j, err := ts.s.FindJob(started.ID, u.ID, caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if j.Status != JobStatusReady {
+		t.Fatalf("expected job to be %s, got %s", JobStatusReady, j.Status)
+	}
+
+	link, err := url.Parse(j.DownloadURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPath := fmt.Sprintf("/exports/%s/archive", j.ID)
+	if link.Host != "users.ecovo.ca" || link.Path != expectedPath {
+		t.Errorf("expected link to %s, got %s", expectedPath, j.DownloadURL)
+	}
+
+	return j, link.Query().Get("token")
+}
+
+func TestServiceJobs(t *testing.T) {
+	t.Run("Should download the archive of a job through its link", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+
+		j, token := ts.startJob(t, harold)
+
+		opened, r, err := ts.s.Open(j.ID, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if int64(len(content)) != opened.Size {
+			t.Errorf("expected archive of %d bytes, got %d", opened.Size, len(content))
+		}
+
+		if files := readArchive(t, content); files["profile.json"] == "" {
+			t.Error("expected archive to contain the profile")
+		}
+	})
+
+	t.Run("Should fail with invalid link error when the token is tampered with", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		j, token := ts.startJob(t, harold)
+		other, _ := ts.startJob(t, harold)
+
+		for _, tc := range []struct {
+			name  string
+			ID    entity.ID
+			token string
+		}{
+			{"malformed", j.ID, "harold"},
+			{"signature", j.ID, token + "a"},
+			{"other job", other.ID, token},
+		} {
+			_, _, err := ts.s.Open(tc.ID, tc.token)
+			if _, ok := err.(InvalidLinkError); !ok {
+				t.Errorf("%s: expected InvalidLinkError, got %v", tc.name, err)
+			}
+		}
+	})
+
+	t.Run("Should fail with expired link error until a new link is generated", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		j, token := ts.startJob(t, harold)
+
+		ts.now = ts.now.Add(DefaultLinkTTL)
+		_, _, err := ts.s.Open(j.ID, token)
+		if _, ok := err.(ExpiredLinkError); !ok {
+			t.Errorf("expected ExpiredLinkError, got %v", err)
+		}
+
+		j, err = ts.s.FindJob(j.ID, harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		link, err := url.Parse(j.DownloadURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, r, err := ts.s.Open(j.ID, link.Query().Get("token"))
+		if err != nil {
+			t.Fatalf("expected a new link to be valid, got %v", err)
+		}
+		r.Close()
+	})
+
+	t.Run("Should fail with not found error and remove the archive when the job expired", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		j, _ := ts.startJob(t, harold)
+
+		ts.now = ts.now.Add(DefaultRetention)
+		_, err := ts.s.FindJob(j.ID, harold.ID, &user.Caller{SubID: harold.SubID})
+		if _, ok := err.(NotFoundError); !ok {
+			t.Errorf("expected NotFoundError, got %v", err)
+		}
+
+		_, err = ts.store.Get(j.archiveKey())
+		if _, ok := err.(media.NotFoundError); !ok {
+			t.Errorf("expected archive to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Should fail with not found error when the job is of another user", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		maurice := ts.register(t, "maurice|1")
+		j, _ := ts.startJob(t, harold)
+
+		_, err := ts.s.FindJob(j.ID, maurice.ID, &user.Caller{SubID: maurice.SubID})
+		if _, ok := err.(NotFoundError); !ok {
+			t.Errorf("expected NotFoundError, got %v", err)
+		}
+	})
+
+	t.Run("Should remove expired jobs and their archive when sweeping", func(t *testing.T) {
+		ts := newTestServices(t)
+		harold := ts.register(t, "harold|1")
+		expired, _ := ts.startJob(t, harold)
+
+		ts.now = ts.now.Add(time.Hour)
+		current, _ := ts.startJob(t, harold)
+
+		ts.now = expired.ExpiresAt
+		err := ts.s.Sweep()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.store.Get(expired.archiveKey())
+		if _, ok := err.(media.NotFoundError); !ok {
+			t.Errorf("expected archive of expired job to be removed, got %v", err)
+		}
+
+		if _, err := ts.s.repo.FindByID(expired.ID); err == nil {
+			t.Error("expected expired job to be removed")
+		}
+
+		r, err := ts.store.Get(current.archiveKey())
+		if err != nil {
+			t.Fatalf("expected archive of current job to be kept, got %v", err)
+		}
+		r.Close()
+	})
+}
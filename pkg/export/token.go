@@ -0,0 +1,11 @@
+package export
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A linkToken is the token of a download link. It is signed, so that the
+// archive can be downloaded without being authenticated, which lets users
+// open the link in a browser.
+type linkToken struct {
+	JobID     entity.ID `json:"jid"`
+	ExpiresAt int64     `json:"exp"`
+}
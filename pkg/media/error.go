@@ -0,0 +1,15 @@
+package media
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A NotFoundError is an error that represents that no file is stored with the
+// given key.
+type NotFoundError struct {
+	entity.BaseError
+}
+
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "fileNotFound",
+	Detail: "file does not exist",
+}
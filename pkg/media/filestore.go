@@ -0,0 +1,111 @@
+package media
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// A FileStore is a store that keeps files in a directory of the local
+// filesystem. The content type of the files is not kept, since it is expected
+// to be known by whoever refers to the files.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store that keeps files in the given directory, which
+// is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("media.FileStore: directory is missing")
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("media.FileStore: failed to create directory (%s)", err)
+	}
+
+	return &FileStore{dir}, nil
+}
+
+// Put stores the file read from r with the given key, replacing the file that
+// may already be stored with the same key. The file is written to a temporary
+// file first, so that a partially written file is never visible.
+func (s *FileStore) Put(key string, contentType string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return fmt.Errorf("media.FileStore: failed to create directory of \"%s\" (%s)", key, err)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(p), ".upload-")
+	if err != nil {
+		return fmt.Errorf("media.FileStore: failed to create file \"%s\" (%s)", key, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("media.FileStore: failed to write file \"%s\" (%s)", key, err)
+	}
+
+	err = os.Rename(f.Name(), p)
+	if err != nil {
+		return fmt.Errorf("media.FileStore: failed to write file \"%s\" (%s)", key, err)
+	}
+
+	return nil
+}
+
+// Get opens the file stored with the given key, if it exists. The caller must
+// close it.
+func (s *FileStore) Get(key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, NotFoundError{notFound.New(fmt.Sprintf("media.FileStore: no file found with key \"%s\"", key))}
+	} else if err != nil {
+		return nil, fmt.Errorf("media.FileStore: failed to open file \"%s\" (%s)", key, err)
+	}
+
+	return f, nil
+}
+
+// Delete removes the file stored with the given key. Deleting a file that
+// does not exist is not an error.
+func (s *FileStore) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("media.FileStore: failed to delete file \"%s\" (%s)", key, err)
+	}
+
+	return nil
+}
+
+// path returns the path of the file stored with the given key.
+func (s *FileStore) path(key string) (string, error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T) *FileStore {
+	dir, err := ioutil.TempDir("", "media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestFileStore(t *testing.T) {
+	s := newTestFileStore(t)
+	key := "documents/5c8f9ddfdc5bda1a3c2a2f1b/license"
+
+	err := s.Put(key, "application/pdf", strings.NewReader("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Put(key, "application/pdf", strings.NewReader("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := s.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "second" {
+		t.Errorf("expected file to be replaced, got %q", content)
+	}
+
+	err = s.Delete(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Get(key)
+	if _, ok := err.(NotFoundError); !ok {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+
+	err = s.Delete(key)
+	if err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+}
+
+func TestFileStoreRejectsKeysOutsideOfDirectory(t *testing.T) {
+	s := newTestFileStore(t)
+
+	keys := []string{"", "/etc/passwd", "../secret", "documents/../../secret", "documents//license", ".."}
+	for _, key := range keys {
+		if err := s.Put(key, "text/plain", strings.NewReader("pain")); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config contains the information required to store files in a bucket of
+// an S3-compatible object storage service, such as Amazon S3 or MinIO.
+type S3Config struct {
+	// Endpoint specifies the URL of the service (ex.
+	// https://s3.ca-central-1.amazonaws.com or http://localhost:9000).
+	Endpoint string
+
+	// Region specifies the region in which the bucket is hosted.
+	//
+	// An empty region means DefaultS3Region.
+	Region string
+
+	// Bucket specifies the name of the bucket in which files are stored.
+	Bucket string
+
+	// AccessKeyID and SecretAccessKey specify the credentials used to sign
+	// the requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// VirtualHostedStyle specifies whether the bucket is addressed as a
+	// subdomain of the endpoint (ex. https://bucket.s3.amazonaws.com/key)
+	// instead of as the first segment of the path (ex.
+	// http://localhost:9000/bucket/key), which most local stand-ins expect.
+	VirtualHostedStyle bool
+
+	// Client specifies the HTTP client used to send the requests.
+	//
+	// Nil means a client with DefaultS3Timeout.
+	Client *http.Client
+}
+
+const (
+	// DefaultS3Region represents the region used when none is specified.
+	DefaultS3Region = "us-east-1"
+
+	// DefaultS3Timeout represents the default amount of time to wait for
+	// the service to respond.
+	DefaultS3Timeout = 30 * time.Second
+)
+
+// validate looks at the configuration's contents to ensure it has all the
+// required fields.
+func (conf *S3Config) validate() error {
+	if conf.Endpoint == "" {
+		return errors.New("missing endpoint")
+	}
+
+	if u, err := url.Parse(conf.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("malformed endpoint \"%s\"", conf.Endpoint)
+	}
+
+	if conf.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	if conf.AccessKeyID == "" || conf.SecretAccessKey == "" {
+		return errors.New("missing credentials")
+	}
+
+	return nil
+}
+
+// An S3Store is a store that keeps files as objects in a bucket of an
+// S3-compatible object storage service. Requests are signed with AWS
+// Signature Version 4.
+type S3Store struct {
+	endpoint           *url.URL
+	bucket             string
+	virtualHostedStyle bool
+	credentials        credentials
+	client             *http.Client
+	now                func() time.Time
+}
+
+// NewS3Store creates a store that keeps files in the bucket specified in the
+// given configuration.
+func NewS3Store(config *S3Config) (*S3Store, error) {
+	if config == nil {
+		return nil, errors.New("media.S3Store: missing configuration")
+	}
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("media.S3Store: configuration %s", err)
+	}
+
+	endpoint, _ := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+
+	region := config.Region
+	if region == "" {
+		region = DefaultS3Region
+	}
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultS3Timeout}
+	}
+
+	return &S3Store{
+		endpoint:           endpoint,
+		bucket:             config.Bucket,
+		virtualHostedStyle: config.VirtualHostedStyle,
+		credentials:        credentials{config.AccessKeyID, config.SecretAccessKey, region, "s3"},
+		client:             client,
+		now:                time.Now,
+	}, nil
+}
+
+// Put stores the file read from r as an object with the given key, replacing
+// the object that may already exist. The file is read in memory to compute
+// the hash of the payload that is signed.
+func (s *S3Store) Put(key string, contentType string, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("media.S3Store: failed to read file \"%s\" (%s)", key, err)
+	}
+
+	req, err := s.newRequest(http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.unexpectedResponse(key, resp)
+	}
+
+	return nil
+}
+
+// Get retrieves the object with the given key, if it exists. The caller must
+// close it.
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+
+		return nil, NotFoundError{notFound.New(fmt.Sprintf("media.S3Store: no file found with key \"%s\"", key))}
+	default:
+		defer resp.Body.Close()
+
+		return nil, s.unexpectedResponse(key, resp)
+	}
+}
+
+// Delete removes the object with the given key. Deleting an object that does
+// not exist is not an error.
+func (s *S3Store) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.unexpectedResponse(key, resp)
+	}
+
+	return nil
+}
+
+// newRequest creates a request on the object with the given key.
+func (s *S3Store) newRequest(method string, key string, content []byte) (*http.Request, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *s.endpoint
+	if s.virtualHostedStyle {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+	} else {
+		u.Path = u.Path + "/" + s.bucket + "/" + key
+	}
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("media.S3Store: failed to create request for \"%s\" (%s)", key, err)
+	}
+
+	return req, nil
+}
+
+// do signs and sends a request.
+func (s *S3Store) do(req *http.Request, content []byte) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if len(content) > 0 {
+		payloadHash = hashHex(content)
+	}
+	s.credentials.sign(req, payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("media.S3Store: failed to send request (%s)", err)
+	}
+
+	return resp, nil
+}
+
+// unexpectedResponse creates an error from a response whose status is not
+// the expected one. The body of S3 errors is an XML document that describes
+// them, which is kept as is.
+func (s *S3Store) unexpectedResponse(key string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	return fmt.Errorf("media.S3Store: %s \"%s\" failed with status %d (%s)", resp.Request.Method, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,164 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A credentials contains what is needed to sign requests with AWS Signature
+// Version 4.
+type credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+	service         string
+}
+
+const (
+	amzDateFormat       = "20060102T150405Z"
+	amzDateStampFormat  = "20060102"
+	sigV4Algorithm      = "AWS4-HMAC-SHA256"
+	emptyPayloadHash    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	contentSHA256Header = "X-Amz-Content-Sha256"
+	amzDateHeader       = "X-Amz-Date"
+)
+
+// sign signs a request whose payload has the given SHA-256 hash, by setting
+// its X-Amz-Date, X-Amz-Content-Sha256 and Authorization headers.
+func (c *credentials) sign(req *http.Request, payloadHash string, now time.Time) {
+	req.Header.Set(amzDateHeader, now.UTC().Format(amzDateFormat))
+	req.Header.Set(contentSHA256Header, payloadHash)
+	req.Header.Set("Authorization", c.authorization(req, payloadHash, now))
+}
+
+// authorization computes the Authorization header of a request signed at the
+// given time. The host, the content type, the range and the x-amz-* headers
+// are signed.
+func (c *credentials) authorization(req *http.Request, payloadHash string, now time.Time) string {
+	now = now.UTC()
+	scope := strings.Join([]string{now.Format(amzDateStampFormat), c.region, c.service, "aws4_request"}, "/")
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(amzDateFormat),
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretAccessKey), now.Format(amzDateStampFormat))
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, c.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, c.accessKeyID, scope, signedHeaders, signature)
+}
+
+// canonicalHeaders returns the canonical headers of a request and the list of
+// their names.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			values[name] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery returns the query parameters of a request sorted by name and
+// encoded the way AWS expects them.
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, escape(name, false)+"="+escape(v, false))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// escapePath URI-encodes every segment of a path.
+func escapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	return escape(path, true)
+}
+
+// escape URI-encodes every byte of s except the unreserved characters, and
+// slashes when keepSlash is true.
+func escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,28 @@
+// Package media stores the files uploaded by users, such as the documents
+// proving that they are allowed to drive, in a pluggable blob store.
+package media
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// A Store is a blob store in which files are identified by a key made of
+// slash-separated segments (ex. documents/5c8f9ddfdc5bda1a3c2a2f1b/license).
+type Store interface {
+	Put(key string, contentType string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// validateKey makes sure that a key cannot refer to a file outside of the
+// store.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("media: invalid key \"%s\"", key)
+	}
+
+	return nil
+}
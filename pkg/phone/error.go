@@ -0,0 +1,87 @@
+package phone
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A NotFoundError is an error that represents that the user has no pending
+// verification for its current phone number.
+type NotFoundError struct {
+	entity.BaseError
+}
+
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "phoneVerificationNotFound",
+	Detail: "no code was sent to the current phone number",
+}
+
+// A MissingPhoneNumberError is an error that represents that the user has no
+// phone number to verify.
+type MissingPhoneNumberError struct {
+	entity.BaseError
+}
+
+var missingPhoneNumber = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "phoneNumberMissing",
+	Field: "phoneNumber",
+}
+
+// An AlreadyVerifiedError is an error that represents that the user's phone
+// number is already verified.
+type AlreadyVerifiedError struct {
+	entity.BaseError
+}
+
+var alreadyVerified = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "phoneNumberAlreadyVerified",
+	Detail: "phone number is already verified",
+}
+
+// A TooSoonError is an error that represents that a code was sent to the user
+// too recently to send another one.
+type TooSoonError struct {
+	entity.BaseError
+}
+
+var tooSoon = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindTooManyRequests,
+	Code:   "verificationCodeRecentlySent",
+	Detail: "a code was sent recently, wait before sending another one",
+}
+
+// An ExpiredCodeError is an error that represents that the code of the
+// verification expired.
+type ExpiredCodeError struct {
+	entity.BaseError
+}
+
+var expiredCode = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "verificationCodeExpired",
+	Field: "code",
+}
+
+// An IncorrectCodeError is an error that represents that the code does not
+// match the one that was sent.
+type IncorrectCodeError struct {
+	entity.BaseError
+}
+
+var incorrectCode = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "verificationCodeIncorrect",
+	Field: "code",
+}
+
+// A TooManyAttemptsError is an error that represents that the user tried too
+// many incorrect codes, so that a new code must be sent.
+type TooManyAttemptsError struct {
+	entity.BaseError
+}
+
+var tooManyAttempts = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindTooManyRequests,
+	Code:   "verificationAttemptsExceeded",
+	Detail: "too many incorrect codes, a new code must be sent",
+}
@@ -0,0 +1,80 @@
+package phone
+
+import (
+	"fmt"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on phone
+// number verifications kept in memory. It is safe for concurrent use and is
+// meant to be used in tests and when running the service locally without a
+// database.
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	verifications map[entity.ID]*Verification
+}
+
+// NewMemoryRepository creates an empty in-memory verification repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{verifications: make(map[entity.ID]*Verification)}
+}
+
+// FindByUserID retrieves the pending verification of the user with the given
+// ID, if there is one.
+func (r *MemoryRepository) FindByUserID(userID entity.ID) (*Verification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.verifications[userID]
+	if !ok {
+		return nil, fmt.Errorf("phone.MemoryRepository: no verification found for user \"%s\"", userID)
+	}
+
+	c := *v
+	return &c, nil
+}
+
+// Save stores the verification in memory, replacing the pending verification
+// of the same user, if any.
+func (r *MemoryRepository) Save(v *Verification) error {
+	if v == nil {
+		return fmt.Errorf("phone.MemoryRepository: failed to save verification (verification is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *v
+	r.verifications[v.UserID] = &c
+
+	return nil
+}
+
+// IncrementAttempts counts an attempt on the pending verification of the user
+// with the given ID, as long as fewer than maxAttempts were made.
+func (r *MemoryRepository) IncrementAttempts(userID entity.ID, maxAttempts int) (*Verification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.verifications[userID]
+	if !ok || v.Attempts >= maxAttempts {
+		return nil, errNoAttemptLeft
+	}
+	v.Attempts++
+
+	c := *v
+	return &c, nil
+}
+
+// Delete removes the pending verification of the user with the given ID from
+// memory.
+func (r *MemoryRepository) Delete(userID entity.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.verifications, userID)
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package phone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on phone
+// number verifications in a MongoDB collection. The verifications are
+// identified by the unique identifier of their user.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	UserID      primitive.ObjectID `bson:"_id"`
+	PhoneNumber string             `bson:"phoneNumber"`
+	CodeHash    string             `bson:"codeHash"`
+	Attempts    int                `bson:"attempts"`
+	SentAt      time.Time          `bson:"sentAt"`
+	ExpiresAt   time.Time          `bson:"expiresAt"`
+}
+
+func newDocumentFromEntity(v *Verification) (*document, error) {
+	if v == nil {
+		return nil, fmt.Errorf("phone.MongoRepository: entity is nil")
+	}
+
+	userID, err := primitive.ObjectIDFromHex(v.UserID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: failed to create user object ID")
+	}
+
+	return &document{
+		userID,
+		v.PhoneNumber,
+		v.CodeHash,
+		v.Attempts,
+		v.SentAt,
+		v.ExpiresAt,
+	}, nil
+}
+
+func (d document) Entity() *Verification {
+	return &Verification{
+		UserID:      entity.NewIDFromHex(d.UserID.Hex()),
+		PhoneNumber: d.PhoneNumber,
+		CodeHash:    d.CodeHash,
+		Attempts:    d.Attempts,
+		SentAt:      d.SentAt,
+		ExpiresAt:   d.ExpiresAt,
+	}
+}
+
+// verificationRetention represents how long expired verifications are kept
+// before MongoDB removes them.
+const verificationRetention = 24 * time.Hour
+
+// NewMongoRepository creates a verification repository for a MongoDB
+// collection and makes sure expired verifications are eventually removed.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("phone.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(int32(verificationRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindByUserID retrieves the pending verification of the user with the given
+// ID, if there is one.
+func (r *MongoRepository) FindByUserID(userID entity.ID) (*Verification, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	var d document
+	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
+	if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: no verification found for user \"%s\" (%s)", userID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// Save stores the verification in the collection, replacing the pending
+// verification of the same user, if any.
+func (r *MongoRepository) Save(v *Verification) error {
+	d, err := newDocumentFromEntity(v)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "_id", Value: d.UserID}}
+	_, err = r.collection.ReplaceOne(context.TODO(), filter, d, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("phone.MongoRepository: failed to save verification of user \"%s\" (%s)", v.UserID, err)
+	}
+
+	return nil
+}
+
+// IncrementAttempts counts an attempt on the pending verification of the user
+// with the given ID, as long as fewer than maxAttempts were made. The attempts
+// are incremented and checked in a single update, so that concurrent attempts
+// cannot exceed the maximum.
+func (r *MongoRepository) IncrementAttempts(userID entity.ID, maxAttempts int) (*Verification, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: objectID},
+		{Key: "attempts", Value: bson.D{{Key: "$lt", Value: maxAttempts}}},
+	}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}}}
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d document
+	err = r.collection.FindOneAndUpdate(context.TODO(), filter, update, findOptions).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, errNoAttemptLeft
+	} else if err != nil {
+		return nil, fmt.Errorf("phone.MongoRepository: failed to count attempt of user \"%s\" (%s)", userID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// Delete removes the pending verification of the user with the given ID from
+// the collection.
+func (r *MongoRepository) Delete(userID entity.ID) error {
+	objectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return fmt.Errorf("phone.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	_, err = r.collection.DeleteOne(context.TODO(), filter)
+	if err != nil {
+		return fmt.Errorf("phone.MongoRepository: failed to delete verification of user \"%s\" (%s)", userID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package phone
+
+import (
+	"errors"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on phone number verifications in a database. A user has at most
+// one pending verification.
+//
+// IncrementAttempts atomically counts an attempt on the pending verification
+// of a user, as long as fewer than the maximum number of attempts were made,
+// and returns the verification as modified. It returns errNoAttemptLeft when
+// the maximum is reached or there is no pending verification.
+type Repository interface {
+	FindByUserID(userID entity.ID) (*Verification, error)
+	Save(v *Verification) error
+	IncrementAttempts(userID entity.ID, maxAttempts int) (*Verification, error)
+	Delete(userID entity.ID) error
+}
+
+// errNoAttemptLeft is returned by the repositories when an attempt cannot be
+// counted on a verification.
+var errNoAttemptLeft = errors.New("phone: no attempt left")
@@ -0,0 +1,31 @@
+package phone
+
+import (
+	"io"
+	"log"
+)
+
+// An SMSSender sends text messages to phone numbers in the E.164 format.
+type SMSSender interface {
+	Send(phoneNumber string, message string) error
+}
+
+// A LogSender is an SMS sender that writes the text messages to a log instead
+// of sending them. It is meant to be used in tests and when running the
+// service locally, where the log can be written to a file.
+type LogSender struct {
+	logger *log.Logger
+}
+
+// NewLogSender creates an SMS sender that writes the text messages to the
+// given writer.
+func NewLogSender(w io.Writer) *LogSender {
+	return &LogSender{log.New(w, "sms: ", log.LstdFlags)}
+}
+
+// Send writes the text message to the log.
+func (s *LogSender) Send(phoneNumber string, message string) error {
+	s.logger.Printf("to=%s message=%q", phoneNumber, message)
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package phone
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves verifying phone numbers.
+type UseCase interface {
+	Start(subID string) (*Verification, error)
+	Confirm(subID string, code string) error
+}
+
+// Config contains the rules of the phone number verifications.
+type Config struct {
+	// CodeLength specifies the number of digits of the codes.
+	//
+	// Zero means DefaultCodeLength.
+	CodeLength int
+
+	// TTL specifies how long a code can be used after it was sent.
+	//
+	// Zero means DefaultTTL.
+	TTL time.Duration
+
+	// MaxAttempts specifies how many incorrect codes can be tried before a new
+	// code must be sent.
+	//
+	// Zero means DefaultMaxAttempts.
+	MaxAttempts int
+
+	// ResendInterval specifies how long to wait before sending another code
+	// to the same user, whatever its phone number.
+	//
+	// Zero means DefaultResendInterval.
+	ResendInterval time.Duration
+}
+
+const (
+	// DefaultCodeLength represents the default number of digits of the codes.
+	DefaultCodeLength = 6
+
+	// DefaultTTL represents the default amount of time during which a code can
+	// be used.
+	DefaultTTL = 10 * time.Minute
+
+	// DefaultMaxAttempts represents the default number of incorrect codes that
+	// can be tried.
+	DefaultMaxAttempts = 5
+
+	// DefaultResendInterval represents the default amount of time to wait
+	// before sending another code.
+	DefaultResendInterval = time.Minute
+)
+
+// A Service handles the business logic related to verifying phone numbers.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+	sender   SMSSender
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates a phone number verification service that keeps the
+// pending verifications in a repository and sends the codes with an SMS
+// sender. A nil configuration means the default one.
+func NewService(repo Repository, uService user.UseCase, sender SMSSender, config *Config) *Service {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+
+	if c.CodeLength == 0 {
+		c.CodeLength = DefaultCodeLength
+	}
+
+	if c.TTL == 0 {
+		c.TTL = DefaultTTL
+	}
+
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if c.ResendInterval == 0 {
+		c.ResendInterval = DefaultResendInterval
+	}
+
+	return &Service{repo, uService, sender, c, time.Now}
+}
+
+// Start sends a new code to the phone number of the user with the given
+// subscription ID, replacing the code that was previously sent, if any. Codes
+// are sent at most once per resend interval to a user, even when it modifies
+// its phone number in between.
+func (s *Service) Start(subID string) (*Verification, error) {
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.PhoneNumber == "" {
+		return nil, MissingPhoneNumberError{missingPhoneNumber.New("phone.Service: user has no phone number to verify")}
+	}
+
+	if u.PhoneVerified {
+		return nil, AlreadyVerifiedError{alreadyVerified.New(fmt.Sprintf("phone.Service: phone number of user \"%s\" is already verified", u.ID))}
+	}
+
+	now := s.now()
+	previous, err := s.repo.FindByUserID(u.ID)
+	if err == nil && now.Before(previous.SentAt.Add(s.config.ResendInterval)) {
+		return nil, TooSoonError{tooSoon.New(fmt.Sprintf("phone.Service: a code was sent to user \"%s\" less than %s ago", u.ID, s.config.ResendInterval))}
+	}
+
+	code, err := newCode(s.config.CodeLength)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Verification{
+		UserID:      u.ID,
+		PhoneNumber: u.PhoneNumber,
+		CodeHash:    hashCode(u.ID, code),
+		SentAt:      now,
+		ExpiresAt:   now.Add(s.config.TTL),
+	}
+
+	err = s.repo.Save(v)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Your Ecovo verification code is %s. It expires in %d minutes.", code, int(s.config.TTL.Minutes()))
+	err = s.sender.Send(u.PhoneNumber, message)
+	if err != nil {
+		_ = s.repo.Delete(u.ID)
+
+		return nil, fmt.Errorf("phone.Service: failed to send code to user \"%s\" (%s)", u.ID, err)
+	}
+
+	return v, nil
+}
+
+// Confirm checks the code entered by the user with the given subscription ID
+// against the one that was sent to its phone number and, if it matches, marks
+// the phone number as verified. Every code entered counts as an attempt
+// before it is checked, so that concurrent guesses cannot exceed the maximum
+// number of attempts. A verification whose code cannot be used anymore is
+// kept until it is replaced, for the resend interval to keep applying.
+func (s *Service) Confirm(subID string, code string) error {
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return err
+	}
+
+	v, err := s.repo.FindByUserID(u.ID)
+	if err != nil {
+		return NotFoundError{notFound.New(err.Error())}
+	}
+
+	if v.PhoneNumber != u.PhoneNumber {
+		return NotFoundError{notFound.New(fmt.Sprintf("phone.Service: phone number of user \"%s\" was modified since the code was sent", u.ID))}
+	}
+
+	if !s.now().Before(v.ExpiresAt) {
+		return ExpiredCodeError{expiredCode.New("code expired, a new code must be sent")}
+	}
+
+	v, err = s.repo.IncrementAttempts(u.ID, s.config.MaxAttempts)
+	if err == errNoAttemptLeft {
+		return TooManyAttemptsError{tooManyAttempts.New(fmt.Sprintf("phone.Service: user \"%s\" tried too many incorrect codes", u.ID))}
+	} else if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashCode(u.ID, code)), []byte(v.CodeHash)) != 1 {
+		return IncorrectCodeError{incorrectCode.New(fmt.Sprintf("code is incorrect, %d attempt(s) left", s.config.MaxAttempts-v.Attempts))}
+	}
+
+	err = s.uService.VerifyPhoneNumber(u.ID, v.PhoneNumber)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(u.ID)
+}
+
+// newCode generates a random code made of the given number of digits.
+func newCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("phone.Service: failed to generate code (%s)", err)
+		}
+
+		code[i] = byte('0' + digit.Int64())
+	}
+
+	return string(code), nil
+}
+
+// hashCode hashes a code along with the unique identifier of the user it was
+// sent to.
+func hashCode(userID entity.ID, code string) string {
+	sum := sha256.Sum256([]byte(userID.Hex() + ":" + code))
+
+	return hex.EncodeToString(sum[:])
+}
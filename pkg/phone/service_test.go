@@ -0,0 +1,271 @@
+package phone
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// A recordingSender is an SMS sender that keeps the last code it sent.
+type recordingSender struct {
+	phoneNumber string
+	code        string
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (s *recordingSender) Send(phoneNumber string, message string) error {
+	s.phoneNumber = phoneNumber
+	s.code = codePattern.FindString(message)
+
+	return nil
+}
+
+type testServices struct {
+	s        *Service
+	uService *user.Service
+	sender   *recordingSender
+	now      time.Time
+}
+
+func newTestServices(t *testing.T) (*testServices, *entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+	harold, err := uService.Register(&entity.User{
+		SubID:       "harold|1",
+		FirstName:   "Harold",
+		LastName:    "The Great",
+		DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+		PhoneNumber: "(450) 123-4567",
+		Gender:      entity.GenderMale,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &testServices{
+		uService: uService,
+		sender:   &recordingSender{},
+		now:      time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	ts.s = NewService(NewMemoryRepository(), uService, ts.sender, &Config{MaxAttempts: 2})
+	ts.s.now = func() time.Time { return ts.now }
+
+	return ts, harold
+}
+
+func TestServiceStart(t *testing.T) {
+	t.Run("Should send a code to the phone number in E.164 format", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		v, err := ts.s.Start(harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ts.sender.phoneNumber != "+14501234567" || ts.sender.code == "" {
+			t.Errorf("expected a code to be sent to %q, got %q to %q", "+14501234567", ts.sender.code, ts.sender.phoneNumber)
+		}
+
+		if !v.ExpiresAt.Equal(ts.now.Add(DefaultTTL)) {
+			t.Errorf("expected code to expire at %s, got %s", ts.now.Add(DefaultTTL), v.ExpiresAt)
+		}
+	})
+
+	t.Run("Should fail when user has no phone number", func(t *testing.T) {
+		ts, _ := newTestServices(t)
+		u, err := ts.uService.Register(&entity.User{
+			SubID:       "harold|2",
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.s.Start(u.SubID)
+		if _, ok := err.(MissingPhoneNumberError); !ok {
+			t.Errorf("expected MissingPhoneNumberError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when a code was sent too recently", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := ts.s.Start(harold.SubID)
+		if _, ok := err.(TooSoonError); !ok {
+			t.Errorf("expected TooSoonError, got %v", err)
+		}
+
+		ts.now = ts.now.Add(DefaultResendInterval)
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Errorf("expected a new code to be sent, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when a code was sent too recently to another phone number", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		modified := &entity.User{ID: harold.ID, PhoneNumber: "+33123456789"}
+		err := ts.uService.Update(modified, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ts.s.Confirm(harold.SubID, ts.sender.code); err == nil {
+			t.Fatal("expected the code sent to the previous phone number to be rejected")
+		}
+
+		_, err = ts.s.Start(harold.SubID)
+		if _, ok := err.(TooSoonError); !ok {
+			t.Errorf("expected TooSoonError, got %v", err)
+		}
+	})
+}
+
+func TestServiceConfirm(t *testing.T) {
+	t.Run("Should mark the phone number as verified", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		err := ts.s.Confirm(harold.SubID, ts.sender.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := ts.uService.FindByID(harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !u.PhoneVerified {
+			t.Error("expected phone number to be verified")
+		}
+
+		_, err = ts.s.Start(harold.SubID)
+		if _, ok := err.(AlreadyVerifiedError); !ok {
+			t.Errorf("expected AlreadyVerifiedError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when no code was sent", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		err := ts.s.Confirm(harold.SubID, "123456")
+		if _, ok := err.(NotFoundError); !ok {
+			t.Errorf("expected NotFoundError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when code expired", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		ts.now = ts.now.Add(DefaultTTL)
+		err := ts.s.Confirm(harold.SubID, ts.sender.code)
+		if _, ok := err.(ExpiredCodeError); !ok {
+			t.Errorf("expected ExpiredCodeError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail after too many incorrect codes", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		incorrect := "000000"
+		if ts.sender.code == incorrect {
+			incorrect = "111111"
+		}
+
+		for i := 0; i < 2; i++ {
+			err := ts.s.Confirm(harold.SubID, incorrect)
+			if _, ok := err.(IncorrectCodeError); !ok {
+				t.Fatalf("expected IncorrectCodeError, got %v", err)
+			}
+		}
+
+		err := ts.s.Confirm(harold.SubID, ts.sender.code)
+		if _, ok := err.(TooManyAttemptsError); !ok {
+			t.Errorf("expected TooManyAttemptsError, got %v", err)
+		}
+	})
+
+	t.Run("Should not exceed the maximum number of attempts with concurrent codes", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		incorrect := "000000"
+		if ts.sender.code == incorrect {
+			incorrect = "111111"
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- ts.s.Confirm(harold.SubID, incorrect)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		attempts := 0
+		for err := range errs {
+			if _, ok := err.(IncorrectCodeError); ok {
+				attempts++
+			} else if _, ok := err.(TooManyAttemptsError); !ok {
+				t.Errorf("expected IncorrectCodeError or TooManyAttemptsError, got %v", err)
+			}
+		}
+
+		if attempts != 2 {
+			t.Errorf("expected %d codes to be checked, got %d", 2, attempts)
+		}
+	})
+
+	t.Run("Should fail when phone number was modified since the code was sent", func(t *testing.T) {
+		ts, harold := newTestServices(t)
+
+		if _, err := ts.s.Start(harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+
+		modified := &entity.User{ID: harold.ID, PhoneNumber: "+33123456789"}
+		err := ts.uService.Update(modified, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ts.s.Confirm(harold.SubID, ts.sender.code)
+		if _, ok := err.(NotFoundError); !ok {
+			t.Errorf("expected NotFoundError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+// Package phone verifies that users own their phone number by sending them a
+// one-time code by text message.
+package phone
+
+import (
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A Verification is a pending verification of a user's phone number. Only the
+// hash of the code that was sent is kept, so that the code cannot be read
+// back from the repository.
+type Verification struct {
+	UserID      entity.ID `json:"-"`
+	PhoneNumber string    `json:"phoneNumber"`
+	CodeHash    string    `json:"-"`
+	Attempts    int       `json:"-"`
+	SentAt      time.Time `json:"sentAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
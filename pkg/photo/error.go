@@ -0,0 +1,63 @@
+package photo
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A MissingFileError is an error that represents that the uploaded photo is
+// empty.
+type MissingFileError struct {
+	entity.BaseError
+}
+
+var missingFile = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  entity.CodeMissing,
+	Field: "photo",
+}
+
+// A FileTooLargeError is an error that represents that the uploaded photo is
+// larger than the maximum size, either in bytes or in pixels.
+type FileTooLargeError struct {
+	entity.BaseError
+}
+
+var fileTooLarge = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "fileTooLarge",
+	Field: "photo",
+}
+
+// An UnsupportedContentTypeError is an error that represents that the
+// uploaded photo is not an image in a supported format.
+type UnsupportedContentTypeError struct {
+	entity.BaseError
+}
+
+var unsupportedContentType = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "unsupportedContentType",
+	Field: "photo",
+}
+
+// A MalformedImageError is an error that represents that the uploaded photo
+// claims to be an image but cannot be decoded.
+type MalformedImageError struct {
+	entity.BaseError
+}
+
+var malformedImage = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  entity.CodeMalformed,
+	Field: "photo",
+}
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to modify the photo of another user or of its vehicules.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "photoOfAnotherUser",
+	Detail: "not allowed to modify the photo of another user",
+}
@@ -0,0 +1,299 @@
+// Package photo lets users upload the photos of their profile and of their
+// vehicules. Photos are scaled down to a few sizes, stored in a media.Store,
+// and the profile or vehicule is updated with the URL where they are served.
+package photo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	// Registers the GIF and PNG formats with image.Decode.
+	_ "image/gif"
+	_ "image/png"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves photos.
+type UseCase interface {
+	UploadUserPhoto(userID entity.ID, r io.Reader, caller *user.Caller) (*Photo, error)
+	UploadVehiculePhoto(ID entity.ID, userID entity.ID, r io.Reader, subID string) (*Photo, error)
+	MaxFileSize() int64
+}
+
+// A Photo contains the URLs where an uploaded photo is served. The URL of the
+// photo itself is the one of its largest size.
+type Photo struct {
+	URL        string            `json:"photo"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+// Config contains the information required to store and serve photos.
+type Config struct {
+	// BaseURL specifies the URL under which the keys of the store are served
+	// (ex. https://users.ecovo.ca/media, which serves the photos/... keys).
+	BaseURL string
+
+	// MaxFileSize specifies the maximum size of an uploaded photo in bytes.
+	//
+	// Zero means DefaultMaxFileSize.
+	MaxFileSize int64
+
+	// MaxPixels specifies the maximum number of pixels of an uploaded photo,
+	// so that decoding it does not exhaust the memory.
+	//
+	// Zero means DefaultMaxPixels.
+	MaxPixels int
+}
+
+const (
+	// DefaultMaxFileSize represents the default maximum size of an uploaded
+	// photo (5 MiB).
+	DefaultMaxFileSize = 5 << 20
+
+	// DefaultMaxPixels represents the default maximum number of pixels of an
+	// uploaded photo (40 megapixels).
+	DefaultMaxPixels = 40000000
+
+	// jpegQuality represents the quality with which photos are encoded.
+	jpegQuality = 85
+)
+
+// contentTypes contains the content types of the photos that can be uploaded.
+var contentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// validate looks at the configuration's contents to ensure it has all the
+// required fields.
+func (conf *Config) validate() error {
+	if conf.BaseURL == "" {
+		return errors.New("missing base URL")
+	}
+
+	if _, err := url.Parse(conf.BaseURL); err != nil {
+		return fmt.Errorf("malformed base URL (%s)", err)
+	}
+
+	return nil
+}
+
+// A Service handles the business logic related to photos.
+type Service struct {
+	store    media.Store
+	uService user.UseCase
+	vService vehicule.UseCase
+	config   Config
+}
+
+// NewService creates a photo service that keeps photos in a store.
+func NewService(store media.Store, uService user.UseCase, vService vehicule.UseCase, config *Config) (*Service, error) {
+	if config == nil {
+		return nil, fmt.Errorf("photo: missing configuration")
+	}
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("photo: configuration %s", err)
+	}
+
+	c := *config
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	if c.MaxFileSize == 0 {
+		c.MaxFileSize = DefaultMaxFileSize
+	}
+
+	if c.MaxPixels == 0 {
+		c.MaxPixels = DefaultMaxPixels
+	}
+
+	return &Service{store, uService, vService, c}, nil
+}
+
+// MaxFileSize returns the maximum size of an uploaded photo in bytes.
+func (s *Service) MaxFileSize() int64 {
+	return s.config.MaxFileSize
+}
+
+// UploadUserPhoto replaces the photo of the user with the given ID by the
+// uploaded one. Unless the caller is privileged, it can only replace its own
+// photo. The previous photo is deleted when it was uploaded the same way.
+func (s *Service) UploadUserPhoto(userID entity.ID, r io.Reader, caller *user.Caller) (*Photo, error) {
+	if caller == nil {
+		return nil, fmt.Errorf("photo.Service: caller is nil")
+	}
+
+	u, err := s.uService.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("photo.Service: cannot modify the photo of another user \"%s\"", userID))}
+	}
+
+	prefix := fmt.Sprintf("photos/users/%s/", userID)
+	p, err := s.upload(prefix, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uService.Update(&entity.User{ID: userID, Photo: p.URL}, caller)
+	if err != nil {
+		s.delete(prefix, p.URL)
+
+		return nil, err
+	}
+
+	s.delete(prefix, u.Photo)
+
+	return p, nil
+}
+
+// UploadVehiculePhoto replaces the photo of the vehicule with the given ID by
+// the uploaded one. A user can only replace the photo of its own vehicules.
+// The previous photo is deleted when it was uploaded the same way.
+func (s *Service) UploadVehiculePhoto(ID entity.ID, userID entity.ID, r io.Reader, subID string) (*Photo, error) {
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.ID != userID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("photo.Service: cannot modify the photo of a vehicule of another user \"%s\"", userID))}
+	}
+
+	v, err := s.vService.FindByID(ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("photos/vehicules/%s/", ID)
+	p, err := s.upload(prefix, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.vService.Update(&entity.Vehicule{ID: ID, UserID: userID, Photo: p.URL}, subID)
+	if err != nil {
+		s.delete(prefix, p.URL)
+
+		return nil, err
+	}
+
+	s.delete(prefix, v.Photo)
+
+	return p, nil
+}
+
+// upload scales an uploaded photo down to every size and stores each of them
+// under a new version of the given prefix, so that the URLs of a photo never
+// serve another photo.
+func (s *Service) upload(prefix string, r io.Reader) (*Photo, error) {
+	img, err := s.decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version := make([]byte, 8)
+	_, _ = rand.Read(version)
+	prefix += hex.EncodeToString(version) + "/"
+
+	p := &Photo{Thumbnails: make(map[string]string, len(Sizes))}
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, thumbnail(img, size.Dimension), &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return nil, fmt.Errorf("photo.Service: failed to encode photo (%s)", err)
+		}
+
+		key := prefix + size.Name + ".jpg"
+		err = s.store.Put(key, "image/jpeg", &buf)
+		if err != nil {
+			s.delete(prefix, p.URL)
+
+			return nil, err
+		}
+
+		p.URL = s.config.BaseURL + "/" + key
+		p.Thumbnails[size.Name] = p.URL
+	}
+
+	return p, nil
+}
+
+// decode reads and decodes an uploaded photo, making sure that it is an image
+// in a supported format that is neither empty nor too large.
+func (s *Service) decode(r io.Reader) (*image.RGBA, error) {
+	if r == nil {
+		return nil, MissingFileError{missingFile.New("photo.Service: photo is missing")}
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(r, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("photo.Service: failed to read photo (%s)", err)
+	}
+
+	if len(content) == 0 {
+		return nil, MissingFileError{missingFile.New("photo.Service: photo is empty")}
+	}
+
+	if int64(len(content)) > s.config.MaxFileSize {
+		return nil, FileTooLargeError{fileTooLarge.New(fmt.Sprintf("photo.Service: photo is larger than %d bytes", s.config.MaxFileSize))}
+	}
+
+	contentType := http.DetectContentType(content)
+	if !contentTypes[contentType] {
+		return nil, UnsupportedContentTypeError{unsupportedContentType.New(fmt.Sprintf("photo.Service: photo of type \"%s\" must be a JPEG, a PNG or a GIF", contentType))}
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, MalformedImageError{malformedImage.New(fmt.Sprintf("photo.Service: photo could not be decoded (%s)", err))}
+	}
+
+	if config.Width*config.Height > s.config.MaxPixels {
+		return nil, FileTooLargeError{fileTooLarge.New(fmt.Sprintf("photo.Service: photo has more than %d pixels", s.config.MaxPixels))}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, MalformedImageError{malformedImage.New(fmt.Sprintf("photo.Service: photo could not be decoded (%s)", err))}
+	}
+
+	return toRGBA(img), nil
+}
+
+// delete removes every size of the photo with the given URL from the store,
+// as long as it was stored under the given prefix. Photos that are hosted
+// elsewhere, or that belong to another user or vehicule, are left untouched.
+// Failing to delete a photo only leaves an orphan file behind, so errors are
+// ignored.
+func (s *Service) delete(prefix string, photoURL string) {
+	key := strings.TrimPrefix(photoURL, s.config.BaseURL+"/")
+	if key == photoURL || !strings.HasPrefix(key, prefix) {
+		return
+	}
+
+	dir := path.Dir(key)
+	for _, size := range Sizes {
+		_ = s.store.Delete(dir + "/" + size.Name + ".jpg")
+	}
+}
@@ -0,0 +1,210 @@
+package photo
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+)
+
+type testServices struct {
+	s        *Service
+	uService *user.Service
+	vService *vehicule.Service
+	store    *media.FileStore
+	dir      string
+}
+
+func newTestServices(t *testing.T) (*testServices, *entity.User, *entity.User) {
+	dir, err := ioutil.TempDir("", "photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store, err := media.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uService := user.NewService(user.NewMemoryRepository())
+	register := func(subID string) *entity.User {
+		u, err := uService.Register(&entity.User{
+			SubID:       subID,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	ts := &testServices{
+		uService: uService,
+		vService: vehicule.NewService(vehicule.NewMemoryRepository(), uService, nil),
+		store:    store,
+		dir:      dir,
+	}
+	ts.s, err = NewService(store, uService, ts.vService, &Config{BaseURL: "https://ecovo.ca/media/", MaxFileSize: 64 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ts, register("harold|1"), register("maurice|1")
+}
+
+// newPNG encodes a PNG image of the given size.
+func newPNG(t *testing.T, w int, h int) []byte {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// open decodes the stored photo served at the given URL.
+func (ts *testServices) open(t *testing.T, url string) image.Config {
+	f, err := ts.store.Get(strings.TrimPrefix(url, "https://ecovo.ca/media/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	config, err := jpeg.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return config
+}
+
+func TestServiceUploadUserPhoto(t *testing.T) {
+	t.Run("Should store every size and set the user's photo", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		p, err := ts.s.UploadUserPhoto(harold.ID, bytes.NewReader(newPNG(t, 2048, 1024)), &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(p.URL, "https://ecovo.ca/media/photos/users/"+harold.ID.Hex()+"/") || p.URL != p.Thumbnails["large"] {
+			t.Errorf("unexpected photo URL %q", p.URL)
+		}
+
+		for _, size := range Sizes {
+			config := ts.open(t, p.Thumbnails[size.Name])
+			if config.Width != size.Dimension || config.Height != size.Dimension/2 {
+				t.Errorf("expected %s photo to be %dx%d, got %dx%d", size.Name, size.Dimension, size.Dimension/2, config.Width, config.Height)
+			}
+		}
+
+		u, err := ts.uService.FindByID(harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Photo != p.URL {
+			t.Errorf("expected user's photo to be %q, got %q", p.URL, u.Photo)
+		}
+	})
+
+	t.Run("Should delete the previous photo", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+		caller := &user.Caller{SubID: harold.SubID}
+
+		previous, err := ts.s.UploadUserPhoto(harold.ID, bytes.NewReader(newPNG(t, 10, 10)), caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.s.UploadUserPhoto(harold.ID, bytes.NewReader(newPNG(t, 10, 10)), caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ts.store.Get(strings.TrimPrefix(previous.URL, "https://ecovo.ca/media/"))
+		if _, ok := err.(media.NotFoundError); !ok {
+			t.Errorf("expected previous photo to be deleted, got %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		content func(t *testing.T) []byte
+		check   func(err error) bool
+	}{
+		{"Should fail when photo is empty", func(t *testing.T) []byte { return nil }, func(err error) bool { _, ok := err.(MissingFileError); return ok }},
+		{"Should fail when photo is too large", func(t *testing.T) []byte { return append(newPNG(t, 1, 1), make([]byte, 64<<10)...) }, func(err error) bool { _, ok := err.(FileTooLargeError); return ok }},
+		{"Should fail when photo has too many pixels", func(t *testing.T) []byte { return newPNG(t, 8000, 6000) }, func(err error) bool { _, ok := err.(FileTooLargeError); return ok }},
+		{"Should fail when photo is not an image", func(t *testing.T) []byte { return []byte("%PDF-1.4\n") }, func(err error) bool { _, ok := err.(UnsupportedContentTypeError); return ok }},
+		{"Should fail when photo is truncated", func(t *testing.T) []byte { return newPNG(t, 10, 10)[:40] }, func(err error) bool { _, ok := err.(MalformedImageError); return ok }},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ts, harold, _ := newTestServices(t)
+
+			_, err := ts.s.UploadUserPhoto(harold.ID, bytes.NewReader(test.content(t)), &user.Caller{SubID: harold.SubID})
+			if !test.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+
+	t.Run("Should fail when modifying the photo of another user", func(t *testing.T) {
+		ts, harold, maurice := newTestServices(t)
+
+		_, err := ts.s.UploadUserPhoto(harold.ID, bytes.NewReader(newPNG(t, 10, 10)), &user.Caller{SubID: maurice.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+}
+
+func TestServiceUploadVehiculePhoto(t *testing.T) {
+	ts, harold, maurice := newTestServices(t)
+
+	v, err := ts.vService.Register(&entity.Vehicule{UserID: harold.ID, Year: 2015, Make: "Audi", Model: "A4", Color: "red", Seats: 4}, harold.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should set the vehicule's photo", func(t *testing.T) {
+		p, err := ts.s.UploadVehiculePhoto(v.ID, harold.ID, bytes.NewReader(newPNG(t, 10, 10)), harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := ts.vService.FindByID(v.ID, harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found.Photo != p.URL || !strings.Contains(p.URL, "/photos/vehicules/"+v.ID.Hex()+"/") {
+			t.Errorf("expected vehicule's photo to be %q, got %q", p.URL, found.Photo)
+		}
+	})
+
+	t.Run("Should fail when modifying the vehicule of another user", func(t *testing.T) {
+		_, err := ts.s.UploadVehiculePhoto(v.ID, harold.ID, bytes.NewReader(newPNG(t, 10, 10)), maurice.SubID)
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package photo
+
+import (
+	"image"
+	"image/draw"
+)
+
+// A Size is a size in which photos are generated. Photos are scaled down so
+// that their largest side is at most the size's dimension, while keeping
+// their aspect ratio. Photos are never scaled up.
+type Size struct {
+	Name      string
+	Dimension int
+}
+
+// Sizes contains the sizes in which every photo is generated, from the
+// smallest to the largest. The largest one is the photo itself, while the
+// others are its thumbnails.
+var Sizes = []Size{
+	{"small", 64},
+	{"medium", 256},
+	{"large", 1024},
+}
+
+// thumbnail scales an image down so that its largest side is at most the
+// given dimension. Each pixel of the thumbnail is the average of the pixels of
+// the image that it covers, which avoids the aliasing of nearest-neighbour
+// scaling without requiring an imaging library.
+func thumbnail(src *image.RGBA, dimension int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	tw, th := w, h
+	if w >= h && w > dimension {
+		tw, th = dimension, max(1, h*dimension/w)
+	} else if h > w && h > dimension {
+		tw, th = max(1, w*dimension/h), dimension
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	if tw == w && th == h {
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, max((x+1)*w/tw, x*w/tw+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// toRGBA converts an image to RGBA, so that its pixels can be read directly.
+// Transparent pixels are drawn over a white background, since photos are
+// encoded as JPEG.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+
+	return dst
+}
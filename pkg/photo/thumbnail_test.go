@@ -0,0 +1,46 @@
+package photo
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name      string
+		w, h      int
+		dimension int
+		expectedW int
+		expectedH int
+	}{
+		{"Should scale landscape photo down to the dimension", 400, 200, 100, 100, 50},
+		{"Should scale portrait photo down to the dimension", 200, 400, 100, 50, 100},
+		{"Should not scale photo up", 40, 20, 100, 40, 20},
+		{"Should keep at least one pixel", 1000, 1, 10, 10, 1},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			thumb := thumbnail(image.NewRGBA(image.Rect(0, 0, test.w, test.h)), test.dimension)
+
+			if thumb.Bounds().Dx() != test.expectedW || thumb.Bounds().Dy() != test.expectedH {
+				t.Errorf("expected %dx%d, got %v", test.expectedW, test.expectedH, thumb.Bounds())
+			}
+		})
+	}
+}
+
+func TestThumbnailAveragesPixels(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	src.Set(1, 0, color.RGBA{255, 0, 0, 255})
+	src.Set(0, 1, color.RGBA{0, 0, 255, 255})
+	src.Set(1, 1, color.RGBA{0, 0, 255, 255})
+
+	thumb := thumbnail(src, 1)
+
+	if c := thumb.RGBAAt(0, 0); c != (color.RGBA{127, 0, 127, 255}) {
+		t.Errorf("expected the average of the pixels, got %v", c)
+	}
+}
@@ -0,0 +1,27 @@
+package rating
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// An AlreadyExistsError is an error that represents that the author already
+// reviewed the subject for the same trip and role.
+type AlreadyExistsError struct {
+	entity.BaseError
+}
+
+var alreadyExists = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "reviewAlreadyExists",
+	Detail: "user was already reviewed for this trip",
+}
+
+// An InvalidQueryError is an error that represents that a query to list reviews is
+// malformed or out of bounds. Its field is the query parameter that is not
+// valid.
+type InvalidQueryError struct {
+	entity.BaseError
+}
+
+var invalidQuery = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "invalidQuery",
+}
@@ -0,0 +1,100 @@
+package rating
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on reviews
+// kept in memory. It is safe for concurrent use and is meant to be used in
+// tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	reviews map[entity.ID]*entity.Review
+}
+
+// NewMemoryRepository creates an empty in-memory review repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{reviews: make(map[entity.ID]*entity.Review)}
+}
+
+// FindBySubjectID retrieves a page of the reviews that the user with the given
+// ID received, filtered according to the query.
+func (r *MemoryRepository) FindBySubjectID(subjectID entity.ID, q *Query) (*Page, error) {
+	return r.find(func(review *entity.Review) bool { return review.SubjectID == subjectID }, q)
+}
+
+// FindByAuthorID retrieves a page of the reviews that the user with the given
+// ID wrote, filtered according to the query.
+func (r *MemoryRepository) FindByAuthorID(authorID entity.ID, q *Query) (*Page, error) {
+	return r.find(func(review *entity.Review) bool { return review.AuthorID == authorID }, q)
+}
+
+// find retrieves a page of the reviews that match the filter, further
+// filtered according to the query.
+func (r *MemoryRepository) find(filter func(review *entity.Review) bool, q *Query) (*Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var reviews = make([]*entity.Review, 0)
+	for _, review := range r.reviews {
+		if filter(review) && q.matches(review) {
+			c := *review
+			reviews = append(reviews, &c)
+		}
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID > reviews[j].ID
+	})
+
+	if len(reviews) > q.Limit+1 {
+		reviews = reviews[:q.Limit+1]
+	}
+
+	return newPage(q, reviews), nil
+}
+
+// Summarize computes the rating summary of the reviews that the user with the
+// given ID received for a role.
+func (r *MemoryRepository) Summarize(subjectID entity.ID, role string) (*entity.RatingSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var histogram [entity.StarsMaximum]int
+	for _, review := range r.reviews {
+		if review.SubjectID == subjectID && review.Role == role {
+			histogram[review.Stars-entity.StarsMinimum]++
+		}
+	}
+
+	return entity.NewRatingSummary(histogram), nil
+}
+
+// Create stores the new review in memory and returns the unique identifier
+// that was generated for it.
+func (r *MemoryRepository) Create(review *entity.Review) (entity.ID, error) {
+	if review == nil {
+		return entity.NilID, fmt.Errorf("rating.MemoryRepository: failed to create review (review is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.reviews {
+		if existing.AuthorID == review.AuthorID && existing.SubjectID == review.SubjectID &&
+			existing.TripID == review.TripID && existing.Role == review.Role {
+			return entity.NilID, AlreadyExistsError{alreadyExists.New(fmt.Sprintf("rating.MemoryRepository: user \"%s\" already reviewed user \"%s\" as %s of trip \"%s\"", review.AuthorID, review.SubjectID, review.Role, review.TripID))}
+		}
+	}
+
+	c := *review
+	c.ID = entity.NewIDFromHex(primitive.NewObjectID().Hex())
+	r.reviews[c.ID] = &c
+
+	return c.ID, nil
+}
@@ -0,0 +1,286 @@
+package rating
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on reviews
+// in a MongoDB collection.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	AuthorID  primitive.ObjectID `bson:"authorId"`
+	SubjectID primitive.ObjectID `bson:"subjectId"`
+	TripID    string             `bson:"tripId"`
+	Role      string             `bson:"role"`
+	Stars     int                `bson:"stars"`
+	Comment   string             `bson:"comment"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// duplicateKeyErrorCode represents the code of the error returned by MongoDB
+// when a document violates a unique index.
+const duplicateKeyErrorCode = 11000
+
+func newDocumentFromEntity(r *entity.Review) (*document, error) {
+	if r == nil {
+		return nil, fmt.Errorf("rating.MongoRepository: entity is nil")
+	}
+
+	id := primitive.NilObjectID
+	if !r.ID.IsZero() {
+		objectID, err := primitive.ObjectIDFromHex(r.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("rating.MongoRepository: failed to create object")
+		}
+
+		id = objectID
+	}
+
+	authorID, err := primitive.ObjectIDFromHex(r.AuthorID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create author object ID")
+	}
+
+	subjectID, err := primitive.ObjectIDFromHex(r.SubjectID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create subject object ID")
+	}
+
+	return &document{
+		id,
+		authorID,
+		subjectID,
+		r.TripID,
+		r.Role,
+		r.Stars,
+		r.Comment,
+		r.CreatedAt,
+	}, nil
+}
+
+func (d document) Entity() *entity.Review {
+	return &entity.Review{
+		ID:        entity.NewIDFromHex(d.ID.Hex()),
+		AuthorID:  entity.NewIDFromHex(d.AuthorID.Hex()),
+		SubjectID: entity.NewIDFromHex(d.SubjectID.Hex()),
+		TripID:    d.TripID,
+		Role:      d.Role,
+		Stars:     d.Stars,
+		Comment:   d.Comment,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+// NewMongoRepository creates a review repository for a MongoDB collection and
+// makes sure the indexes used to list reviews and to prevent duplicate
+// reviews exist.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("rating.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "subjectId", Value: 1},
+				{Key: "role", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().SetName("subject"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "authorId", Value: 1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().SetName("author"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "authorId", Value: 1},
+				{Key: "subjectId", Value: 1},
+				{Key: "tripId", Value: 1},
+				{Key: "role", Value: 1},
+			},
+			Options: options.Index().SetName("oneReviewPerTrip").SetUnique(true),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindBySubjectID retrieves a page of the reviews that the user with the given
+// ID received, filtered according to the query.
+func (r *MongoRepository) FindBySubjectID(subjectID entity.ID, q *Query) (*Page, error) {
+	subjectObjectID, err := primitive.ObjectIDFromHex(string(subjectID))
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create subject object ID")
+	}
+
+	filter := bson.D{{Key: "subjectId", Value: subjectObjectID}}
+	return r.find(filter, q)
+}
+
+// FindByAuthorID retrieves a page of the reviews that the user with the given
+// ID wrote, filtered according to the query.
+func (r *MongoRepository) FindByAuthorID(authorID entity.ID, q *Query) (*Page, error) {
+	authorObjectID, err := primitive.ObjectIDFromHex(string(authorID))
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create author object ID")
+	}
+
+	filter := bson.D{{Key: "authorId", Value: authorObjectID}}
+	return r.find(filter, q)
+}
+
+// find retrieves a page of the reviews that match the filter, further
+// filtered according to the query.
+func (r *MongoRepository) find(filter bson.D, q *Query) (*Page, error) {
+	if q.Role != "" {
+		filter = append(filter, bson.E{Key: "role", Value: q.Role})
+	}
+
+	if q.After != "" {
+		afterID, err := primitive.ObjectIDFromHex(q.After)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("after", "rating: malformed cursor")}
+		}
+
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: afterID}}})
+	}
+
+	// One more review than the limit is fetched to know whether there is a
+	// next page.
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(q.Limit + 1))
+	cur, err := r.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to find reviews (%s)", err)
+	}
+	defer cur.Close(context.TODO())
+
+	var reviews = make([]*entity.Review, 0)
+	for cur.Next(context.TODO()) {
+		var d document
+		err := cur.Decode(&d)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, d.Entity())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(q, reviews), nil
+}
+
+// Summarize computes the rating summary of the reviews that the user with the
+// given ID received for a role.
+func (r *MongoRepository) Summarize(subjectID entity.ID, role string) (*entity.RatingSummary, error) {
+	subjectObjectID, err := primitive.ObjectIDFromHex(string(subjectID))
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to create subject object ID")
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: "subjectId", Value: subjectObjectID},
+			{Key: "role", Value: role},
+		}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$stars"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+	cur, err := r.collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("rating.MongoRepository: failed to summarize reviews of user \"%s\" (%s)", subjectID, err)
+	}
+	defer cur.Close(context.TODO())
+
+	var histogram [entity.StarsMaximum]int
+	for cur.Next(context.TODO()) {
+		var group struct {
+			Stars int `bson:"_id"`
+			Count int `bson:"count"`
+		}
+		err := cur.Decode(&group)
+		if err != nil {
+			return nil, err
+		}
+
+		if group.Stars >= entity.StarsMinimum && group.Stars <= entity.StarsMaximum {
+			histogram[group.Stars-entity.StarsMinimum] = group.Count
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return entity.NewRatingSummary(histogram), nil
+}
+
+// Create stores the new review in the database and returns the unique
+// identifier that was generated for it.
+func (r *MongoRepository) Create(review *entity.Review) (entity.ID, error) {
+	if review == nil {
+		return entity.NilID, fmt.Errorf("rating.MongoRepository: failed to create review (review is nil)")
+	}
+
+	d, err := newDocumentFromEntity(review)
+	if err != nil {
+		return entity.NilID, fmt.Errorf("rating.MongoRepository: failed to create review document from entity (%s)", err)
+	}
+
+	res, err := r.collection.InsertOne(context.TODO(), d)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return entity.NilID, AlreadyExistsError{alreadyExists.New(fmt.Sprintf("rating.MongoRepository: user \"%s\" already reviewed user \"%s\" as %s of trip \"%s\"", review.AuthorID, review.SubjectID, review.Role, review.TripID))}
+		}
+
+		return entity.NilID, fmt.Errorf("rating.MongoRepository: failed to create review (%s)", err)
+	}
+
+	ID, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return entity.NilID, fmt.Errorf("rating.MongoRepository: failed to get ID of created review")
+	}
+
+	return entity.ID(ID.Hex()), nil
+}
+
+// isDuplicateKeyError returns whether a write failed because it violates a
+// unique index.
+func isDuplicateKeyError(err error) bool {
+	e, ok := err.(mongo.WriteException)
+	if !ok {
+		return false
+	}
+
+	for _, we := range e.WriteErrors {
+		if we.Code == duplicateKeyErrorCode {
+			return true
+		}
+	}
+
+	return false
+}
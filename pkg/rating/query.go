@@ -0,0 +1,124 @@
+package rating
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// A Query describes which of the reviews a user received to list. Reviews are
+// listed from the most recent to the oldest.
+type Query struct {
+	// Role specifies the role the user had in the reviewed trips
+	// (entity.ReviewRoleRider or entity.ReviewRoleDriver).
+	//
+	// An empty role means any role.
+	Role string
+
+	// Limit specifies the maximum number of reviews to list.
+	//
+	// Zero means DefaultLimit.
+	Limit int
+
+	// After specifies the cursor of the page to list. It is the next cursor
+	// that was returned with the previous page.
+	After string
+}
+
+const (
+	// DefaultLimit represents the default number of reviews listed at once.
+	DefaultLimit = 20
+
+	// MaxLimit represents the maximum number of reviews listed at once.
+	MaxLimit = 100
+)
+
+// A Page contains reviews listed by a query, along with the cursor of the next
+// page, if there is one.
+type Page struct {
+	Reviews []*entity.Review `json:"reviews"`
+	Next    string           `json:"next,omitempty"`
+}
+
+// ParseQuery creates a query from URL query values (role, limit and after) and
+// validates it.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		Role:  values.Get("role"),
+		After: values.Get("after"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("rating: limit \"%s\" is not a number", limit))}
+		}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// validate makes sure the query's values are within bounds and fills in the
+// default values.
+func (q *Query) validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("rating: limit must be between 1 and %d", MaxLimit))}
+	}
+
+	switch q.Role {
+	case "", entity.ReviewRoleRider, entity.ReviewRoleDriver:
+	default:
+		return InvalidQueryError{invalidQuery.NewForField("role", fmt.Sprintf("rating: role must be %s or %s", entity.ReviewRoleRider, entity.ReviewRoleDriver))}
+	}
+
+	if q.After != "" {
+		if _, err := primitive.ObjectIDFromHex(q.After); err != nil {
+			return InvalidQueryError{invalidQuery.NewForField("after", "rating: malformed cursor")}
+		}
+	}
+
+	return nil
+}
+
+// matches returns whether a review satisfies the query's filters.
+func (q *Query) matches(r *entity.Review) bool {
+	if q.Role != "" && r.Role != q.Role {
+		return false
+	}
+
+	if q.After != "" && r.ID.Hex() >= q.After {
+		return false
+	}
+
+	return true
+}
+
+// newPage creates a page from the reviews that were found for a query. When
+// more reviews than the query's limit were found, the extra reviews are
+// dropped and the cursor of the next page is set to the last review's unique
+// identifier.
+func newPage(q *Query, reviews []*entity.Review) *Page {
+	if len(reviews) <= q.Limit {
+		return &Page{Reviews: reviews}
+	}
+
+	reviews = reviews[:q.Limit]
+
+	return &Page{
+		Reviews: reviews,
+		Next:    reviews[len(reviews)-1].ID.Hex(),
+	}
+}
@@ -0,0 +1,12 @@
+package rating
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on reviews in a database.
+type Repository interface {
+	FindBySubjectID(subjectID entity.ID, q *Query) (*Page, error)
+	FindByAuthorID(authorID entity.ID, q *Query) (*Page, error)
+	Summarize(subjectID entity.ID, role string) (*entity.RatingSummary, error)
+	Create(r *entity.Review) (entity.ID, error)
+}
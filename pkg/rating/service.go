@@ -0,0 +1,144 @@
+package rating
+
+import (
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves reviews and ratings.
+type UseCase interface {
+	Submit(r *entity.Review, authorSubID string) (*entity.Review, error)
+	FindBySubjectID(subjectID entity.ID, q *Query) (*Page, error)
+	Summarize(subjectID entity.ID) (*Ratings, error)
+}
+
+// Ratings contains the summaries of the reviews a user received as a rider
+// and as a driver.
+type Ratings struct {
+	UserRating   *entity.RatingSummary `json:"userRating"`
+	DriverRating *entity.RatingSummary `json:"driverRating"`
+}
+
+// A Service handles the business logic related to reviews and ratings.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+}
+
+// NewService creates a rating service to handle business logic and manipulate
+// reviews through a repository.
+func NewService(repo Repository, uService user.UseCase) *Service {
+	return &Service{repo, uService}
+}
+
+// Submit validates the review written by the user with the given subscription
+// ID, persists it in the repository and counts it in the subject's rating
+// summary for the review's role. Users can only review another user once per
+// trip and role.
+//
+// The summary is only incremented with the review, so that concurrent reviews
+// and updates of the subject are never lost. Since the review is persisted
+// first, a summary that failed to be updated can be recomputed from the
+// reviews with Summarize.
+func (s *Service) Submit(r *entity.Review, authorSubID string) (*entity.Review, error) {
+	if r == nil {
+		return nil, fmt.Errorf("rating.Service: review is nil")
+	}
+
+	author, err := s.uService.FindBySubID(authorSubID)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := s.uService.FindByID(r.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.AuthorID = author.ID
+	r.SubjectID = subject.ID
+	r.CreatedAt = time.Now().UTC()
+
+	err = r.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	r.ID, err = s.repo.Create(r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.uService.AddRating(subject.ID, r.Role, r.Stars)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// FindBySubjectID retrieves a page of the reviews that the user with the given
+// ID received. A nil query lists the most recent reviews.
+func (s *Service) FindBySubjectID(subjectID entity.ID, q *Query) (*Page, error) {
+	if q == nil {
+		q = &Query{}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.uService.FindByID(subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindBySubjectID(subjectID, q)
+}
+
+// Summarize computes the average number of stars of the reviews that the user
+// with the given ID received as a rider and as a driver.
+func (s *Service) Summarize(subjectID entity.ID) (*Ratings, error) {
+	subject, err := s.uService.FindByID(subjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	userRating, err := s.summarize(subject, entity.ReviewRoleRider)
+	if err != nil {
+		return nil, err
+	}
+
+	driverRating, err := s.summarize(subject, entity.ReviewRoleDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ratings{userRating, driverRating}, nil
+}
+
+// summarize computes the rating summary of the reviews that the subject
+// received for a role, including the legacy reviews of its current summary,
+// which only exist in the user's summary.
+func (s *Service) summarize(subject *entity.User, role string) (*entity.RatingSummary, error) {
+	summary, err := s.repo.Summarize(subject.ID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	current := subject.UserRating
+	if role == entity.ReviewRoleDriver {
+		current = subject.DriverRating
+	}
+
+	if current == nil {
+		return summary, nil
+	}
+
+	return summary.WithLegacy(current.Legacy), nil
+}
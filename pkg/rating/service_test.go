@@ -0,0 +1,189 @@
+package rating
+
+import (
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+func newTestServices(t *testing.T) (*Service, user.UseCase, []*entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+
+	var users []*entity.User
+	for _, subID := range []string{"harold|1", "harold|2", "harold|3"} {
+		u, err := uService.Register(&entity.User{
+			SubID:       subID,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		users = append(users, u)
+	}
+
+	return NewService(NewMemoryRepository(), uService), uService, users
+}
+
+func TestServiceSubmit(t *testing.T) {
+	s, uService, users := newTestServices(t)
+	driver, rider, otherRider := users[0], users[1], users[2]
+
+	submit := func(t *testing.T, author *entity.User, stars int) {
+		_, err := s.Submit(&entity.Review{SubjectID: driver.ID, TripID: "trip|1", Role: entity.ReviewRoleDriver, Stars: stars}, author.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Should update the subject's rating for the role", func(t *testing.T) {
+		submit(t, rider, 5)
+		submit(t, otherRider, 4)
+
+		u, err := uService.FindByID(driver.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.DriverRating.Average != 4.5 || u.DriverRating.Histogram != [entity.StarsMaximum]int{0, 0, 0, 1, 1} || u.UserRating.Count != 0 {
+			t.Errorf("unexpected ratings %+v and %+v", u.UserRating, u.DriverRating)
+		}
+
+		ratings, err := s.Summarize(driver.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ratings.DriverRating.Average != 4.5 || ratings.DriverRating.Count != 2 || ratings.UserRating.Count != 0 {
+			t.Errorf("unexpected ratings %+v and %+v", ratings.UserRating, ratings.DriverRating)
+		}
+	})
+
+	t.Run("Should fail when reviewing the same trip twice", func(t *testing.T) {
+		_, err := s.Submit(&entity.Review{SubjectID: driver.ID, TripID: "trip|1", Role: entity.ReviewRoleDriver, Stars: 1}, rider.SubID)
+		if _, ok := err.(AlreadyExistsError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should let the driver review each rider of a trip", func(t *testing.T) {
+		for _, subject := range []*entity.User{rider, otherRider} {
+			_, err := s.Submit(&entity.Review{SubjectID: subject.ID, TripID: "trip|1", Role: entity.ReviewRoleRider, Stars: 3}, driver.SubID)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("Should fail when reviewing oneself", func(t *testing.T) {
+		_, err := s.Submit(&entity.Review{SubjectID: rider.ID, TripID: "trip|2", Role: entity.ReviewRoleRider, Stars: 5}, rider.SubID)
+		if _, ok := err.(entity.ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when subject does not exist", func(t *testing.T) {
+		_, err := s.Submit(&entity.Review{SubjectID: entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3"), TripID: "trip|2", Role: entity.ReviewRoleRider, Stars: 5}, rider.SubID)
+		if _, ok := err.(user.NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSubmitWithLegacyRating(t *testing.T) {
+	s, uService, users := newTestServices(t)
+	driver, rider := users[0], users[1]
+
+	err := uService.UpdateRatings(driver.ID, nil, entity.NewLegacyRatingSummary(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Submit(&entity.Review{SubjectID: driver.ID, TripID: "trip|1", Role: entity.ReviewRoleDriver, Stars: 5}, rider.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := uService.FindByID(driver.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.DriverRating.Count != 2 || u.DriverRating.Average != 4.5 || u.DriverRating.Histogram != [entity.StarsMaximum]int{0, 0, 0, 1, 1} {
+		t.Errorf("expected the legacy rating to be kept, got %+v", u.DriverRating)
+	}
+
+	ratings, err := s.Summarize(driver.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *ratings.DriverRating != *u.DriverRating {
+		t.Errorf("expected summary %+v to match the user's rating %+v", ratings.DriverRating, u.DriverRating)
+	}
+}
+
+func TestServiceFindBySubjectID(t *testing.T) {
+	s, _, users := newTestServices(t)
+	driver, rider := users[0], users[1]
+
+	trips := []struct {
+		tripID string
+		role   string
+	}{
+		{"trip|1", entity.ReviewRoleDriver},
+		{"trip|2", entity.ReviewRoleRider},
+		{"trip|3", entity.ReviewRoleDriver},
+	}
+	for _, trip := range trips {
+		_, err := s.Submit(&entity.Review{SubjectID: driver.ID, TripID: trip.tripID, Role: trip.role, Stars: 4}, rider.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(t *testing.T, q Query) []string {
+		var tripIDs []string
+		for {
+			p, err := s.FindBySubjectID(driver.ID, &q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, r := range p.Reviews {
+				tripIDs = append(tripIDs, r.TripID)
+			}
+
+			if p.Next == "" {
+				return tripIDs
+			}
+			q.After = p.Next
+		}
+	}
+
+	t.Run("Should list the most recent reviews first", func(t *testing.T) {
+		tripIDs := list(t, Query{Limit: 1})
+		if len(tripIDs) != 3 || tripIDs[0] != "trip|3" || tripIDs[2] != "trip|1" {
+			t.Errorf("unexpected reviews %v", tripIDs)
+		}
+	})
+
+	t.Run("Should filter by role", func(t *testing.T) {
+		tripIDs := list(t, Query{Limit: 1, Role: entity.ReviewRoleRider})
+		if len(tripIDs) != 1 || tripIDs[0] != "trip|2" {
+			t.Errorf("unexpected reviews %v", tripIDs)
+		}
+	})
+
+	t.Run("Should fail when role is unknown", func(t *testing.T) {
+		_, err := s.FindBySubjectID(driver.ID, &Query{Role: "passenger"})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+}
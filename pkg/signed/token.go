@@ -0,0 +1,73 @@
+// Package signed encodes payloads in tokens signed with HMAC-SHA256, so that
+// the tokens given to clients can be trusted without being stored.
+package signed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed is returned when a token is not made of an encoded payload
+	// and signature.
+	ErrMalformed = errors.New("signed: malformed token")
+
+	// ErrInvalidSignature is returned when a token was not signed with the
+	// secret.
+	ErrInvalidSignature = errors.New("signed: token signature is not valid")
+)
+
+// Encode encodes a payload as JSON in a token and signs it with the secret.
+func Encode(payload interface{}, secret []byte) (string, error) {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encodedPayload := base64.RawURLEncoding.EncodeToString(content)
+
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signature(encodedPayload, secret)), nil
+}
+
+// Decode verifies that a token was signed with the secret and decodes its
+// payload. It does not check whether the token expired.
+func Decode(token string, secret []byte, payload interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return ErrMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, signature(parts[0], secret)) {
+		return ErrInvalidSignature
+	}
+
+	content, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrMalformed
+	}
+
+	err = json.Unmarshal(content, payload)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	return nil
+}
+
+// Expired returns whether a token that expires at the given Unix time expired
+// at the given time.
+func Expired(expiresAt int64, now time.Time) bool {
+	return !now.Before(time.Unix(expiresAt, 0))
+}
+
+func signature(encodedPayload string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encodedPayload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,71 @@
+package signed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type payload struct {
+	Name      string `json:"name"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func TestDecode(t *testing.T) {
+	secret := []byte("hide the pain")
+	token, err := Encode(&payload{"harold", 42}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should decode the payload of a token signed with the secret", func(t *testing.T) {
+		var p payload
+		err := Decode(token, secret, &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Name != "harold" || p.ExpiresAt != 42 {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	})
+
+	t.Run("Should fail when token was signed with another secret", func(t *testing.T) {
+		var p payload
+		if err := Decode(token, []byte("no pain"), &p); err != ErrInvalidSignature {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when payload was tampered with", func(t *testing.T) {
+		forged, err := Encode(&payload{"maurice", 42}, []byte("no pain"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged = strings.Split(forged, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		var p payload
+		if err := Decode(forged, secret, &p); err != ErrInvalidSignature {
+			t.Errorf("expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when token is malformed", func(t *testing.T) {
+		var p payload
+		if err := Decode("harold", secret, &p); err != ErrMalformed {
+			t.Errorf("expected ErrMalformed, got %v", err)
+		}
+	})
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	if Expired(now.Add(time.Second).Unix(), now) {
+		t.Error("expected token to not be expired before its expiration")
+	}
+
+	if !Expired(now.Unix(), now) {
+		t.Error("expected token to be expired at its expiration")
+	}
+}
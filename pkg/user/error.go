@@ -1,20 +1,88 @@
 package user
 
+import "azure.com/ecovo/user-service/pkg/entity"
+
 // A NotFoundError is an error that represents that no user was found.
 type NotFoundError struct {
-	msg string
+	entity.BaseError
 }
 
-func (e NotFoundError) Error() string {
-	return e.msg
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "userNotFound",
+	Detail: "user does not exist",
 }
 
 // A AlreadyExistsError is an error that represents that a user already exists
 // with a given unique identifier.
 type AlreadyExistsError struct {
-	msg string
+	entity.BaseError
+}
+
+var alreadyExists = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "userAlreadyExists",
+	Detail: "user already exists",
+}
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to perform an operation on a user.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "userOperationForbidden",
+	Detail: "not allowed to perform this operation on the user",
+}
+
+// An InvalidQueryError is an error that represents that a query to search users is
+// malformed or out of bounds. Its field is the query parameter that is not
+// valid.
+type InvalidQueryError struct {
+	entity.BaseError
+}
+
+var invalidQuery = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "invalidQuery",
+}
+
+// An InvalidSignUpTransitionError is an error that represents that a user
+// cannot move from its sign up phase to another one, such as going back to a
+// previous phase or skipping one.
+type InvalidSignUpTransitionError struct {
+	entity.BaseError
+}
+
+var invalidSignUpTransition = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "invalidSignUpTransition",
+	Field: "signUpPhase",
+}
+
+// An InvalidStatusTransitionError is an error that represents that a user's
+// account cannot move from its status to another one, such as deactivating an
+// account that is already deactivated.
+type InvalidStatusTransitionError struct {
+	entity.BaseError
+}
+
+var invalidStatusTransition = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "invalidStatusTransition",
+	Field: "status",
+}
+
+// A SignUpIncompleteError is an error that represents that a user has not
+// provided everything that is required to move on to a sign up phase.
+// Its field is the field that is required.
+type SignUpIncompleteError struct {
+	entity.BaseError
 }
 
-func (e AlreadyExistsError) Error() string {
-	return e.msg
+var signUpIncomplete = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "signUpIncomplete",
 }
@@ -0,0 +1,225 @@
+package user
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on users
+// kept in memory. It is safe for concurrent use and is meant to be used in
+// tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[entity.ID]*entity.User
+}
+
+// NewMemoryRepository creates an empty in-memory user repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{users: make(map[entity.ID]*entity.User)}
+}
+
+// FindByID retrieves the user with the given ID, if it exists and has one of
+// the given statuses (active by default).
+func (r *MemoryRepository) FindByID(ID entity.ID, statuses ...string) (*entity.User, error) {
+	_, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return nil, fmt.Errorf("user.MemoryRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[ID]
+	if !ok || !hasStatus(u, statuses) {
+		return nil, fmt.Errorf("user.MemoryRepository: no user found with ID \"%s\" and status %v (%w)", ID, lookupStatuses(statuses), mongo.ErrNoDocuments)
+	}
+
+	return copyUser(u), nil
+}
+
+// FindBySubID retrieves the user with the given subscription ID, if it exists
+// and has one of the given statuses (active by default).
+func (r *MemoryRepository) FindBySubID(subID string, statuses ...string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.SubID == subID && hasStatus(u, statuses) {
+			return copyUser(u), nil
+		}
+	}
+
+	return nil, fmt.Errorf("user.MemoryRepository: no user found with subscription ID \"%s\" and status %v (%w)", subID, lookupStatuses(statuses), mongo.ErrNoDocuments)
+}
+
+// Search retrieves a page of the active users that satisfy the query's
+// filters. Only the fields that are part of a user's public profile are
+// returned.
+func (r *MemoryRepository) Search(q *Query) (*Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users = make([]*entity.User, 0)
+	for _, u := range r.users {
+		if hasStatus(u, nil) && q.matches(u) {
+			users = append(users, searchableFields(u))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	if len(users) > q.Limit+1 {
+		users = users[:q.Limit+1]
+	}
+
+	return newPage(q, users), nil
+}
+
+// Create stores the new user in memory and returns the unique identifier that
+// was generated for it.
+func (r *MemoryRepository) Create(u *entity.User) (entity.ID, error) {
+	if u == nil {
+		return entity.NilID, fmt.Errorf("user.MemoryRepository: failed to create user (user is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := copyUser(u)
+	c.ID = entity.NewIDFromHex(primitive.NewObjectID().Hex())
+	r.users[c.ID] = c
+
+	return c.ID, nil
+}
+
+// Update updates the user in memory.
+func (r *MemoryRepository) Update(u *entity.User) error {
+	if u == nil {
+		return fmt.Errorf("user.MemoryRepository: failed to update user (user is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[u.ID]
+	if !ok {
+		return fmt.Errorf("user.MemoryRepository: no matching user was found")
+	}
+
+	updated := copyUser(u)
+	updated.UserRating = current.UserRating
+	updated.DriverRating = current.DriverRating
+	r.users[u.ID] = updated
+
+	return nil
+}
+
+// UpdateRatings replaces the ratings of the user with the given ID. A nil
+// rating is left unchanged.
+func (r *MemoryRepository) UpdateRatings(ID entity.ID, userRating *entity.RatingSummary, driverRating *entity.RatingSummary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[ID]
+	if !ok {
+		return fmt.Errorf("user.MemoryRepository: no user found with ID \"%s\" (%w)", ID, mongo.ErrNoDocuments)
+	}
+
+	if userRating != nil {
+		rating := *userRating
+		u.UserRating = &rating
+	}
+
+	if driverRating != nil {
+		rating := *driverRating
+		u.DriverRating = &rating
+	}
+
+	return nil
+}
+
+// AddRating counts a review of the given number of stars in the rating
+// summary of the given role of the user with the given ID.
+func (r *MemoryRepository) AddRating(ID entity.ID, role string, stars int) (*entity.RatingSummary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[ID]
+	if !ok {
+		return nil, fmt.Errorf("user.MemoryRepository: no user found with ID \"%s\" (%w)", ID, mongo.ErrNoDocuments)
+	}
+
+	current := u.UserRating
+	if role == entity.ReviewRoleDriver {
+		current = u.DriverRating
+	}
+
+	var histogram, legacy [entity.StarsMaximum]int
+	if current != nil {
+		histogram = current.Histogram
+		legacy = current.Legacy
+	}
+	histogram[stars-entity.StarsMinimum]++
+
+	rating := entity.NewRatingSummary(histogram)
+	rating.Legacy = legacy
+	if role == entity.ReviewRoleDriver {
+		u.DriverRating = rating
+	} else {
+		u.UserRating = rating
+	}
+
+	c := *rating
+	return &c, nil
+}
+
+// Delete removes the user with the given ID from memory.
+func (r *MemoryRepository) Delete(ID entity.ID) error {
+	_, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return fmt.Errorf("user.MemoryRepository: failed to create object ID")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, ID)
+
+	return nil
+}
+
+// copyUser makes a deep copy of a user so that callers can never modify the
+// repository's state without going through it.
+func copyUser(u *entity.User) *entity.User {
+	c := *u
+
+	if u.Preferences != nil {
+		p := *u.Preferences
+		if u.Preferences.Others != nil {
+			p.Others = make(map[string]int, len(u.Preferences.Others))
+			for key, value := range u.Preferences.Others {
+				p.Others[key] = value
+			}
+		}
+		c.Preferences = &p
+	}
+
+	if u.UserRating != nil {
+		r := *u.UserRating
+		c.UserRating = &r
+	}
+
+	if u.DriverRating != nil {
+		r := *u.DriverRating
+		c.DriverRating = &r
+	}
+
+	return &c
+}
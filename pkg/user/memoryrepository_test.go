@@ -0,0 +1,81 @@
+package user
+
+import (
+	"sync"
+	"testing"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	t.Run("Should generate object IDs", func(t *testing.T) {
+		r := NewMemoryRepository()
+
+		ID, err := r.Create(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := primitive.ObjectIDFromHex(ID.Hex()); err != nil {
+			t.Errorf("expected an object ID, got %q", ID)
+		}
+	})
+
+	t.Run("Should not share state with callers", func(t *testing.T) {
+		r := NewMemoryRepository()
+
+		u := newTestUser("harold|1")
+		u.Preferences = &entity.Preferences{}
+		ID, err := r.Create(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u.FirstName = "Maurice"
+		u.Preferences.Music = entity.PreferenceRegularly
+
+		found, err := r.FindByID(ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found.FirstName != "Harold" || found.Preferences.Music != entity.PreferenceNever {
+			t.Error("expected stored user to be unaffected by changes to the original")
+		}
+	})
+
+	t.Run("Should fail to update a user that does not exist", func(t *testing.T) {
+		r := NewMemoryRepository()
+
+		u := newTestUser("harold|1")
+		u.ID = entity.NewIDFromHex(primitive.NewObjectID().Hex())
+
+		if err := r.Update(u); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should be safe for concurrent use", func(t *testing.T) {
+		r := NewMemoryRepository()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				ID, err := r.Create(newTestUser("harold|1"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if _, err := r.FindByID(ID); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
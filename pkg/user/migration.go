@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// legacyRatingTypes contains the BSON types of the ratings that were stored as
+// a number of stars, before ratings were summaries.
+var legacyRatingTypes = bson.A{"int", "long", "double"}
+
+// migrateRatings converts the ratings stored as a number of stars into rating
+// summaries, in which they are kept as legacy reviews. It only modifies the
+// users that were not migrated yet, so it is safe to run every time the service
+// starts.
+func migrateRatings(collection *mongo.Collection) error {
+	for _, field := range []string{"userRating", "driverRating"} {
+		filter := bson.D{{Key: field, Value: bson.D{{Key: "$type", Value: legacyRatingTypes}}}}
+		findOptions := options.Find().SetProjection(bson.D{{Key: field, Value: 1}})
+		cur, err := collection.Find(context.TODO(), filter, findOptions)
+		if err != nil {
+			return fmt.Errorf("user.MongoRepository: failed to find %s to migrate (%s)", field, err)
+		}
+
+		type legacyRating struct {
+			id    primitive.ObjectID
+			stars float64
+		}
+
+		var legacyRatings []legacyRating
+		for cur.Next(context.TODO()) {
+			id, ok := cur.Current.Lookup("_id").ObjectIDOK()
+			if !ok {
+				continue
+			}
+
+			value := cur.Current.Lookup(field)
+			if stars, ok := value.Int32OK(); ok {
+				legacyRatings = append(legacyRatings, legacyRating{id, float64(stars)})
+			} else if stars, ok := value.Int64OK(); ok {
+				legacyRatings = append(legacyRatings, legacyRating{id, float64(stars)})
+			} else if stars, ok := value.DoubleOK(); ok {
+				legacyRatings = append(legacyRatings, legacyRating{id, stars})
+			}
+		}
+
+		err = cur.Err()
+		cur.Close(context.TODO())
+		if err != nil {
+			return fmt.Errorf("user.MongoRepository: failed to find %s to migrate (%s)", field, err)
+		}
+
+		for _, r := range legacyRatings {
+			filter := bson.D{{Key: "_id", Value: r.id}}
+			update := bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: entity.NewLegacyRatingSummary(r.stars)}}}}
+			_, err := collection.UpdateOne(context.TODO(), filter, update)
+			if err != nil {
+				return fmt.Errorf("user.MongoRepository: failed to migrate %s of user \"%s\" (%s)", field, r.id.Hex(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateStatuses marks the users registered before accounts had a status as
+// active, so that lookups, which filter users by status, keep finding them.
+func migrateStatuses(collection *mongo.Collection) error {
+	filter := bson.D{{Key: "status", Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: entity.UserStatusActive}}}}
+	_, err := collection.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("user.MongoRepository: failed to migrate statuses (%s)", err)
+	}
+
+	return nil
+}
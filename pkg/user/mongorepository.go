@@ -9,6 +9,7 @@ import (
 	"github.com/mongodb/mongo-go-driver/bson"
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
 )
 
 // A MongoRepository is a repository that performs CRUD operations on users in
@@ -18,20 +19,27 @@ type MongoRepository struct {
 }
 
 type document struct {
-	ID           primitive.ObjectID  `bson:"_id,omitempty"`
-	SubID        string              `bson:"subId"`
-	Email        string              `bson:"email"`
-	FirstName    string              `bson:"firstName"`
-	LastName     string              `bson:"lastName"`
-	DateOfBirth  time.Time           `bson:"dateOfBirth"`
-	PhoneNumber  string              `bson:"phoneNumber"`
-	Gender       string              `bson:"gender"`
-	Photo        string              `bson:"photo"`
-	Description  string              `bson:"description"`
-	Preferences  *entity.Preferences `bson:"preferences"`
-	SignUpPhase  string              `bson:"signUpPhase"`
-	UserRating   int                 `json:"userRating" bson:"userRating"`
-	DriverRating int                 `json:"driverRating" bson:"driverRating"`
+	ID            primitive.ObjectID    `bson:"_id,omitempty"`
+	SubID         string                `bson:"subId"`
+	Email         string                `bson:"email"`
+	EmailVerified bool                  `bson:"emailVerified"`
+	IdentityEmail string                `bson:"identityEmail"`
+	FirstName     string                `bson:"firstName"`
+	LastName      string                `bson:"lastName"`
+	DateOfBirth   time.Time             `bson:"dateOfBirth"`
+	PhoneNumber   string                `bson:"phoneNumber"`
+	PhoneVerified bool                  `bson:"phoneVerified"`
+	Gender        string                `bson:"gender"`
+	Photo         string                `bson:"photo"`
+	Description   string                `bson:"description"`
+	Preferences   *entity.Preferences   `bson:"preferences"`
+	SignUpPhase   string                `bson:"signUpPhase"`
+	UserRating    *entity.RatingSummary `bson:"userRating,omitempty"`
+	DriverRating  *entity.RatingSummary `bson:"driverRating,omitempty"`
+
+	VerifiedDriverUntil time.Time `bson:"verifiedDriverUntil"`
+	Status              string    `bson:"status"`
+	StatusChangedAt     time.Time `bson:"statusChangedAt"`
 }
 
 func newDocumentFromEntity(u *entity.User) (*document, error) {
@@ -55,75 +63,234 @@ func newDocumentFromEntity(u *entity.User) (*document, error) {
 		id,
 		u.SubID,
 		u.Email,
+		u.EmailVerified,
+		u.IdentityEmail,
 		u.FirstName,
 		u.LastName,
 		u.DateOfBirth,
 		u.PhoneNumber,
+		u.PhoneVerified,
 		u.Gender,
 		u.Photo,
 		u.Description,
 		u.Preferences,
 		u.SignUpPhase,
-		*u.UserRating,
-		*u.DriverRating,
+		u.UserRating,
+		u.DriverRating,
+		u.VerifiedDriverUntil,
+		u.CurrentStatus(),
+		u.StatusChangedAt,
 	}, nil
 }
 
 func (d document) Entity() *entity.User {
 	return &entity.User{
-		entity.NewIDFromHex(d.ID.Hex()),
-		d.SubID,
-		d.Email,
-		d.FirstName,
-		d.LastName,
-		d.DateOfBirth,
-		d.PhoneNumber,
-		d.Gender,
-		d.Photo,
-		d.Description,
-		d.Preferences,
-		d.SignUpPhase,
-		&d.UserRating,
-		&d.DriverRating,
+		ID:            entity.NewIDFromHex(d.ID.Hex()),
+		SubID:         d.SubID,
+		Email:         d.Email,
+		EmailVerified: d.EmailVerified,
+		IdentityEmail: d.IdentityEmail,
+		FirstName:     d.FirstName,
+		LastName:      d.LastName,
+		DateOfBirth:   d.DateOfBirth,
+		PhoneNumber:   d.PhoneNumber,
+		PhoneVerified: d.PhoneVerified,
+		Gender:        d.Gender,
+		Photo:         d.Photo,
+		Description:   d.Description,
+		Preferences:   d.Preferences,
+		SignUpPhase:   d.SignUpPhase,
+		UserRating:    d.UserRating,
+		DriverRating:  d.DriverRating,
+
+		VerifiedDriverUntil: d.VerifiedDriverUntil,
+		Status:              d.Status,
+		StatusChangedAt:     d.StatusChangedAt,
 	}
 }
 
-// NewMongoRepository creates a user repository for a MongoDB collection.
+// NewMongoRepository creates a user repository for a MongoDB collection,
+// migrates the users stored in an older format and makes sure the indexes used
+// to look up and search users exist. Lookups only find active users, unless
+// the statuses of the users to find are given.
 func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
 	if collection == nil {
 		return nil, fmt.Errorf("user.MongoRepository: collection is nil")
 	}
 
+	err := migrateRatings(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateStatuses(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "subId", Value: 1}},
+			Options: options.Index().SetName("subId"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}},
+			Options: options.Index().SetName("status"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "signUpPhase", Value: 1},
+				{Key: "gender", Value: 1},
+				{Key: "preferences.smoking", Value: 1},
+				{Key: "preferences.conversation", Value: 1},
+				{Key: "preferences.music", Value: 1},
+			},
+			Options: options.Index().SetName("directory"),
+		},
+		{
+			Keys:    bson.D{{Key: "driverRating.average", Value: -1}},
+			Options: options.Index().SetName("driverRatingAverage"),
+		},
+		{
+			Keys:    bson.D{{Key: "userRating.average", Value: -1}},
+			Options: options.Index().SetName("userRatingAverage"),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to create indexes (%s)", err)
+	}
+
 	return &MongoRepository{collection}, nil
 }
 
-// FindByID retrieves the user with the given ID, if it exists.
-func (r *MongoRepository) FindByID(ID entity.ID) (*entity.User, error) {
+// FindByID retrieves the user with the given ID, if it exists and has one of
+// the given statuses (active by default).
+func (r *MongoRepository) FindByID(ID entity.ID, statuses ...string) (*entity.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(string(ID))
 	if err != nil {
-		return nil, fmt.Errorf("user.MongoRepository: failed to create object ID")
+		return nil, fmt.Errorf("user.MongoRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
 	}
 
-	filter := bson.D{{"_id", objectID}}
+	filter := bson.D{{Key: "_id", Value: objectID}, statusFilter(statuses)}
 	var d document
 	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
-	if err != nil {
-		return nil, fmt.Errorf("user.MongoRepository: no user found with ID \"%s\" (%s)", ID, err)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user.MongoRepository: no user found with ID \"%s\" and status %v (%w)", ID, lookupStatuses(statuses), err)
+	} else if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to find user with ID \"%s\" (%s)", ID, err)
 	}
 	return d.Entity(), nil
 }
 
-// FindBySubID retrieves the user with the given subscription ID, if it exists.
-func (r *MongoRepository) FindBySubID(subID string) (*entity.User, error) {
-	filter := bson.D{{"subId", subID}}
+// FindBySubID retrieves the user with the given subscription ID, if it exists
+// and has one of the given statuses (active by default).
+func (r *MongoRepository) FindBySubID(subID string, statuses ...string) (*entity.User, error) {
+	filter := bson.D{{Key: "subId", Value: subID}, statusFilter(statuses)}
 	var d document
 	err := r.collection.FindOne(context.TODO(), filter).Decode(&d)
-	if err != nil {
-		return nil, fmt.Errorf("user.MongoRepository: no user found with subscription ID \"%s\" (%s)", subID, err)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user.MongoRepository: no user found with subscription ID \"%s\" and status %v (%w)", subID, lookupStatuses(statuses), err)
+	} else if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to find user with subscription ID \"%s\" (%s)", subID, err)
 	}
 	return d.Entity(), nil
 }
 
+// statusFilter returns the filter that only matches the users with one of the
+// given statuses (active by default).
+func statusFilter(statuses []string) bson.E {
+	values := bson.A{}
+	for _, status := range lookupStatuses(statuses) {
+		values = append(values, status)
+	}
+
+	return bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: values}}}
+}
+
+// Search retrieves a page of the active users that satisfy the query's
+// filters. Only the fields that are part of a user's public profile are
+// returned.
+func (r *MongoRepository) Search(q *Query) (*Page, error) {
+	filter := bson.D{statusFilter(nil)}
+
+	preferences := []struct {
+		key   string
+		value *int
+	}{
+		{"preferences.smoking", q.Smoking},
+		{"preferences.conversation", q.Conversation},
+		{"preferences.music", q.Music},
+	}
+	for _, p := range preferences {
+		if p.value != nil {
+			filter = append(filter, bson.E{Key: p.key, Value: *p.value})
+		}
+	}
+
+	if q.Gender != "" {
+		filter = append(filter, bson.E{Key: "gender", Value: q.Gender})
+	}
+
+	if q.MinUserRating != nil {
+		filter = append(filter, bson.E{Key: "userRating.average", Value: bson.D{{Key: "$gte", Value: *q.MinUserRating}}})
+	}
+
+	if q.MinDriverRating != nil {
+		filter = append(filter, bson.E{Key: "driverRating.average", Value: bson.D{{Key: "$gte", Value: *q.MinDriverRating}}})
+	}
+
+	if q.SignUpPhase != "" {
+		filter = append(filter, bson.E{Key: "signUpPhase", Value: q.SignUpPhase})
+	}
+
+	if q.After != "" {
+		afterID, err := primitive.ObjectIDFromHex(q.After)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("after", "user: malformed cursor")}
+		}
+
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: afterID}}})
+	}
+
+	// One more user than the limit is fetched to know whether there is a next
+	// page.
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(q.Limit + 1)).
+		SetProjection(bson.D{
+			{Key: "firstName", Value: 1},
+			{Key: "lastName", Value: 1},
+			{Key: "dateOfBirth", Value: 1},
+			{Key: "photo", Value: 1},
+			{Key: "description", Value: 1},
+			{Key: "preferences", Value: 1},
+			{Key: "userRating", Value: 1},
+			{Key: "driverRating", Value: 1},
+			{Key: "verifiedDriverUntil", Value: 1},
+		})
+	cur, err := r.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to search users (%s)", err)
+	}
+	defer cur.Close(context.TODO())
+
+	var users = make([]*entity.User, 0)
+	for cur.Next(context.TODO()) {
+		var d document
+		err := cur.Decode(&d)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, d.Entity())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return newPage(q, users), nil
+}
+
 // Create stores the new user in the database and returns the unique
 // identifier that was generated for it.
 func (r *MongoRepository) Create(u *entity.User) (entity.ID, error) {
@@ -156,9 +323,14 @@ func (r *MongoRepository) Update(u *entity.User) error {
 		return fmt.Errorf("user.MongoRepository: failed to create user document from entity (%s)", err)
 	}
 
-	filter := bson.D{{"_id", d.ID}}
+	// The ratings are omitted from the document, so that they are left as
+	// they are.
+	d.UserRating = nil
+	d.DriverRating = nil
+
+	filter := bson.D{{Key: "_id", Value: d.ID}}
 	update := bson.D{
-		bson.E{"$set", d},
+		bson.E{Key: "$set", Value: d},
 	}
 	res, err := r.collection.UpdateOne(context.TODO(), filter, update)
 	if err != nil {
@@ -172,6 +344,95 @@ func (r *MongoRepository) Update(u *entity.User) error {
 	return nil
 }
 
+// UpdateRatings replaces the ratings of the user with the given ID, without
+// modifying any other field. A nil rating is left unchanged.
+func (r *MongoRepository) UpdateRatings(ID entity.ID, userRating *entity.RatingSummary, driverRating *entity.RatingSummary) error {
+	objectID, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return fmt.Errorf("user.MongoRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	ratings := bson.D{}
+	if userRating != nil {
+		ratings = append(ratings, bson.E{Key: "userRating", Value: userRating})
+	}
+
+	if driverRating != nil {
+		ratings = append(ratings, bson.E{Key: "driverRating", Value: driverRating})
+	}
+
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	update := bson.D{{Key: "$set", Value: ratings}}
+	res, err := r.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("user.MongoRepository: failed to update ratings of user with ID \"%s\" (%s)", ID, err)
+	}
+
+	if res.MatchedCount <= 0 {
+		return fmt.Errorf("user.MongoRepository: no user found with ID \"%s\" (%w)", ID, mongo.ErrNoDocuments)
+	}
+
+	return nil
+}
+
+// AddRating counts a review of the given number of stars in the rating
+// summary of the given role of the user with the given ID. The count and the
+// histogram are incremented in a single update, and the average is then
+// recomputed from them, unless another review was counted in the meantime,
+// in which case the update that counted it recomputes the average.
+func (r *MongoRepository) AddRating(ID entity.ID, role string, stars int) (*entity.RatingSummary, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	field := ratingField(role)
+
+	// Users created before they had ratings have no summary to increment.
+	filter := bson.D{{Key: "_id", Value: objectID}, {Key: field, Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: &entity.RatingSummary{}}}}}
+	_, err = r.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to initialize %s of user with ID \"%s\" (%s)", field, ID, err)
+	}
+
+	filter = bson.D{{Key: "_id", Value: objectID}}
+	update = bson.D{{Key: "$inc", Value: bson.D{
+		{Key: field + ".count", Value: 1},
+		{Key: fmt.Sprintf("%s.histogram.%d", field, stars-entity.StarsMinimum), Value: 1},
+	}}}
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var d document
+	err = r.collection.FindOneAndUpdate(context.TODO(), filter, update, findOptions).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("user.MongoRepository: no user found with ID \"%s\" (%w)", ID, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to add %s of user with ID \"%s\" (%s)", field, ID, err)
+	}
+
+	current := d.UserRating
+	if role == entity.ReviewRoleDriver {
+		current = d.DriverRating
+	}
+
+	rating := entity.NewRatingSummary(current.Histogram)
+	rating.Legacy = current.Legacy
+
+	filter = bson.D{{Key: "_id", Value: objectID}, {Key: field + ".count", Value: rating.Count}}
+	update = bson.D{{Key: "$set", Value: bson.D{{Key: field + ".average", Value: rating.Average}}}}
+	_, err = r.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return nil, fmt.Errorf("user.MongoRepository: failed to update %s of user with ID \"%s\" (%s)", field, ID, err)
+	}
+
+	return rating, nil
+}
+
 // Delete removes the user with the given ID from the database.
 func (r *MongoRepository) Delete(ID entity.ID) error {
 	objectID, err := primitive.ObjectIDFromHex(string(ID))
@@ -179,7 +440,7 @@ func (r *MongoRepository) Delete(ID entity.ID) error {
 		return fmt.Errorf("user.MongoRepository: failed to create object ID")
 	}
 
-	filter := bson.D{{"_id", objectID}}
+	filter := bson.D{{Key: "_id", Value: objectID}}
 	_, err = r.collection.DeleteOne(context.TODO(), filter)
 	if err != nil {
 		return fmt.Errorf("user.MongoRepository: failed to delete user with ID \"%s\" (%s)", ID, err)
@@ -0,0 +1,242 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// A Query describes which users to list when searching the user directory.
+// Users are listed in the order in which they were created.
+type Query struct {
+	// Smoking, Conversation and Music specify the exact value the users'
+	// preferences must have. A nil value means any value.
+	Smoking      *int
+	Conversation *int
+	Music        *int
+
+	// Gender specifies the gender of the users.
+	Gender string
+
+	// MinUserRating and MinDriverRating specify the minimum ratings the users
+	// must have. A nil value means any rating.
+	MinUserRating   *float64
+	MinDriverRating *float64
+
+	// SignUpPhase specifies the sign up phase the users must be at.
+	SignUpPhase string
+
+	// Limit specifies the maximum number of users to list.
+	//
+	// Zero means DefaultLimit.
+	Limit int
+
+	// After specifies the cursor of the page to list. It is the next cursor
+	// that was returned with the previous page.
+	After string
+}
+
+const (
+	// DefaultLimit represents the default number of users listed at once.
+	DefaultLimit = 20
+
+	// MaxLimit represents the maximum number of users listed at once.
+	MaxLimit = 100
+)
+
+// A Page contains users listed by a query, along with the cursor of the next
+// page, if there is one.
+type Page struct {
+	Users []*entity.User
+	Next  string
+}
+
+// ParseQuery creates a query from URL query values (smoking, conversation,
+// music, gender, minUserRating, minDriverRating, signUpPhase, limit and
+// after) and validates it.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		Gender:      values.Get("gender"),
+		SignUpPhase: values.Get("signUpPhase"),
+		After:       values.Get("after"),
+	}
+
+	optionalInts := []struct {
+		name  string
+		value **int
+	}{
+		{"smoking", &q.Smoking},
+		{"conversation", &q.Conversation},
+		{"music", &q.Music},
+	}
+	for _, optional := range optionalInts {
+		s := values.Get(optional.name)
+		if s == "" {
+			continue
+		}
+
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField(optional.name, fmt.Sprintf("user: %s \"%s\" is not a number", optional.name, s))}
+		}
+		*optional.value = &i
+	}
+
+	optionalFloats := []struct {
+		name  string
+		value **float64
+	}{
+		{"minUserRating", &q.MinUserRating},
+		{"minDriverRating", &q.MinDriverRating},
+	}
+	for _, optional := range optionalFloats {
+		s := values.Get(optional.name)
+		if s == "" {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField(optional.name, fmt.Sprintf("user: %s \"%s\" is not a number", optional.name, s))}
+		}
+		*optional.value = &f
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("user: limit \"%s\" is not a number", limit))}
+		}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// validate makes sure the query's values are within bounds and fills in the
+// default values.
+func (q *Query) validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("user: limit must be between 1 and %d", MaxLimit))}
+	}
+
+	for key, preference := range map[entity.PreferenceKey]*int{entity.PreferenceSmoking: q.Smoking, entity.PreferenceConversation: q.Conversation, entity.PreferenceMusic: q.Music} {
+		if preference == nil {
+			continue
+		}
+
+		if d, _ := entity.LookupPreference(key); !d.Allows(*preference) {
+			return InvalidQueryError{invalidQuery.NewForField(string(key), fmt.Sprintf("user: %s preference is not one of the values of the catalog", key))}
+		}
+	}
+
+	switch q.Gender {
+	case "", entity.GenderMale, entity.GenderFemale, entity.GenderOther:
+	default:
+		return InvalidQueryError{invalidQuery.NewForField("gender", fmt.Sprintf("user: gender must be %s, %s or %s", entity.GenderMale, entity.GenderFemale, entity.GenderOther))}
+	}
+
+	for name, rating := range map[string]*float64{"minUserRating": q.MinUserRating, "minDriverRating": q.MinDriverRating} {
+		if rating != nil && (*rating < entity.RatingMinimum || *rating > entity.RatingMaximum) {
+			return InvalidQueryError{invalidQuery.NewForField(name, fmt.Sprintf("user: %s must be between %d and %d", name, entity.RatingMinimum, entity.RatingMaximum))}
+		}
+	}
+
+	switch q.SignUpPhase {
+	case "", entity.SignUpPhasePersonalInfo, entity.SignUpPhasePreferences, entity.SignUpPhaseDone:
+	default:
+		return InvalidQueryError{invalidQuery.NewForField("signUpPhase", fmt.Sprintf("user: sign up phase must be %s, %s or %s", entity.SignUpPhasePersonalInfo, entity.SignUpPhasePreferences, entity.SignUpPhaseDone))}
+	}
+
+	if q.After != "" {
+		if _, err := primitive.ObjectIDFromHex(q.After); err != nil {
+			return InvalidQueryError{invalidQuery.NewForField("after", "user: malformed cursor")}
+		}
+	}
+
+	return nil
+}
+
+// matches returns whether a user satisfies the query's filters.
+func (q *Query) matches(u *entity.User) bool {
+	preferenceMatches := func(expected *int, actual func(p *entity.Preferences) int) bool {
+		return expected == nil || (u.Preferences != nil && actual(u.Preferences) == *expected)
+	}
+
+	if !preferenceMatches(q.Smoking, func(p *entity.Preferences) int { return p.Smoking }) ||
+		!preferenceMatches(q.Conversation, func(p *entity.Preferences) int { return p.Conversation }) ||
+		!preferenceMatches(q.Music, func(p *entity.Preferences) int { return p.Music }) {
+		return false
+	}
+
+	if q.Gender != "" && u.Gender != q.Gender {
+		return false
+	}
+
+	if q.MinUserRating != nil && (u.UserRating == nil || u.UserRating.Average < *q.MinUserRating) {
+		return false
+	}
+
+	if q.MinDriverRating != nil && (u.DriverRating == nil || u.DriverRating.Average < *q.MinDriverRating) {
+		return false
+	}
+
+	if q.SignUpPhase != "" && u.SignUpPhase != q.SignUpPhase {
+		return false
+	}
+
+	if q.After != "" && u.ID.Hex() <= q.After {
+		return false
+	}
+
+	return true
+}
+
+// newPage creates a page from the users that were found for a query. When more
+// users than the query's limit were found, the extra users are dropped and
+// the cursor of the next page is set to the last user's unique identifier.
+func newPage(q *Query, users []*entity.User) *Page {
+	if len(users) <= q.Limit {
+		return &Page{Users: users}
+	}
+
+	users = users[:q.Limit]
+
+	return &Page{
+		Users: users,
+		Next:  users[len(users)-1].ID.Hex(),
+	}
+}
+
+// searchableFields returns a copy of a user that only contains the fields that
+// are returned when searching the user directory, the same ones as the
+// projection used by the Mongo repository.
+func searchableFields(u *entity.User) *entity.User {
+	c := copyUser(u)
+
+	return &entity.User{
+		ID:           c.ID,
+		FirstName:    c.FirstName,
+		LastName:     c.LastName,
+		DateOfBirth:  c.DateOfBirth,
+		Photo:        c.Photo,
+		Description:  c.Description,
+		Preferences:  c.Preferences,
+		UserRating:   c.UserRating,
+		DriverRating: c.DriverRating,
+
+		VerifiedDriverUntil: c.VerifiedDriverUntil,
+	}
+}
@@ -6,10 +6,58 @@ import (
 
 // Repository is an interface representing the ability to perform CRUD
 // operations on users in a database.
+//
+// Lookups only find active users, unless the statuses of the users to find
+// are given. Searches only ever list active users. When no user is found, the
+// error of a lookup wraps mongo.ErrNoDocuments.
+//
+// Update never modifies the ratings of a user, which are only modified by
+// UpdateRatings and AddRating, so that a review submitted while the user is
+// updated is not lost. AddRating counts a review of the given number of stars
+// in the rating summary of a role in a single update, so that concurrent
+// reviews are all counted, and returns the summary as modified.
 type Repository interface {
-	FindByID(ID entity.ID) (*entity.User, error)
-	FindBySubID(subID string) (*entity.User, error)
+	FindByID(ID entity.ID, statuses ...string) (*entity.User, error)
+	FindBySubID(subID string, statuses ...string) (*entity.User, error)
+	Search(q *Query) (*Page, error)
 	Create(user *entity.User) (entity.ID, error)
 	Update(user *entity.User) error
+	UpdateRatings(ID entity.ID, userRating *entity.RatingSummary, driverRating *entity.RatingSummary) error
+	AddRating(ID entity.ID, role string, stars int) (*entity.RatingSummary, error)
 	Delete(ID entity.ID) error
 }
+
+// AccountStatuses contains the statuses of the users whose account was not
+// deleted, for the lookups that must also find paused accounts.
+var AccountStatuses = []string{entity.UserStatusActive, entity.UserStatusDeactivated, entity.UserStatusSuspended}
+
+// ratingField returns the field of the rating summary of a review role.
+func ratingField(role string) string {
+	if role == entity.ReviewRoleDriver {
+		return "driverRating"
+	}
+
+	return "userRating"
+}
+
+// lookupStatuses returns the statuses of the users that a lookup finds, which
+// default to the active status.
+func lookupStatuses(statuses []string) []string {
+	if len(statuses) == 0 {
+		return []string{entity.UserStatusActive}
+	}
+
+	return statuses
+}
+
+// hasStatus returns whether the user has one of the statuses that a lookup
+// finds.
+func hasStatus(u *entity.User, statuses []string) bool {
+	for _, status := range lookupStatuses(statuses) {
+		if u.CurrentStatus() == status {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,64 +1,93 @@
 package user
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/mongo"
 )
 
 // UseCase is an interface representing the ability to handle the business
 // logic that involves users.
 type UseCase interface {
 	Register(u *entity.User) (*entity.User, error)
-	Update(modifiedUser *entity.User) error
-	FindByID(ID entity.ID) (*entity.User, error)
-	FindBySubID(subID string) (*entity.User, error)
+	Update(modifiedUser *entity.User, caller *Caller) error
+	FindByID(ID entity.ID, statuses ...string) (*entity.User, error)
+	FindBySubID(subID string, statuses ...string) (*entity.User, error)
+	Search(q *Query) (*Page, error)
+	UpdateRatings(ID entity.ID, userRating *entity.RatingSummary, driverRating *entity.RatingSummary) error
+	AddRating(ID entity.ID, role string, stars int) error
+	VerifyPhoneNumber(ID entity.ID, phoneNumber string) error
+	UpdateEmail(ID entity.ID, email string) error
+	SyncIdentityEmail(ID entity.ID, email string, verified bool) (*entity.User, error)
+	UpdateVerifiedDriverUntil(ID entity.ID, until time.Time) error
+	AdvanceSignUp(ID entity.ID, caller *Caller) (*entity.User, error)
+	Deactivate(ID entity.ID, caller *Caller) (*entity.User, error)
+	Reactivate(ID entity.ID, caller *Caller) (*entity.User, error)
 	Delete(ID entity.ID) error
 }
 
-const (
-	// RatingMinimum represents the minimum rating than a user could be have.
-	RatingMinimum = 0
+// A Caller represents the authenticated user on behalf of whom an operation
+// is performed.
+type Caller struct {
+	// SubID is the caller's subscription ID.
+	SubID string
 
-	// RatingMaximum represents the maximum rating than a user could be have.
-	RatingMaximum = 5
-)
+	// Privileged specifies whether the caller is allowed to act on other
+	// users and to modify the fields that are managed by the system.
+	Privileged bool
+}
 
 // A Service handles the business logic related to users.
 type Service struct {
 	repo Repository
+	now  func() time.Time
 }
 
 // NewService creates a user service to handle business logic and manipulate
 // users through a repository.
 func NewService(repo Repository) *Service {
-	return &Service{repo}
+	return &Service{repo, time.Now}
 }
 
 // Register validates the user's personal informartion, makes it move on to the
-// next sign up phase, and persists it in the repository.
+// next sign up phase, and persists it in the repository as an active user. The
+// user's email is expected to come from the identity provider, which is
+// remembered so that the user can later be synced with it (see
+// SyncIdentityEmail). A user whose account is deactivated or suspended cannot
+// be registered again.
 func (s *Service) Register(u *entity.User) (*entity.User, error) {
 	if u == nil {
 		return nil, fmt.Errorf("user.Service: user is nil")
 	}
 
-	_, err := s.FindBySubID(u.SubID)
+	_, err := s.FindBySubID(u.SubID, AccountStatuses...)
 	if err == nil {
-		return nil, AlreadyExistsError{fmt.Sprintf("user.Service: user already exists with ID \"%s\"", u.SubID)}
+		return nil, AlreadyExistsError{alreadyExists.New(fmt.Sprintf("user.Service: user already exists with ID \"%s\"", u.SubID))}
 	}
 
-	u.SignUpPhase = entity.SignUpPhasePreferences
+	u.SignUpPhase = entity.SignUpPhasePersonalInfo
+	u.Status = entity.UserStatusActive
+	u.StatusChangedAt = s.now()
+	u.PhoneVerified = false
+	u.IdentityEmail = u.Email
 
-	u.UserRating = new(int)
-	*u.UserRating = 0
-	u.DriverRating = new(int)
-	*u.DriverRating = 0
+	u.UserRating = &entity.RatingSummary{}
+	u.DriverRating = &entity.RatingSummary{}
 
+	u.Normalize()
 	err = u.Validate()
 	if err != nil {
 		return nil, err
 	}
 
+	err = moveSignUpPhase(u, entity.SignUpPhasePreferences)
+	if err != nil {
+		return nil, err
+	}
+
 	u.ID, err = s.repo.Create(u)
 	if err != nil {
 		return nil, err
@@ -68,40 +97,127 @@ func (s *Service) Register(u *entity.User) (*entity.User, error) {
 }
 
 // FindByID retrieves the user with the given ID in the repository, if it
-// exists.
-func (s *Service) FindByID(ID entity.ID) (*entity.User, error) {
-	u, err := s.repo.FindByID(ID)
+// exists and has one of the given statuses (active by default).
+func (s *Service) FindByID(ID entity.ID, statuses ...string) (*entity.User, error) {
+	u, err := s.repo.FindByID(ID, statuses...)
 	if err != nil {
-		return nil, NotFoundError{err.Error()}
+		return nil, lookupError(err)
 	}
+	u.RefreshVerifiedDriver(s.now())
 
 	return u, nil
 }
 
 // FindBySubID retrieves the user with the given subscription ID in the
-// repository, if it exists.
-func (s *Service) FindBySubID(subID string) (*entity.User, error) {
-	u, err := s.repo.FindBySubID(subID)
+// repository, if it exists and has one of the given statuses (active by
+// default).
+func (s *Service) FindBySubID(subID string, statuses ...string) (*entity.User, error) {
+	u, err := s.repo.FindBySubID(subID, statuses...)
 	if err != nil {
-		return nil, NotFoundError{err.Error()}
+		return nil, lookupError(err)
 	}
+	u.RefreshVerifiedDriver(s.now())
 
 	return u, nil
 }
 
+// Search retrieves a page of the users in the repository that satisfy the
+// query's filters. Only the fields that are part of a user's public profile
+// are returned. A nil query lists the first users.
+func (s *Service) Search(q *Query) (*Page, error) {
+	if q == nil {
+		q = &Query{}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.repo.Search(q)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, u := range p.Users {
+		u.RefreshVerifiedDriver(now)
+	}
+
+	return p, nil
+}
+
 // Update validates that the user contains all the required personal
 // information, that all values are correct and well formatted, and persists
 // the modified user in the repository.
-func (s *Service) Update(modifiedUser *entity.User) error {
+//
+// Unless the caller is privileged, it can only modify its own user and cannot
+// modify the sign up phase. Moving from the preferences sign up phase to the
+// next one is done automatically when the preferences are provided. Even
+// privileged callers can only move the user on to the next sign up phase, once
+// it satisfies the phase's requirements (see AdvanceSignUp). The
+// ratings are derived from the reviews the user received and cannot be
+// modified by anyone. The phone number is only marked as verified through
+// VerifyPhoneNumber, and stops being verified when it is modified.
+func (s *Service) Update(modifiedUser *entity.User, caller *Caller) error {
 	if modifiedUser == nil {
 		return fmt.Errorf("user.Service: modified user is nil")
 	}
 
+	if caller == nil {
+		return fmt.Errorf("user.Service: caller is nil")
+	}
+
 	u, err := s.repo.FindByID(entity.ID(modifiedUser.ID))
 	if err != nil {
-		return NotFoundError{err.Error()}
+		return lookupError(err)
 	}
 
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return ForbiddenError{forbidden.New(fmt.Sprintf("user.Service: cannot modify another user \"%s\"", u.ID))}
+	}
+
+	err = checkSystemManagedFieldsUnchanged(u, modifiedUser, caller)
+	if err != nil {
+		return err
+	}
+
+	phoneNumber := u.PhoneNumber
+	applySelfEditableFields(u, modifiedUser)
+
+	phase := u.SignUpPhase
+	if caller.Privileged && modifiedUser.SignUpPhase != "" {
+		phase = modifiedUser.SignUpPhase
+	} else if modifiedUser.Preferences != nil && u.SignUpPhase == entity.SignUpPhasePreferences {
+		phase = entity.SignUpPhaseDone
+	}
+
+	u.Normalize()
+	if u.PhoneNumber != phoneNumber {
+		u.PhoneVerified = false
+	}
+
+	err = moveSignUpPhase(u, phase)
+	if err != nil {
+		return err
+	}
+
+	err = u.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Update(u)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applySelfEditableFields modifies the fields that users are allowed to
+// modify on their own user.
+func applySelfEditableFields(u *entity.User, modifiedUser *entity.User) {
 	if modifiedUser.FirstName != "" {
 		u.FirstName = modifiedUser.FirstName
 	}
@@ -138,20 +254,64 @@ func (s *Service) Update(modifiedUser *entity.User) error {
 		u.Preferences.Smoking = modifiedUser.Preferences.Smoking
 		u.Preferences.Conversation = modifiedUser.Preferences.Conversation
 		u.Preferences.Music = modifiedUser.Preferences.Music
+		u.Preferences.Others = modifiedUser.Preferences.Others
+	}
+}
+
+// checkSystemManagedFieldsUnchanged makes sure that the modified user does
+// not try to change a field that the caller is not allowed to modify. Fields
+// that are sent back as is are tolerated.
+func checkSystemManagedFieldsUnchanged(u *entity.User, modifiedUser *entity.User, caller *Caller) error {
+	if !caller.Privileged && modifiedUser.SignUpPhase != "" && modifiedUser.SignUpPhase != u.SignUpPhase {
+		return ForbiddenError{forbidden.New("user.Service: sign up phase cannot be modified")}
+	}
+
+	if modifiedUser.Status != "" && modifiedUser.Status != u.CurrentStatus() {
+		return ForbiddenError{forbidden.New("user.Service: status cannot be modified")}
 	}
 
-	if modifiedUser.SignUpPhase != "" {
-		u.SignUpPhase = modifiedUser.SignUpPhase
+	if ratingModified(u.UserRating, modifiedUser.UserRating) {
+		return ForbiddenError{forbidden.New("user.Service: user rating cannot be modified")}
+	}
+
+	if ratingModified(u.DriverRating, modifiedUser.DriverRating) {
+		return ForbiddenError{forbidden.New("user.Service: driver rating cannot be modified")}
+	}
+
+	return nil
+}
+
+// ratingModified reports whether the modified rating summary differs from the
+// current one. The legacy reviews are ignored, since they are never sent to
+// the clients.
+func ratingModified(current *entity.RatingSummary, modified *entity.RatingSummary) bool {
+	if modified == nil {
+		return false
+	}
+
+	if current == nil {
+		return true
+	}
+
+	return modified.Average != current.Average || modified.Count != current.Count || modified.Histogram != current.Histogram
+}
+
+// UpdateRatings replaces the ratings of the user with the given ID by the ones
+// computed from the reviews it received. A nil rating is left unchanged. Only
+// the ratings are written, so that it can run concurrently with updates of
+// the user's other fields.
+func (s *Service) UpdateRatings(ID entity.ID, userRating *entity.RatingSummary, driverRating *entity.RatingSummary) error {
+	u, err := s.repo.FindByID(ID)
+	if err != nil {
+		return lookupError(err)
 	}
 
-	if modifiedUser.UserRating != nil &&
-		(*modifiedUser.UserRating >= RatingMinimum && *modifiedUser.UserRating <= RatingMaximum) {
-		u.UserRating = modifiedUser.UserRating
+	if userRating != nil {
+		u.UserRating = userRating
 	}
 
-	if modifiedUser.DriverRating != nil &&
-		(*modifiedUser.DriverRating >= RatingMinimum && *modifiedUser.DriverRating <= RatingMaximum) {
-		u.DriverRating = modifiedUser.DriverRating
+	if driverRating != nil {
+		u.DriverRating = driverRating
 	}
 
 	err = u.Validate()
@@ -159,20 +319,250 @@ func (s *Service) Update(modifiedUser *entity.User) error {
 		return err
 	}
 
-	err = s.repo.Update(u)
+	return lookupError(s.repo.UpdateRatings(ID, userRating, driverRating))
+}
+
+// AddRating counts a review of the given number of stars that the user with
+// the given ID received for a role in the rating summary of that role. The
+// review is counted in a single update of the summary, so that concurrent
+// reviews are all counted.
+func (s *Service) AddRating(ID entity.ID, role string, stars int) error {
+	if role != entity.ReviewRoleRider && role != entity.ReviewRoleDriver {
+		return fmt.Errorf("user.Service: unknown review role \"%s\"", role)
+	}
+
+	if stars < entity.StarsMinimum || stars > entity.StarsMaximum {
+		return fmt.Errorf("user.Service: stars (%d) are not between (%d) and (%d)", stars, entity.StarsMinimum, entity.StarsMaximum)
+	}
+
+	_, err := s.repo.AddRating(ID, role, stars)
 	if err != nil {
-		return err
+		return lookupError(err)
 	}
 
 	return nil
 }
 
-// Delete erases the user from the repository.
+// VerifyPhoneNumber marks the phone number of the user with the given ID as
+// verified. It fails if the user's phone number was modified since it was
+// verified.
+func (s *Service) VerifyPhoneNumber(ID entity.ID, phoneNumber string) error {
+	u, err := s.repo.FindByID(ID)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	if u.PhoneNumber != phoneNumber {
+		return ForbiddenError{forbidden.New(fmt.Sprintf("user.Service: phone number of user \"%s\" was modified during its verification", ID))}
+	}
+
+	u.PhoneVerified = true
+
+	return s.repo.Update(u)
+}
+
+// UpdateEmail replaces the email of the user with the given ID by an email
+// that the user proved it owns.
+func (s *Service) UpdateEmail(ID entity.ID, email string) error {
+	u, err := s.repo.FindByID(ID)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	u.Email = email
+	u.EmailVerified = true
+
+	err = u.Validate()
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Update(u)
+}
+
+// SyncIdentityEmail makes sure the email of the user with the given ID is not
+// stale compared to the one known by the identity provider, and returns the
+// synced user, even if its account is deactivated or suspended. When the user
+// modified its email with the identity provider, the user's email is replaced
+// by the new one. Otherwise, the email that the user may have modified through
+// UpdateEmail is kept, and is only marked as verified once the identity
+// provider verified it.
+func (s *Service) SyncIdentityEmail(ID entity.ID, email string, verified bool) (*entity.User, error) {
+	u, err := s.repo.FindByID(ID, AccountStatuses...)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	if !entity.IsEmail(email) {
+		return u, nil
+	}
+
+	switch {
+	case email != u.IdentityEmail:
+		u.IdentityEmail = email
+		u.Email = email
+		u.EmailVerified = verified
+	case email == u.Email && verified && !u.EmailVerified:
+		u.EmailVerified = true
+	default:
+		u.RefreshVerifiedDriver(s.now())
+		return u, nil
+	}
+
+	err = u.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Update(u)
+	if err != nil {
+		return nil, err
+	}
+	u.RefreshVerifiedDriver(s.now())
+
+	return u, nil
+}
+
+// UpdateVerifiedDriverUntil replaces the time until which the user with the
+// given ID is a verified driver, which is derived from the driver documents
+// that were approved. A zero time means that the user is not a verified
+// driver.
+func (s *Service) UpdateVerifiedDriverUntil(ID entity.ID, until time.Time) error {
+	u, err := s.repo.FindByID(ID)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	u.VerifiedDriverUntil = until
+
+	return s.repo.Update(u)
+}
+
+// AdvanceSignUp moves the user with the given ID on to the next sign up phase,
+// as long as it satisfies the phase's requirements, and returns the advanced
+// user. Unless the caller is privileged, it can only advance its own sign up.
+func (s *Service) AdvanceSignUp(ID entity.ID, caller *Caller) (*entity.User, error) {
+	if caller == nil {
+		return nil, fmt.Errorf("user.Service: caller is nil")
+	}
+
+	u, err := s.repo.FindByID(ID)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("user.Service: cannot advance the sign up of another user \"%s\"", u.ID))}
+	}
+
+	next := nextSignUpPhase(u.SignUpPhase)
+	if next == "" {
+		return nil, InvalidSignUpTransitionError{invalidSignUpTransition.New(fmt.Sprintf("user.Service: sign up of user \"%s\" is already complete", u.ID))}
+	}
+
+	err = moveSignUpPhase(u, next)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Update(u)
+	if err != nil {
+		return nil, err
+	}
+	u.RefreshVerifiedDriver(s.now())
+
+	return u, nil
+}
+
+// Deactivate pauses the account of the user with the given ID and returns the
+// deactivated user. A deactivated user is no longer found by lookups nor listed
+// by searches until it is reactivated. Unless the caller is privileged, it can
+// only deactivate its own account.
+func (s *Service) Deactivate(ID entity.ID, caller *Caller) (*entity.User, error) {
+	return s.changeStatus(ID, entity.UserStatusDeactivated, caller)
+}
+
+// Reactivate reactivates the deactivated or suspended account of the user with
+// the given ID and returns the reactivated user. Unless the caller is
+// privileged, it can only reactivate its own account, and only if it was not
+// suspended.
+func (s *Service) Reactivate(ID entity.ID, caller *Caller) (*entity.User, error) {
+	return s.changeStatus(ID, entity.UserStatusActive, caller)
+}
+
+// changeStatus moves the account of the user with the given ID on to the given
+// status on behalf of the caller.
+func (s *Service) changeStatus(ID entity.ID, status string, caller *Caller) (*entity.User, error) {
+	if caller == nil {
+		return nil, fmt.Errorf("user.Service: caller is nil")
+	}
+
+	u, err := s.repo.FindByID(ID, AccountStatuses...)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("user.Service: cannot change the status of another user \"%s\"", u.ID))}
+	}
+
+	if !caller.Privileged && u.CurrentStatus() == entity.UserStatusSuspended {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("user.Service: account of user \"%s\" is suspended", u.ID))}
+	}
+
+	err = moveStatus(u, status, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Update(u)
+	if err != nil {
+		return nil, err
+	}
+	u.RefreshVerifiedDriver(s.now())
+
+	return u, nil
+}
+
+// Delete deletes the account of the user with the given ID. The user is kept
+// as deleted, so that the reviews it wrote and received still refer to an
+// existing user, but its personal information is erased and it is no longer
+// found by lookups.
 func (s *Service) Delete(ID entity.ID) error {
-	err := s.repo.Delete(ID)
+	u, err := s.repo.FindByID(ID, AccountStatuses...)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	err = moveStatus(u, entity.UserStatusDeleted, s.now())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.repo.Update(erasePersonalInformation(u))
+}
+
+// lookupError returns the error to report when the repository fails to find
+// a user, which is a not found error when the user does not exist.
+func lookupError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return NotFoundError{notFound.New(err.Error())}
+	}
+
+	return err
+}
+
+// erasePersonalInformation erases everything that identifies the user, only
+// keeping its subscription ID, its status and its ratings, which are derived
+// from the reviews of other users.
+func erasePersonalInformation(u *entity.User) *entity.User {
+	return &entity.User{
+		ID:              u.ID,
+		SubID:           u.SubID,
+		SignUpPhase:     u.SignUpPhase,
+		UserRating:      u.UserRating,
+		DriverRating:    u.DriverRating,
+		Status:          u.Status,
+		StatusChangedAt: u.StatusChangedAt,
+	}
 }
@@ -0,0 +1,812 @@
+package user
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+func newTestUser(subID string) *entity.User {
+	return &entity.User{
+		SubID:       subID,
+		Email:       "harold@hide-the-pain.meme",
+		FirstName:   "Harold",
+		LastName:    "The Great",
+		DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+		Gender:      entity.GenderMale,
+	}
+}
+
+func TestServiceRegister(t *testing.T) {
+	t.Run("Should generate an ID and move on to the preferences phase", func(t *testing.T) {
+		s := NewService(NewMemoryRepository())
+
+		u, err := s.Register(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID.IsZero() {
+			t.Error("expected an ID to be generated")
+		}
+
+		if u.SignUpPhase != entity.SignUpPhasePreferences {
+			t.Errorf("expected sign up phase %s, got %s", entity.SignUpPhasePreferences, u.SignUpPhase)
+		}
+
+		if u.Status != entity.UserStatusActive {
+			t.Errorf("expected status %s, got %s", entity.UserStatusActive, u.Status)
+		}
+	})
+
+	t.Run("Should fail when a user already exists with the same subscription ID", func(t *testing.T) {
+		s := NewService(NewMemoryRepository())
+
+		_, err := s.Register(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.Register(newTestUser("harold|1"))
+		if _, ok := err.(AlreadyExistsError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when the user is not valid", func(t *testing.T) {
+		s := NewService(NewMemoryRepository())
+
+		u := newTestUser("harold|1")
+		u.FirstName = ""
+
+		if _, err := s.Register(u); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceFind(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should find user by ID", func(t *testing.T) {
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.SubID != registered.SubID {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should find user by subscription ID", func(t *testing.T) {
+		u, err := s.FindBySubID(registered.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID != registered.ID {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail with not found error when ID is unknown", func(t *testing.T) {
+		_, err := s.FindByID(entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3"))
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail with not found error when ID is malformed", func(t *testing.T) {
+		_, err := s.FindByID(entity.NewIDFromHex("harold"))
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceUpdate(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should only modify the fields that are set", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, Description: "So much pain."}, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Description != "So much pain." {
+			t.Errorf("expected description to be modified, got %q", u.Description)
+		}
+
+		if u.FirstName != registered.FirstName {
+			t.Errorf("expected first name to be unchanged, got %q", u.FirstName)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when modifying another user", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, Description: "Not my pain."}, &Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail with forbidden error when modifying a rating", func(t *testing.T) {
+		rating := entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 0, 1})
+		err := s.Update(&entity.User{ID: registered.ID, DriverRating: rating}, &Caller{SubID: registered.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail with forbidden error when modifying the sign up phase", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, SignUpPhase: entity.SignUpPhaseDone}, &Caller{SubID: registered.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should tolerate unchanged system managed fields", func(t *testing.T) {
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.Update(u, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should tolerate unchanged ratings without their legacy reviews", func(t *testing.T) {
+		err := s.UpdateRatings(registered.ID, nil, entity.NewLegacyRatingSummary(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rating := entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 1, 0})
+		err = s.Update(&entity.User{ID: registered.ID, DriverRating: rating}, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when a privileged caller modifies a rating", func(t *testing.T) {
+		rating := entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 0, 1})
+		err := s.Update(&entity.User{ID: registered.ID, DriverRating: rating}, &Caller{SubID: "admin|1", Privileged: true})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail with invalid transition error when going back to a previous sign up phase", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, SignUpPhase: entity.SignUpPhasePersonalInfo}, &Caller{SubID: "admin|1", Privileged: true})
+		if _, ok := err.(InvalidSignUpTransitionError); !ok {
+			t.Errorf("expected InvalidSignUpTransitionError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail with sign up incomplete error when completing sign up without preferences", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, SignUpPhase: entity.SignUpPhaseDone}, &Caller{SubID: "admin|1", Privileged: true})
+		if e, ok := err.(SignUpIncompleteError); !ok || e.Field() != "preferences" {
+			t.Errorf("expected SignUpIncompleteError on preferences, got %v", err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.SignUpPhase != entity.SignUpPhasePreferences {
+			t.Errorf("expected sign up phase %s, got %s", entity.SignUpPhasePreferences, u.SignUpPhase)
+		}
+	})
+
+	t.Run("Should complete sign up when preferences are provided", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: registered.ID, Preferences: &entity.Preferences{}}, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.SignUpPhase != entity.SignUpPhaseDone {
+			t.Errorf("expected sign up phase %s, got %s", entity.SignUpPhaseDone, u.SignUpPhase)
+		}
+	})
+
+	t.Run("Should fail with not found error when user does not exist", func(t *testing.T) {
+		err := s.Update(&entity.User{ID: entity.NewIDFromHex("5c6d9a0b4f0e8a0001a1b2c3")}, &Caller{SubID: registered.SubID})
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceAdvanceSignUp(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should fail with forbidden error when advancing another user", func(t *testing.T) {
+		_, err := s.AdvanceSignUp(registered.ID, &Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail with sign up incomplete error when preferences are missing", func(t *testing.T) {
+		_, err := s.AdvanceSignUp(registered.ID, &Caller{SubID: registered.SubID})
+		if _, ok := err.(SignUpIncompleteError); !ok {
+			t.Errorf("expected SignUpIncompleteError, got %v", err)
+		}
+	})
+
+	t.Run("Should move on to the next phase once its requirements are satisfied", func(t *testing.T) {
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.Preferences = &entity.Preferences{}
+		err = s.repo.Update(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err = s.AdvanceSignUp(registered.ID, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.SignUpPhase != entity.SignUpPhaseDone {
+			t.Errorf("expected sign up phase %s, got %s", entity.SignUpPhaseDone, u.SignUpPhase)
+		}
+	})
+
+	t.Run("Should fail with invalid transition error when sign up is complete", func(t *testing.T) {
+		_, err := s.AdvanceSignUp(registered.ID, &Caller{SubID: "admin|1", Privileged: true})
+		if _, ok := err.(InvalidSignUpTransitionError); !ok {
+			t.Errorf("expected InvalidSignUpTransitionError, got %v", err)
+		}
+	})
+}
+
+func TestServiceUpdateRatings(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should only modify the ratings that are set", func(t *testing.T) {
+		rating := entity.NewRatingSummary([entity.StarsMaximum]int{0, 0, 0, 1, 0})
+		err := s.UpdateRatings(registered.ID, nil, rating)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *u.DriverRating != *rating || u.UserRating.Count != 0 {
+			t.Errorf("unexpected ratings %+v and %+v", u.UserRating, u.DriverRating)
+		}
+	})
+
+	t.Run("Should fail when rating is not valid", func(t *testing.T) {
+		rating := &entity.RatingSummary{Average: entity.RatingMaximum + 1, Count: 1}
+		err := s.UpdateRatings(registered.ID, rating, nil)
+		if _, ok := err.(entity.ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceAddRating(t *testing.T) {
+	t.Run("Should count concurrent reviews", func(t *testing.T) {
+		s := NewService(NewMemoryRepository())
+
+		registered, err := s.Register(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := s.AddRating(registered.ID, entity.ReviewRoleDriver, 4); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.DriverRating.Count != 10 || u.DriverRating.Average != 4 || u.UserRating.Count != 0 {
+			t.Errorf("unexpected ratings %+v and %+v", u.UserRating, u.DriverRating)
+		}
+	})
+
+	t.Run("Should not be overwritten by an update of the user", func(t *testing.T) {
+		repo := NewMemoryRepository()
+		s := NewService(repo)
+
+		registered, err := s.Register(newTestUser("harold|1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stale, err := repo.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.AddRating(registered.ID, entity.ReviewRoleRider, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stale.Description = "Hide the pain"
+		err = repo.Update(stale)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.UserRating.Count != 1 || u.Description != "Hide the pain" {
+			t.Errorf("expected both the review and the update to be kept, got %+v and %q", u.UserRating, u.Description)
+		}
+	})
+}
+
+func TestServiceVerifyPhoneNumber(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	u := newTestUser("harold|1")
+	u.PhoneNumber = "450.123.4567"
+	registered, err := s.Register(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if registered.PhoneNumber != "+14501234567" {
+		t.Fatalf("expected phone number to be normalized, got %q", registered.PhoneNumber)
+	}
+
+	t.Run("Should fail when phone number was modified", func(t *testing.T) {
+		err := s.VerifyPhoneNumber(registered.ID, "+33123456789")
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should mark phone number as verified until it is modified", func(t *testing.T) {
+		err := s.VerifyPhoneNumber(registered.ID, registered.PhoneNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.Update(&entity.User{ID: registered.ID, PhoneNumber: "(450) 123-4567"}, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !u.PhoneVerified {
+			t.Error("expected phone number to still be verified when it is the same")
+		}
+
+		err = s.Update(&entity.User{ID: registered.ID, PhoneNumber: "+33 1 23 45 67 89"}, &Caller{SubID: registered.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err = s.FindByID(registered.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.PhoneVerified || u.PhoneNumber != "+33123456789" {
+			t.Errorf("expected phone number to be modified and not verified, got %q (verified: %t)", u.PhoneNumber, u.PhoneVerified)
+		}
+	})
+}
+
+func TestServiceSyncIdentityEmail(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sync := func(t *testing.T, email string, verified bool) *entity.User {
+		u, err := s.SyncIdentityEmail(registered.ID, email, verified)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	t.Run("Should mark email as verified once the identity provider verified it", func(t *testing.T) {
+		u := sync(t, registered.Email, true)
+		if u.Email != registered.Email || !u.EmailVerified {
+			t.Errorf("unexpected email %q (verified: %t)", u.Email, u.EmailVerified)
+		}
+	})
+
+	t.Run("Should keep an email modified through the service", func(t *testing.T) {
+		err := s.UpdateEmail(registered.ID, "harold@ecovo.ca")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u := sync(t, registered.Email, true)
+		if u.Email != "harold@ecovo.ca" {
+			t.Errorf("expected email to be kept, got %q", u.Email)
+		}
+	})
+
+	t.Run("Should replace the email when it is modified with the identity provider", func(t *testing.T) {
+		u := sync(t, "harold@hide-the-pain.gg", false)
+		if u.Email != "harold@hide-the-pain.gg" || u.EmailVerified {
+			t.Errorf("unexpected email %q (verified: %t)", u.Email, u.EmailVerified)
+		}
+	})
+
+	t.Run("Should ignore malformed emails", func(t *testing.T) {
+		u := sync(t, "harold", true)
+		if u.Email != "harold@hide-the-pain.gg" {
+			t.Errorf("expected email to be kept, got %q", u.Email)
+		}
+	})
+}
+
+func TestServiceUpdateVerifiedDriverUntil(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+	now := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.UpdateVerifiedDriverUntil(registered.ID, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := s.FindByID(registered.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !u.VerifiedDriver {
+		t.Error("expected user to be a verified driver")
+	}
+
+	now = now.Add(time.Hour)
+	p, err := s.Search(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Users[0].VerifiedDriver {
+		t.Error("expected user to stop being a verified driver once its documents expired")
+	}
+}
+
+func TestServiceDelete(t *testing.T) {
+	repo := NewMemoryRepository()
+	s := NewService(repo)
+
+	registered, err := s.Register(newTestUser("harold|1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Delete(registered.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.FindByID(registered.ID, AccountStatuses...); err == nil {
+		t.Error("expected user to be deleted")
+	}
+
+	deleted, err := repo.FindByID(registered.ID, entity.UserStatusDeleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted.FirstName != "" || deleted.Email != "" || !deleted.DateOfBirth.IsZero() {
+		t.Errorf("expected personal information to be erased, got %+v", deleted)
+	}
+
+	if _, ok := s.Delete(registered.ID).(NotFoundError); !ok {
+		t.Error("expected a deleted user to not be found again")
+	}
+
+	if _, err := s.Register(newTestUser("harold|1")); err != nil {
+		t.Errorf("expected a deleted user to be registered again, got %v", err)
+	}
+}
+
+// failingRepository is a repository whose lookups always fail.
+type failingRepository struct {
+	Repository
+	err error
+}
+
+func (r *failingRepository) FindByID(ID entity.ID, statuses ...string) (*entity.User, error) {
+	return nil, r.err
+}
+
+func TestServiceLookupError(t *testing.T) {
+	repoErr := errors.New("user.MongoRepository: server selection timeout")
+	s := NewService(&failingRepository{NewMemoryRepository(), repoErr})
+
+	t.Run("Should not report repository failures as not found when deleting", func(t *testing.T) {
+		if err := s.Delete(entity.NewIDFromHex("5c8a1d5b0190b214360dc031")); err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+	})
+
+	t.Run("Should not report repository failures as not found when deactivating", func(t *testing.T) {
+		if _, err := s.Deactivate(entity.NewIDFromHex("5c8a1d5b0190b214360dc031"), &Caller{Privileged: true}); err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+	})
+}
+
+func TestServiceDeactivate(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+
+	register := func(t *testing.T) *entity.User {
+		u, err := s.Register(newTestUser("harold|" + t.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	t.Run("Should hide deactivated users until they are reactivated", func(t *testing.T) {
+		u := register(t)
+		caller := &Caller{SubID: u.SubID}
+
+		deactivated, err := s.Deactivate(u.ID, caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if deactivated.Status != entity.UserStatusDeactivated || deactivated.StatusChangedAt.IsZero() {
+			t.Errorf("expected status %s with a timestamp, got %s at %s", entity.UserStatusDeactivated, deactivated.Status, deactivated.StatusChangedAt)
+		}
+
+		if _, err := s.FindByID(u.ID); err == nil {
+			t.Error("expected deactivated user to not be found by default")
+		}
+
+		if _, err := s.FindBySubID(u.SubID, AccountStatuses...); err != nil {
+			t.Errorf("expected deactivated user to be found with its status, got %v", err)
+		}
+
+		p, err := s.Search(&Query{Limit: MaxLimit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, found := range p.Users {
+			if found.ID == u.ID {
+				t.Error("expected deactivated user to not be listed")
+			}
+		}
+
+		if _, err := s.Register(newTestUser(u.SubID)); err == nil {
+			t.Error("expected deactivated user to not be registered again")
+		}
+
+		reactivated, err := s.Reactivate(u.ID, caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reactivated.Status != entity.UserStatusActive {
+			t.Errorf("expected status %s, got %s", entity.UserStatusActive, reactivated.Status)
+		}
+
+		if _, err := s.FindByID(u.ID); err != nil {
+			t.Errorf("expected reactivated user to be found, got %v", err)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when deactivating another user", func(t *testing.T) {
+		u := register(t)
+
+		_, err := s.Deactivate(u.ID, &Caller{SubID: "maurice|1"})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail with invalid transition error when already deactivated", func(t *testing.T) {
+		u := register(t)
+		caller := &Caller{SubID: u.SubID}
+
+		_, err := s.Deactivate(u.ID, caller)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.Deactivate(u.ID, caller)
+		if _, ok := err.(InvalidStatusTransitionError); !ok {
+			t.Errorf("expected InvalidStatusTransitionError, got %v", err)
+		}
+	})
+
+	t.Run("Should only let privileged callers reactivate a suspended user", func(t *testing.T) {
+		u := register(t)
+
+		err := moveStatus(u, entity.UserStatusSuspended, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.repo.Update(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.Reactivate(u.ID, &Caller{SubID: u.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+
+		_, err = s.Reactivate(u.ID, &Caller{SubID: "admin|1", Privileged: true})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Should fail with forbidden error when modifying the status", func(t *testing.T) {
+		u := register(t)
+
+		err := s.Update(&entity.User{ID: u.ID, Status: entity.UserStatusSuspended}, &Caller{SubID: u.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+}
+
+func TestServiceSearch(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+	admin := &Caller{SubID: "admin|1", Privileged: true}
+
+	users := []struct {
+		subID        string
+		gender       string
+		smoking      int
+		driverRating int
+	}{
+		{"harold|1", entity.GenderMale, entity.PreferenceNever, 5},
+		{"harold|2", entity.GenderFemale, entity.PreferenceNever, 3},
+		{"harold|3", entity.GenderMale, entity.PreferenceRegularly, 4},
+		{"harold|4", entity.GenderMale, entity.PreferenceNever, 4},
+	}
+	for _, test := range users {
+		u := newTestUser(test.subID)
+		u.Gender = test.gender
+
+		registered, err := s.Register(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.Update(&entity.User{
+			ID:          registered.ID,
+			Preferences: &entity.Preferences{Smoking: test.smoking},
+		}, admin)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var histogram [entity.StarsMaximum]int
+		histogram[test.driverRating-entity.StarsMinimum] = 1
+		err = s.UpdateRatings(registered.ID, nil, entity.NewRatingSummary(histogram))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	never, minRating := entity.PreferenceNever, 4.0
+	q := &Query{Gender: entity.GenderMale, Smoking: &never, MinDriverRating: &minRating, Limit: 1}
+
+	t.Run("Should filter users and paginate", func(t *testing.T) {
+		var found []*entity.User
+		for {
+			p, err := s.Search(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found = append(found, p.Users...)
+
+			if p.Next == "" {
+				break
+			}
+			q.After = p.Next
+		}
+
+		if len(found) != 2 {
+			t.Fatalf("expected 2 users, got %d", len(found))
+		}
+
+		if found[0].DriverRating.Average != 5 || found[1].DriverRating.Average != 4 {
+			t.Error("expected users to be listed in the order in which they were created")
+		}
+	})
+
+	t.Run("Should only return public profile fields", func(t *testing.T) {
+		p, err := s.Search(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, u := range p.Users {
+			if u.SubID != "" || u.Email != "" || u.PhoneNumber != "" {
+				t.Errorf("expected private fields to be omitted, got %+v", u)
+			}
+		}
+	})
+
+	t.Run("Should fail when query is out of bounds", func(t *testing.T) {
+		regularly := entity.PreferenceRegularly + 1
+
+		_, err := s.Search(&Query{Music: &regularly})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+}
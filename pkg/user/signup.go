@@ -0,0 +1,85 @@
+package user
+
+import (
+	"fmt"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// signUpTransitions contains, for each sign up phase, the phases that a user
+// can move on to from it. Users go through the phases in order and never go
+// back to a previous one.
+var signUpTransitions = map[string][]string{
+	entity.SignUpPhasePersonalInfo: {entity.SignUpPhasePreferences},
+	entity.SignUpPhasePreferences:  {entity.SignUpPhaseDone},
+	entity.SignUpPhaseDone:         nil,
+}
+
+// A signUpRequirement is something that a user must have provided before
+// moving on to a sign up phase.
+type signUpRequirement struct {
+	field     string
+	satisfied func(u *entity.User) bool
+}
+
+// signUpRequirements contains, for each sign up phase, what a user must have
+// provided to reach it.
+var signUpRequirements = map[string][]signUpRequirement{
+	entity.SignUpPhasePreferences: {
+		{"firstName", func(u *entity.User) bool { return u.FirstName != "" }},
+		{"lastName", func(u *entity.User) bool { return u.LastName != "" }},
+		{"dateOfBirth", func(u *entity.User) bool { return !u.DateOfBirth.IsZero() }},
+		{"gender", func(u *entity.User) bool { return u.Gender != "" }},
+	},
+	entity.SignUpPhaseDone: {
+		{"preferences", func(u *entity.User) bool { return u.Preferences != nil }},
+	},
+}
+
+// nextSignUpPhase returns the sign up phase that follows the given one, or an
+// empty string when the sign up is complete.
+func nextSignUpPhase(phase string) string {
+	next := signUpTransitions[phase]
+	if len(next) == 0 {
+		return ""
+	}
+
+	return next[0]
+}
+
+// moveSignUpPhase moves the user on to the given sign up phase, making sure
+// that the transition is allowed and that the user satisfies the phase's
+// requirements. Staying at the same phase is always allowed. Unknown phases
+// are left for the user's validation to report.
+func moveSignUpPhase(u *entity.User, phase string) error {
+	if phase == u.SignUpPhase {
+		return nil
+	}
+
+	if _, ok := signUpTransitions[phase]; !ok {
+		u.SignUpPhase = phase
+		return nil
+	}
+
+	allowed := false
+	for _, next := range signUpTransitions[u.SignUpPhase] {
+		if next == phase {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return InvalidSignUpTransitionError{invalidSignUpTransition.New(fmt.Sprintf("user: cannot move from sign up phase %s to %s", u.SignUpPhase, phase))}
+	}
+
+	for _, requirement := range signUpRequirements[phase] {
+		if !requirement.satisfied(u) {
+			return SignUpIncompleteError{signUpIncomplete.NewForField(requirement.field, fmt.Sprintf("user: %s is required to move on to sign up phase %s", requirement.field, phase))}
+		}
+	}
+
+	u.SignUpPhase = phase
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package user
+
+import (
+	"testing"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+func TestMoveSignUpPhase(t *testing.T) {
+	withPreferences := func(u *entity.User) { u.Preferences = &entity.Preferences{} }
+	withoutGender := func(u *entity.User) { u.Gender = "" }
+
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		modify func(u *entity.User)
+		check  func(err error) bool
+	}{
+		{"Should move on to the next phase", entity.SignUpPhasePersonalInfo, entity.SignUpPhasePreferences, nil, func(err error) bool { return err == nil }},
+		{"Should complete sign up with preferences", entity.SignUpPhasePreferences, entity.SignUpPhaseDone, withPreferences, func(err error) bool { return err == nil }},
+		{"Should stay at the same phase", entity.SignUpPhaseDone, entity.SignUpPhaseDone, nil, func(err error) bool { return err == nil }},
+		{"Should leave unknown phases to validation", entity.SignUpPhasePreferences, "unknown", nil, func(err error) bool { return err == nil }},
+		{"Should fail when skipping a phase", entity.SignUpPhasePersonalInfo, entity.SignUpPhaseDone, withPreferences, func(err error) bool { _, ok := err.(InvalidSignUpTransitionError); return ok }},
+		{"Should fail when going back", entity.SignUpPhaseDone, entity.SignUpPhasePreferences, nil, func(err error) bool { _, ok := err.(InvalidSignUpTransitionError); return ok }},
+		{"Should fail when completing sign up without preferences", entity.SignUpPhasePreferences, entity.SignUpPhaseDone, nil, func(err error) bool {
+			e, ok := err.(SignUpIncompleteError)
+			return ok && e.Field() == "preferences"
+		}},
+		{"Should fail when personal information is missing", entity.SignUpPhasePersonalInfo, entity.SignUpPhasePreferences, withoutGender, func(err error) bool {
+			e, ok := err.(SignUpIncompleteError)
+			return ok && e.Field() == "gender"
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			u := newTestUser("harold|1")
+			u.SignUpPhase = test.from
+			if test.modify != nil {
+				test.modify(u)
+			}
+
+			err := moveSignUpPhase(u, test.to)
+			if !test.check(err) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if err == nil && u.SignUpPhase != test.to {
+				t.Errorf("expected sign up phase %s, got %s", test.to, u.SignUpPhase)
+			} else if err != nil && u.SignUpPhase != test.from {
+				t.Errorf("expected sign up phase to stay %s, got %s", test.from, u.SignUpPhase)
+			}
+		})
+	}
+}
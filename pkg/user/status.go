@@ -0,0 +1,40 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// statusTransitions contains, for each status, the statuses that a user's
+// account can move on to from it. A deleted account stays deleted.
+var statusTransitions = map[string][]string{
+	entity.UserStatusActive:      {entity.UserStatusDeactivated, entity.UserStatusSuspended, entity.UserStatusDeleted},
+	entity.UserStatusDeactivated: {entity.UserStatusActive, entity.UserStatusDeleted},
+	entity.UserStatusSuspended:   {entity.UserStatusActive, entity.UserStatusDeleted},
+	entity.UserStatusDeleted:     nil,
+}
+
+// moveStatus moves the user's account on to the given status at the given
+// time, making sure that the transition is allowed.
+func moveStatus(u *entity.User, status string, now time.Time) error {
+	current := u.CurrentStatus()
+
+	allowed := false
+	for _, next := range statusTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return InvalidStatusTransitionError{invalidStatusTransition.New(fmt.Sprintf("user: cannot move account of user \"%s\" from status %s to %s", u.ID, current, status))}
+	}
+
+	u.Status = status
+	u.StatusChangedAt = now
+
+	return nil
+}
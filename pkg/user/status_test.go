@@ -0,0 +1,59 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+func TestMoveStatus(t *testing.T) {
+	now := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		allowed bool
+	}{
+		{"Should deactivate an active account", entity.UserStatusActive, entity.UserStatusDeactivated, true},
+		{"Should suspend an active account", entity.UserStatusActive, entity.UserStatusSuspended, true},
+		{"Should reactivate a deactivated account", entity.UserStatusDeactivated, entity.UserStatusActive, true},
+		{"Should reactivate a suspended account", entity.UserStatusSuspended, entity.UserStatusActive, true},
+		{"Should delete a deactivated account", entity.UserStatusDeactivated, entity.UserStatusDeleted, true},
+		{"Should consider accounts without status as active", "", entity.UserStatusDeactivated, true},
+		{"Should fail when deactivating a deactivated account", entity.UserStatusDeactivated, entity.UserStatusDeactivated, false},
+		{"Should fail when reactivating an active account", entity.UserStatusActive, entity.UserStatusActive, false},
+		{"Should fail when deactivating a suspended account", entity.UserStatusSuspended, entity.UserStatusDeactivated, false},
+		{"Should fail when reactivating a deleted account", entity.UserStatusDeleted, entity.UserStatusActive, false},
+		{"Should fail when moving to an unknown status", entity.UserStatusActive, "unknown", false},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			u := newTestUser("harold|1")
+			u.Status = test.from
+
+			err := moveStatus(u, test.to, now)
+			if !test.allowed {
+				if _, ok := err.(InvalidStatusTransitionError); !ok {
+					t.Fatalf("expected InvalidStatusTransitionError, got %v", err)
+				}
+
+				if u.Status != test.from {
+					t.Errorf("expected status to stay %s, got %s", test.from, u.Status)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if u.Status != test.to || !u.StatusChangedAt.Equal(now) {
+				t.Errorf("expected status %s changed at %s, got %s changed at %s", test.to, now, u.Status, u.StatusChangedAt)
+			}
+		})
+	}
+}
@@ -1,20 +1,38 @@
 package vehicule
 
+import "azure.com/ecovo/user-service/pkg/entity"
+
 // A NotFoundError is an error that represents that no vehicule was found.
 type NotFoundError struct {
-	msg string
+	entity.BaseError
 }
 
-func (e NotFoundError) Error() string {
-	return e.msg
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "vehiculeNotFound",
+	Detail: "vehicule does not exist",
 }
 
 // A WrongUserError is an error that represents that a vehicule is being
 // added to another user then the one authenticated
 type WrongUserError struct {
-	msg string
+	entity.BaseError
+}
+
+var wrongUser = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "vehiculeOfAnotherUser",
+	Detail: "cannot modify vehicule of another user",
+}
+
+// An InvalidQueryError is an error that represents that a query to list vehicules is
+// malformed or out of bounds. Its field is the query parameter that is not
+// valid.
+type InvalidQueryError struct {
+	entity.BaseError
 }
 
-func (e WrongUserError) Error() string {
-	return e.msg
+var invalidQuery = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "invalidQuery",
 }
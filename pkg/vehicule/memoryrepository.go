@@ -0,0 +1,148 @@
+package vehicule
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on
+// vehicules kept in memory. It is safe for concurrent use and is meant to be
+// used in tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	vehicules map[entity.ID]*entity.Vehicule
+}
+
+// NewMemoryRepository creates an empty in-memory vehicule repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{vehicules: make(map[entity.ID]*entity.Vehicule)}
+}
+
+// FindByID retrieves the vehicule with the given ID that belongs to the user
+// with the given ID, if it exists.
+func (r *MemoryRepository) FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error) {
+	_, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return nil, fmt.Errorf("vehicule.MemoryRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, ok := r.vehicules[ID]
+	if !ok || v.UserID != userID {
+		return nil, fmt.Errorf("vehicule.MemoryRepository: no vehicule found with ID \"%s\" for user \"%s\" (%w)", ID, userID, mongo.ErrNoDocuments)
+	}
+
+	return copyVehicule(v), nil
+}
+
+// FindByUserID retrieves a page of the vehicules that belong to the user with
+// the given ID, filtered and ordered according to the query.
+func (r *MemoryRepository) FindByUserID(userID entity.ID, q *Query) (*Page, error) {
+	var after *entity.Vehicule
+	if q.After != "" {
+		c, err := q.cursor()
+		if err != nil {
+			return nil, err
+		}
+
+		after = &entity.Vehicule{ID: c.ID, Year: c.Year, Make: c.Make, Seats: c.Seats}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var vehicules = make([]*entity.Vehicule, 0)
+	for _, v := range r.vehicules {
+		if v.UserID != userID || !q.matches(v) {
+			continue
+		}
+
+		if after != nil && !q.less(after, v) {
+			continue
+		}
+
+		vehicules = append(vehicules, copyVehicule(v))
+	}
+
+	sort.Slice(vehicules, func(i, j int) bool {
+		return q.less(vehicules[i], vehicules[j])
+	})
+
+	if len(vehicules) > q.Limit+1 {
+		vehicules = vehicules[:q.Limit+1]
+	}
+
+	return newPage(q, vehicules), nil
+}
+
+// Create stores the new vehicule in memory and returns the unique identifier
+// that was generated for it.
+func (r *MemoryRepository) Create(v *entity.Vehicule) (entity.ID, error) {
+	if v == nil {
+		return entity.NilID, fmt.Errorf("vehicule.MemoryRepository: failed to create vehicule (vehicule is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := copyVehicule(v)
+	c.ID = entity.NewIDFromHex(primitive.NewObjectID().Hex())
+	r.vehicules[c.ID] = c
+
+	return c.ID, nil
+}
+
+// Update updates the vehicule in memory.
+func (r *MemoryRepository) Update(v *entity.Vehicule) error {
+	if v == nil {
+		return fmt.Errorf("vehicule.MemoryRepository: failed to update vehicule (vehicule is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.vehicules[v.ID]; !ok || existing.UserID != v.UserID {
+		return fmt.Errorf("vehicule.MemoryRepository: no matching vehicule was found")
+	}
+
+	r.vehicules[v.ID] = copyVehicule(v)
+
+	return nil
+}
+
+// Delete removes the vehicule with the given ID that belongs to the user with
+// the given ID from memory.
+func (r *MemoryRepository) Delete(ID entity.ID, userID entity.ID) error {
+	_, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return fmt.Errorf("vehicule.MemoryRepository: failed to create object ID")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v, ok := r.vehicules[ID]; ok && v.UserID == userID {
+		delete(r.vehicules, ID)
+	}
+
+	return nil
+}
+
+// copyVehicule makes a deep copy of a vehicule so that callers can never
+// modify the repository's state without going through it.
+func copyVehicule(v *entity.Vehicule) *entity.Vehicule {
+	c := *v
+
+	if v.Accessories != nil {
+		c.Accessories = append([]string(nil), v.Accessories...)
+	}
+
+	return &c
+}
@@ -73,15 +73,15 @@ func newDocumentFromEntity(v *entity.Vehicule) (*document, error) {
 
 func (d document) Entity() *entity.Vehicule {
 	return &entity.Vehicule{
-		entity.NewIDFromHex(d.ID.Hex()),
-		entity.NewIDFromHex(d.UserID.Hex()),
-		d.Year,
-		d.Make,
-		d.Model,
-		d.Color,
-		d.Photo,
-		d.Seats,
-		d.Accessories,
+		ID:          entity.NewIDFromHex(d.ID.Hex()),
+		UserID:      entity.NewIDFromHex(d.UserID.Hex()),
+		Year:        d.Year,
+		Make:        d.Make,
+		Model:       d.Model,
+		Color:       d.Color,
+		Photo:       d.Photo,
+		Seats:       d.Seats,
+		Accessories: d.Accessories,
 	}
 }
 
@@ -94,32 +94,90 @@ func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
 	return &MongoRepository{collection}, nil
 }
 
-// FindByID retrieves the vehicule with the given ID, if it exists.
-func (r *MongoRepository) FindByID(ID entity.ID) (*entity.Vehicule, error) {
+// FindByID retrieves the vehicule with the given ID that belongs to the user
+// with the given ID, if it exists.
+func (r *MongoRepository) FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error) {
 	objectID, err := primitive.ObjectIDFromHex(string(ID))
 	if err != nil {
-		return nil, fmt.Errorf("vehicule.MongoRepository: failed to create object ID")
+		return nil, fmt.Errorf("vehicule.MongoRepository: failed to create object ID (%w)", mongo.ErrNoDocuments)
 	}
 
-	filter := bson.D{{"_id", objectID}}
+	userObjectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("vehicule.MongoRepository: failed to create user object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}, {Key: "userId", Value: userObjectID}}
 	var d document
 	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
-	if err != nil {
-		return nil, fmt.Errorf("vehicule.MongoRepository: no vehicule found with ID \"%s\" (%s)", ID, err)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("vehicule.MongoRepository: no vehicule found with ID \"%s\" for user \"%s\" (%w)", ID, userID, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("vehicule.MongoRepository: failed to find vehicule with ID \"%s\" for user \"%s\" (%s)", ID, userID, err)
 	}
 	return d.Entity(), nil
 }
 
-// FindByUserID retrieves the vehicule with the given subscription ID, if it exists.
-func (r *MongoRepository) FindByUserID(userID entity.ID) ([]*entity.Vehicule, error) {
-	objectID, err := primitive.ObjectIDFromHex(string(userID))
-	findOptions := options.Find()
-	filter := bson.D{{"userId", objectID}}
-	cur, err := r.collection.Find(context.TODO(), filter, findOptions)
+// FindByUserID retrieves a page of the vehicules that belong to the user with
+// the given ID, filtered and ordered according to the query.
+func (r *MongoRepository) FindByUserID(userID entity.ID, q *Query) (*Page, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("vehicule.MongoRepository: failed to create user object ID (%w)", mongo.ErrNoDocuments)
+	}
+
+	filter := bson.D{{Key: "userId", Value: userObjectID}}
+
+	if q.MinSeats > 0 {
+		filter = append(filter, bson.E{Key: "seats", Value: bson.D{{Key: "$gte", Value: q.MinSeats}}})
+	}
+
+	if q.Accessory != "" {
+		filter = append(filter, bson.E{Key: "accessories", Value: q.Accessory})
+	}
+
+	comparison, order := "$gt", 1
+	if q.descending() {
+		comparison, order = "$lt", -1
+	}
+
+	if q.After != "" {
+		c, err := q.cursor()
+		if err != nil {
+			return nil, err
+		}
+
+		afterID, err := primitive.ObjectIDFromHex(c.ID.Hex())
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("after", "vehicule: malformed cursor")}
+		}
+
+		if field := q.sortField(); field != "" {
+			filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: field, Value: bson.D{{Key: comparison, Value: c.Value}}}},
+				bson.D{{Key: field, Value: c.Value}, {Key: "_id", Value: bson.D{{Key: comparison, Value: afterID}}}},
+			}})
+		} else {
+			filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: comparison, Value: afterID}}})
+		}
+	}
+
+	sort := bson.D{}
+	if field := q.sortField(); field != "" {
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: order})
 
+	// One more vehicule than the limit is fetched to know whether there is a
+	// next page.
+	findOptions := options.Find().
+		SetSort(sort).
+		SetLimit(int64(q.Limit + 1))
+	cur, err := r.collection.Find(context.TODO(), filter, findOptions)
 	if err != nil {
-		return nil, fmt.Errorf("vehicule.MongoRepository: no vehicules found with user ID \"%s\" (%s)", userID, err)
+		return nil, fmt.Errorf("vehicule.MongoRepository: failed to find vehicules with user ID \"%s\" (%s)", userID, err)
 	}
+	defer cur.Close(context.TODO())
 
 	var vehicules = make([]*entity.Vehicule, 0)
 	for cur.Next(context.TODO()) {
@@ -135,9 +193,7 @@ func (r *MongoRepository) FindByUserID(userID entity.ID) ([]*entity.Vehicule, er
 		return nil, err
 	}
 
-	cur.Close(context.TODO())
-
-	return vehicules, nil
+	return newPage(q, vehicules), nil
 }
 
 // Create stores the new vehicule in the database and returns the unique
@@ -165,14 +221,43 @@ func (r *MongoRepository) Create(v *entity.Vehicule) (entity.ID, error) {
 	return entity.ID(ID.Hex()), nil
 }
 
-// Delete removes the vehicule with the given ID from the database.
-func (r *MongoRepository) Delete(ID entity.ID) error {
+// Update updates the vehicule in the database.
+func (r *MongoRepository) Update(v *entity.Vehicule) error {
+	d, err := newDocumentFromEntity(v)
+	if err != nil {
+		return fmt.Errorf("vehicule.MongoRepository: failed to create vehicule document from entity (%s)", err)
+	}
+
+	filter := bson.D{{Key: "_id", Value: d.ID}, {Key: "userId", Value: d.UserID}}
+	update := bson.D{
+		bson.E{Key: "$set", Value: d},
+	}
+	res, err := r.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("vehicule.MongoRepository: failed to update vehicule with ID \"%s\" (%s)", v.ID, err)
+	}
+
+	if res.MatchedCount <= 0 {
+		return fmt.Errorf("vehicule.MongoRepository: no matching vehicule was found")
+	}
+
+	return nil
+}
+
+// Delete removes the vehicule with the given ID that belongs to the user with
+// the given ID from the database.
+func (r *MongoRepository) Delete(ID entity.ID, userID entity.ID) error {
 	objectID, err := primitive.ObjectIDFromHex(string(ID))
 	if err != nil {
 		return fmt.Errorf("vehicule.MongoRepository: failed to create object ID")
 	}
 
-	filter := bson.D{{"_id", objectID}}
+	userObjectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return fmt.Errorf("vehicule.MongoRepository: failed to create user object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}, {Key: "userId", Value: userObjectID}}
 	_, err = r.collection.DeleteOne(context.TODO(), filter)
 	if err != nil {
 		return fmt.Errorf("vehicule.MongoRepository: failed to delete vehicule with ID \"%s\" (%s)", ID, err)
@@ -0,0 +1,254 @@
+package vehicule
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+)
+
+// A Query describes which of a user's vehicules to list, in which order and
+// how many of them at once.
+type Query struct {
+	// Limit specifies the maximum number of vehicules to list.
+	//
+	// Zero means DefaultLimit.
+	Limit int
+
+	// After specifies the cursor of the page to list. It is the next cursor
+	// that was returned with the previous page.
+	After string
+
+	// Sort specifies the field by which the vehicules are ordered (SortYear,
+	// SortMake or SortSeats). The field can be prefixed by a "-" to reverse
+	// the order.
+	//
+	// An empty field means that the vehicules are listed in the order in
+	// which they were created.
+	Sort string
+
+	// MinSeats specifies the minimum number of seats the vehicules must have.
+	MinSeats int
+
+	// Accessory specifies an accessory the vehicules must have.
+	Accessory string
+}
+
+const (
+	// DefaultLimit represents the default number of vehicules listed at once.
+	DefaultLimit = 20
+
+	// MaxLimit represents the maximum number of vehicules listed at once.
+	MaxLimit = 100
+
+	// SortYear orders the vehicules by year.
+	SortYear = "year"
+
+	// SortMake orders the vehicules by make.
+	SortMake = "make"
+
+	// SortSeats orders the vehicules by number of seats.
+	SortSeats = "seats"
+)
+
+// A Page contains vehicules listed by a query, along with the cursor of the
+// next page, if there is one.
+type Page struct {
+	Vehicules []*entity.Vehicule `json:"vehicules"`
+	Next      string             `json:"next,omitempty"`
+}
+
+// ParseQuery creates a query from URL query values (limit, after, sort,
+// minSeats and accessory) and validates it.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		After:     values.Get("after"),
+		Sort:      values.Get("sort"),
+		Accessory: values.Get("accessory"),
+	}
+
+	var err error
+	if limit := values.Get("limit"); limit != "" {
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("vehicule: limit \"%s\" is not a number", limit))}
+		}
+	}
+
+	if minSeats := values.Get("minSeats"); minSeats != "" {
+		q.MinSeats, err = strconv.Atoi(minSeats)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("minSeats", fmt.Sprintf("vehicule: minimum number of seats \"%s\" is not a number", minSeats))}
+		}
+	}
+
+	err = q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// validate makes sure the query's values are within bounds and fills in the
+// default values.
+func (q *Query) validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("vehicule: limit must be between 1 and %d", MaxLimit))}
+	}
+
+	if q.MinSeats < 0 {
+		return InvalidQueryError{invalidQuery.NewForField("minSeats", "vehicule: minimum number of seats must not be negative")}
+	}
+
+	switch q.sortField() {
+	case "", SortYear, SortMake, SortSeats:
+	default:
+		return InvalidQueryError{invalidQuery.NewForField("sort", fmt.Sprintf("vehicule: cannot sort by \"%s\", must be %s, %s or %s", q.Sort, SortYear, SortMake, SortSeats))}
+	}
+
+	if q.After != "" {
+		_, err := q.cursor()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortField returns the field by which the vehicules are ordered, without the
+// order prefix.
+func (q *Query) sortField() string {
+	return strings.TrimPrefix(q.Sort, "-")
+}
+
+// descending returns whether the vehicules are listed in reverse order.
+func (q *Query) descending() bool {
+	return strings.HasPrefix(q.Sort, "-")
+}
+
+// A cursor identifies the last vehicule of a page by its unique identifier and
+// the value of the field by which the vehicules are ordered.
+type cursor struct {
+	Sort  string      `json:"s,omitempty"`
+	ID    entity.ID   `json:"id"`
+	Year  int         `json:"y,omitempty"`
+	Make  string      `json:"m,omitempty"`
+	Seats int         `json:"n,omitempty"`
+	Value interface{} `json:"-"`
+}
+
+func newCursor(q *Query, v *entity.Vehicule) string {
+	c := cursor{Sort: q.Sort, ID: v.ID}
+	switch q.sortField() {
+	case SortYear:
+		c.Year = v.Year
+	case SortMake:
+		c.Make = v.Make
+	case SortSeats:
+		c.Seats = v.Seats
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (q *Query) cursor() (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(q.After)
+	if err != nil {
+		return nil, InvalidQueryError{invalidQuery.NewForField("after", "vehicule: malformed cursor")}
+	}
+
+	var c cursor
+	err = json.Unmarshal(data, &c)
+	if err != nil || c.ID.IsZero() {
+		return nil, InvalidQueryError{invalidQuery.NewForField("after", "vehicule: malformed cursor")}
+	}
+
+	if c.Sort != q.Sort {
+		return nil, InvalidQueryError{invalidQuery.NewForField("after", "vehicule: cursor was created for another sort order")}
+	}
+
+	switch q.sortField() {
+	case SortYear:
+		c.Value = c.Year
+	case SortMake:
+		c.Value = c.Make
+	case SortSeats:
+		c.Value = c.Seats
+	}
+
+	return &c, nil
+}
+
+// newPage creates a page from the vehicules that were found for a query. When
+// more vehicules than the query's limit were found, the extra vehicules are
+// dropped and the cursor of the next page is set.
+func newPage(q *Query, vehicules []*entity.Vehicule) *Page {
+	if len(vehicules) <= q.Limit {
+		return &Page{Vehicules: vehicules}
+	}
+
+	vehicules = vehicules[:q.Limit]
+
+	return &Page{
+		Vehicules: vehicules,
+		Next:      newCursor(q, vehicules[len(vehicules)-1]),
+	}
+}
+
+// less returns whether a vehicule comes before another according to the
+// query's order.
+func (q *Query) less(a *entity.Vehicule, b *entity.Vehicule) bool {
+	cmp := 0
+	switch q.sortField() {
+	case SortYear:
+		cmp = a.Year - b.Year
+	case SortMake:
+		cmp = strings.Compare(a.Make, b.Make)
+	case SortSeats:
+		cmp = a.Seats - b.Seats
+	}
+
+	if cmp == 0 {
+		cmp = strings.Compare(a.ID.Hex(), b.ID.Hex())
+	}
+
+	if q.descending() {
+		return cmp > 0
+	}
+
+	return cmp < 0
+}
+
+// matches returns whether a vehicule satisfies the query's filters.
+func (q *Query) matches(v *entity.Vehicule) bool {
+	if v.Seats < q.MinSeats {
+		return false
+	}
+
+	if q.Accessory != "" {
+		found := false
+		for _, a := range v.Accessories {
+			if a == q.Accessory {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
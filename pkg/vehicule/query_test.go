@@ -0,0 +1,54 @@
+package vehicule
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Run("Should use the default limit", func(t *testing.T) {
+		q, err := ParseQuery(url.Values{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if q.Limit != DefaultLimit {
+			t.Errorf("expected limit %d, got %d", DefaultLimit, q.Limit)
+		}
+	})
+
+	t.Run("Should parse all values", func(t *testing.T) {
+		q, err := ParseQuery(url.Values{
+			"limit":     {"5"},
+			"sort":      {"-seats"},
+			"minSeats":  {"3"},
+			"accessory": {"bikeRack"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if q.Limit != 5 || q.Sort != "-seats" || q.MinSeats != 3 || q.Accessory != "bikeRack" {
+			t.Errorf("unexpected query %+v", q)
+		}
+	})
+
+	invalid := map[string]url.Values{
+		"limit is not a number":               {"limit": {"harold"}},
+		"limit is negative":                   {"limit": {"-1"}},
+		"minimum number of seats is negative": {"minSeats": {"-1"}},
+		"minimum number of seats is a word":   {"minSeats": {"harold"}},
+	}
+	for name, values := range invalid {
+		values := values
+		t.Run("Should fail when "+name, func(t *testing.T) {
+			if _, ok := errOf(ParseQuery(values)).(InvalidQueryError); !ok {
+				t.Fail()
+			}
+		})
+	}
+}
+
+func errOf(_ *Query, err error) error {
+	return err
+}
@@ -6,9 +6,15 @@ import (
 
 // Repository is an interface representing the ability to perform CRUD
 // operations on vehicules in a database.
+//
+// Vehicules are always looked up by their unique identifier along with the
+// unique identifier of the user they belong to, so that a vehicule can never
+// be reached through another user. When no vehicule is found, the error of a
+// lookup wraps mongo.ErrNoDocuments.
 type Repository interface {
-	FindByID(ID entity.ID) (*entity.Vehicule, error)
-	FindByUserID(userID entity.ID) ([]*entity.Vehicule, error)
+	FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error)
+	FindByUserID(userID entity.ID, q *Query) (*Page, error)
 	Create(user *entity.Vehicule) (entity.ID, error)
-	Delete(ID entity.ID) error
+	Update(vehicule *entity.Vehicule) error
+	Delete(ID entity.ID, userID entity.ID) error
 }
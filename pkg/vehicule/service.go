@@ -1,31 +1,41 @@
 package vehicule
 
 import (
+	"errors"
 	"fmt"
 
 	"azure.com/ecovo/user-service/pkg/entity"
 	"azure.com/ecovo/user-service/pkg/user"
+	"github.com/mongodb/mongo-go-driver/mongo"
 )
 
 // UseCase is an interface representing the ability to handle the business
 // logic that involves vehicules.
 type UseCase interface {
 	Register(v *entity.Vehicule, subID string) (*entity.Vehicule, error)
-	FindByID(ID entity.ID) (*entity.Vehicule, error)
-	FindByUserID(userID entity.ID) ([]*entity.Vehicule, error)
+	FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error)
+	FindByUserID(userID entity.ID, q *Query) (*Page, error)
+	Update(modifiedVehicule *entity.Vehicule, subID string) error
 	Delete(ID entity.ID, userID entity.ID, subID string) error
+	Accessories() []entity.Accessory
 }
 
 // A Service handles the business logic related to vehicules.
 type Service struct {
 	repo     Repository
 	uService user.UseCase
+	policy   *entity.VehiculePolicy
 }
 
 // NewService creates a vehicule service to handle business logic and manipulate
-// vehicules through a repository.
-func NewService(repo Repository, uService user.UseCase) *Service {
-	return &Service{repo, uService}
+// vehicules through a repository. The vehicules must satisfy the given policy,
+// or entity.DefaultVehiculePolicy when it is nil.
+func NewService(repo Repository, uService user.UseCase, policy *entity.VehiculePolicy) *Service {
+	if policy == nil {
+		policy = &entity.DefaultVehiculePolicy
+	}
+
+	return &Service{repo, uService, policy}
 }
 
 // Register validates the vehicule's informartion and persists it in the repository.
@@ -40,10 +50,11 @@ func (s *Service) Register(v *entity.Vehicule, subID string) (*entity.Vehicule,
 	}
 
 	if v.UserID != u.ID {
-		return nil, WrongUserError{fmt.Sprintf("vehicule.Service: cannot add a vehicule to another user \"%s\"", v.ID)}
+		return nil, WrongUserError{wrongUser.New(fmt.Sprintf("vehicule.Service: cannot add a vehicule to another user \"%s\"", v.ID))}
 	}
 
-	err = v.Validate()
+	v.Normalize()
+	err = v.ValidatePolicy(s.policy)
 	if err != nil {
 		return nil, err
 	}
@@ -57,29 +68,119 @@ func (s *Service) Register(v *entity.Vehicule, subID string) (*entity.Vehicule,
 	return v, nil
 }
 
-// FindByID retrieves the vehicule with the given ID in the repository, if it
-// exists.
-func (s *Service) FindByID(ID entity.ID) (*entity.Vehicule, error) {
-	v, err := s.repo.FindByID(ID)
+// FindByID retrieves the vehicule with the given ID that belongs to the user
+// with the given ID in the repository, if it exists and the user's account is
+// active.
+func (s *Service) FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error) {
+	_, err := s.uService.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.repo.FindByID(ID, userID)
 	if err != nil {
-		return nil, NotFoundError{err.Error()}
+		return nil, lookupError(err)
 	}
 
 	return v, nil
 }
 
-// FindByUserID retrieves the multiple vehicules with the given user ID in the
-// repository, if some exists.
-func (s *Service) FindByUserID(userID entity.ID) ([]*entity.Vehicule, error) {
-	v, err := s.repo.FindByUserID(userID)
+// FindByUserID retrieves a page of the vehicules with the given user ID in the
+// repository, filtered and ordered according to the query. A nil query lists
+// the first vehicules in the order in which they were created. The user's
+// account must be active.
+func (s *Service) FindByUserID(userID entity.ID, q *Query) (*Page, error) {
+	if q == nil {
+		q = &Query{}
+	}
+
+	err := q.validate()
 	if err != nil {
-		return nil, NotFoundError{err.Error()}
+		return nil, err
 	}
 
-	return v, nil
+	_, err = s.uService.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.repo.FindByUserID(userID, q)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	return p, nil
 }
 
-// Delete erases the vehicule from the repository.
+// Update validates that the vehicule contains all the required information,
+// that all values are correct and well formatted, and persists the modified
+// vehicule in the repository. Only the fields that are set on the modified
+// vehicule are modified.
+//
+// A user can only modify its own vehicules.
+func (s *Service) Update(modifiedVehicule *entity.Vehicule, subID string) error {
+	if modifiedVehicule == nil {
+		return fmt.Errorf("vehicule.Service: modified vehicule is nil")
+	}
+
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return err
+	}
+
+	if modifiedVehicule.UserID != u.ID {
+		return WrongUserError{wrongUser.New(fmt.Sprintf("vehicule.Service: cannot modify a vehicule of another user \"%s\"", modifiedVehicule.ID))}
+	}
+
+	v, err := s.repo.FindByID(modifiedVehicule.ID, modifiedVehicule.UserID)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	if modifiedVehicule.Year != 0 {
+		v.Year = modifiedVehicule.Year
+	}
+
+	if modifiedVehicule.Make != "" {
+		v.Make = modifiedVehicule.Make
+	}
+
+	if modifiedVehicule.Model != "" {
+		v.Model = modifiedVehicule.Model
+	}
+
+	if modifiedVehicule.Color != "" {
+		v.Color = modifiedVehicule.Color
+	}
+
+	if modifiedVehicule.Photo != "" {
+		v.Photo = modifiedVehicule.Photo
+	}
+
+	if modifiedVehicule.Seats != 0 {
+		v.Seats = modifiedVehicule.Seats
+	}
+
+	if modifiedVehicule.Accessories != nil {
+		v.Accessories = modifiedVehicule.Accessories
+	}
+
+	v.Normalize()
+	err = v.ValidatePolicy(s.policy)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Update(v)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete erases the vehicule with the given ID that belongs to the user with
+// the given ID from the repository. A user can only delete its own vehicules.
 func (s *Service) Delete(ID entity.ID, userID entity.ID, subID string) error {
 	u, err := s.uService.FindBySubID(subID)
 	if err != nil {
@@ -87,13 +188,33 @@ func (s *Service) Delete(ID entity.ID, userID entity.ID, subID string) error {
 	}
 
 	if userID != u.ID {
-		return WrongUserError{fmt.Sprintf("vehicule.Service: cannot delete a vehicule of another user \"%s\"", ID)}
+		return WrongUserError{wrongUser.New(fmt.Sprintf("vehicule.Service: cannot delete a vehicule of another user \"%s\"", ID))}
 	}
 
-	err = s.repo.Delete(ID)
+	_, err = s.repo.FindByID(ID, userID)
+	if err != nil {
+		return lookupError(err)
+	}
+
+	err = s.repo.Delete(ID, userID)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// lookupError returns the error to report when the repository fails to find
+// a vehicule, which is a not found error when the vehicule does not exist.
+func lookupError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return NotFoundError{notFound.New(err.Error())}
+	}
+
+	return err
+}
+
+// Accessories returns the accessories that vehicules can have.
+func (s *Service) Accessories() []entity.Accessory {
+	return s.policy.Accessories
+}
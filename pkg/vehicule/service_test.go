@@ -0,0 +1,414 @@
+package vehicule
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/user"
+)
+
+func newTestServices(t *testing.T) (*Service, *entity.User, *entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+
+	register := func(subID string) *entity.User {
+		u, err := uService.Register(&entity.User{
+			SubID:       subID,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	return NewService(NewMemoryRepository(), uService, nil), register("harold|1"), register("harold|2")
+}
+
+func newTestVehicule(userID entity.ID) *entity.Vehicule {
+	return &entity.Vehicule{
+		UserID:      userID,
+		Year:        2018,
+		Make:        "Audi",
+		Model:       "A4",
+		Color:       "Noir",
+		Seats:       4,
+		Accessories: []string{"airConditioning"},
+	}
+}
+
+func TestServiceRegister(t *testing.T) {
+	s, harold, other := newTestServices(t)
+
+	t.Run("Should generate an ID for the vehicule", func(t *testing.T) {
+		v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.ID.IsZero() {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should normalize the vehicule", func(t *testing.T) {
+		v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v.Color != "black" {
+			t.Errorf("expected color %q, got %q", "black", v.Color)
+		}
+	})
+
+	t.Run("Should fail when adding a vehicule to another user", func(t *testing.T) {
+		_, err := s.Register(newTestVehicule(other.ID), harold.SubID)
+		if _, ok := err.(WrongUserError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceRegisterWithPolicy(t *testing.T) {
+	defaultService, harold, _ := newTestServices(t)
+
+	policy := entity.VehiculePolicy{SeatsMinimum: 2, SeatsMaximum: 5}
+	s := NewService(NewMemoryRepository(), defaultService.uService, &policy)
+
+	if len(s.Accessories()) != 0 {
+		t.Errorf("expected no accessories, got %v", s.Accessories())
+	}
+
+	v := newTestVehicule(harold.ID)
+	v.Seats = 7
+	v.Accessories = nil
+
+	_, err := s.Register(v, harold.SubID)
+	if _, ok := err.(entity.ValidationErrors); !ok {
+		t.Errorf("expected validation errors, got %v", err)
+	}
+}
+
+func TestServiceFindByUserID(t *testing.T) {
+	s, harold, other := newTestServices(t)
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := s.Register(newTestVehicule(other.ID), other.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := s.FindByUserID(harold.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Vehicules) != 2 {
+		t.Errorf("expected 2 vehicules, got %d", len(p.Vehicules))
+	}
+
+	if p.Next != "" {
+		t.Errorf("expected no next page, got %q", p.Next)
+	}
+}
+
+func TestServiceFindByUserIDWithQuery(t *testing.T) {
+	s, harold, _ := newTestServices(t)
+
+	fleet := []struct {
+		year        int
+		make        string
+		seats       int
+		accessories []string
+	}{
+		{2015, "Toyota", 4, []string{"airConditioning"}},
+		{2018, "Audi", 4, []string{"airConditioning", "bikeRack"}},
+		{2012, "Honda", 7, nil},
+		{2018, "Mazda", 2, []string{"bikeRack"}},
+		{2020, "Kia", 7, []string{"airConditioning"}},
+	}
+	for _, f := range fleet {
+		v := newTestVehicule(harold.ID)
+		v.Year, v.Make, v.Seats, v.Accessories = f.year, f.make, f.seats, f.accessories
+
+		if _, err := s.Register(v, harold.SubID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(t *testing.T, q Query) []string {
+		var makes []string
+		for {
+			p, err := s.FindByUserID(harold.ID, &q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range p.Vehicules {
+				makes = append(makes, v.Make)
+			}
+
+			if p.Next == "" {
+				return makes
+			}
+			q.After = p.Next
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected string
+	}{
+		{"Should list in creation order", Query{Limit: 2}, "Toyota Audi Honda Mazda Kia"},
+		{"Should sort by year with ties in creation order", Query{Limit: 2, Sort: SortYear}, "Honda Toyota Audi Mazda Kia"},
+		{"Should sort by year in reverse order", Query{Limit: 2, Sort: "-" + SortYear}, "Kia Mazda Audi Toyota Honda"},
+		{"Should sort by make", Query{Limit: 3, Sort: SortMake}, "Audi Honda Kia Mazda Toyota"},
+		{"Should sort by seats", Query{Limit: 1, Sort: SortSeats}, "Mazda Toyota Audi Honda Kia"},
+		{"Should filter by minimum number of seats", Query{Limit: 1, MinSeats: 5}, "Honda Kia"},
+		{"Should filter by accessory", Query{Limit: 1, Accessory: "bikeRack", Sort: "-" + SortMake}, "Mazda Audi"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			makes := strings.Join(list(t, test.query), " ")
+			if makes != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, makes)
+			}
+		})
+	}
+
+	t.Run("Should fail when limit is out of bounds", func(t *testing.T) {
+		_, err := s.FindByUserID(harold.ID, &Query{Limit: MaxLimit + 1})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when sort field is unknown", func(t *testing.T) {
+		_, err := s.FindByUserID(harold.ID, &Query{Sort: "color"})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when cursor was created for another sort order", func(t *testing.T) {
+		p, err := s.FindByUserID(harold.ID, &Query{Limit: 1, Sort: SortYear})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.FindByUserID(harold.ID, &Query{After: p.Next, Sort: SortMake})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when cursor is malformed", func(t *testing.T) {
+		_, err := s.FindByUserID(harold.ID, &Query{After: "harold"})
+		if _, ok := err.(InvalidQueryError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceFindByID(t *testing.T) {
+	s, harold, other := newTestServices(t)
+
+	v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should find the vehicule of the user", func(t *testing.T) {
+		found, err := s.FindByID(v.ID, harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found.ID != v.ID {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when the vehicule belongs to another user", func(t *testing.T) {
+		_, err := s.FindByID(v.ID, other.ID)
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceFindInactiveOwner(t *testing.T) {
+	s, harold, _ := newTestServices(t)
+
+	v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.uService.Deactivate(harold.ID, &user.Caller{SubID: harold.SubID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should not find the vehicule of a deactivated user", func(t *testing.T) {
+		_, err := s.FindByID(v.ID, harold.ID)
+		if _, ok := err.(user.NotFoundError); !ok {
+			t.Errorf("expected user.NotFoundError, got %v", err)
+		}
+	})
+
+	t.Run("Should not list the vehicules of a deactivated user", func(t *testing.T) {
+		_, err := s.FindByUserID(harold.ID, nil)
+		if _, ok := err.(user.NotFoundError); !ok {
+			t.Errorf("expected user.NotFoundError, got %v", err)
+		}
+	})
+}
+
+// failingRepository is a repository whose lookups always fail.
+type failingRepository struct {
+	Repository
+	err error
+}
+
+func (r *failingRepository) FindByID(ID entity.ID, userID entity.ID) (*entity.Vehicule, error) {
+	return nil, r.err
+}
+
+func (r *failingRepository) FindByUserID(userID entity.ID, q *Query) (*Page, error) {
+	return nil, r.err
+}
+
+func TestServiceLookupError(t *testing.T) {
+	s, harold, _ := newTestServices(t)
+	repoErr := errors.New("vehicule.MongoRepository: server selection timeout")
+	s.repo = &failingRepository{s.repo, repoErr}
+
+	t.Run("Should not report repository failures as not found when finding a vehicule", func(t *testing.T) {
+		if _, err := s.FindByID(entity.NewIDFromHex("5c8a1d5b0190b214360dc031"), harold.ID); err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+	})
+
+	t.Run("Should not report repository failures as not found when listing vehicules", func(t *testing.T) {
+		if _, err := s.FindByUserID(harold.ID, nil); err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+	})
+
+	t.Run("Should not report repository failures as not found when deleting", func(t *testing.T) {
+		if err := s.Delete(entity.NewIDFromHex("5c8a1d5b0190b214360dc031"), harold.ID, harold.SubID); err != repoErr {
+			t.Errorf("expected %v, got %v", repoErr, err)
+		}
+	})
+}
+
+func TestServiceDelete(t *testing.T) {
+	s, harold, other := newTestServices(t)
+
+	v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should fail when deleting the vehicule of another user", func(t *testing.T) {
+		err := s.Delete(v.ID, harold.ID, other.SubID)
+		if _, ok := err.(WrongUserError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when the vehicule does not belong to the user", func(t *testing.T) {
+		otherVehicule, err := s.Register(newTestVehicule(other.ID), other.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.Delete(otherVehicule.ID, harold.ID, harold.SubID)
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+
+		if _, err := s.FindByID(otherVehicule.ID, other.ID); err != nil {
+			t.Error("expected vehicule of the other user not to be deleted")
+		}
+	})
+
+	t.Run("Should delete the vehicule", func(t *testing.T) {
+		err := s.Delete(v.ID, harold.ID, harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.FindByID(v.ID, harold.ID)
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceUpdate(t *testing.T) {
+	s, harold, other := newTestServices(t)
+
+	v, err := s.Register(newTestVehicule(harold.ID), harold.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherVehicule, err := s.Register(newTestVehicule(other.ID), other.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Should only modify the fields that are set", func(t *testing.T) {
+		err := s.Update(&entity.Vehicule{ID: v.ID, UserID: harold.ID, Color: "Rouge"}, harold.SubID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		updated, err := s.FindByID(v.ID, harold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if updated.Color != "red" || updated.Make != v.Make || updated.ID != v.ID {
+			t.Errorf("unexpected vehicule %+v", updated)
+		}
+	})
+
+	t.Run("Should fail when modifying a vehicule of another user", func(t *testing.T) {
+		err := s.Update(&entity.Vehicule{ID: otherVehicule.ID, UserID: other.ID, Color: "Rouge"}, harold.SubID)
+		if _, ok := err.(WrongUserError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when the vehicule does not belong to the user", func(t *testing.T) {
+		err := s.Update(&entity.Vehicule{ID: otherVehicule.ID, UserID: harold.ID, Color: "Rouge"}, harold.SubID)
+		if _, ok := err.(NotFoundError); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("Should fail when the modified vehicule is not valid", func(t *testing.T) {
+		err := s.Update(&entity.Vehicule{ID: v.ID, UserID: harold.ID, Seats: -1}, harold.SubID)
+		if _, ok := err.(entity.ValidationErrors); !ok {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package verification
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// A NotFoundError is an error that represents that no document exists with
+// the given ID for the user.
+type NotFoundError struct {
+	entity.BaseError
+}
+
+var notFound = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindNotFound,
+	Code:   "documentNotFound",
+	Detail: "document does not exist",
+}
+
+// A ForbiddenError is an error that represents that the caller is not allowed
+// to see or to add the documents of another user.
+type ForbiddenError struct {
+	entity.BaseError
+}
+
+var forbidden = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindForbidden,
+	Code:   "documentOfAnotherUser",
+	Detail: "not allowed to access the documents of another user",
+}
+
+// A MissingFileError is an error that represents that the uploaded file is
+// empty.
+type MissingFileError struct {
+	entity.BaseError
+}
+
+var missingFile = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  entity.CodeMissing,
+	Field: "file",
+}
+
+// A FileTooLargeError is an error that represents that the uploaded file is
+// larger than the maximum size.
+type FileTooLargeError struct {
+	entity.BaseError
+}
+
+var fileTooLarge = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "fileTooLarge",
+	Field: "file",
+}
+
+// An UnsupportedContentTypeError is an error that represents that the
+// uploaded file is neither a PDF nor an image.
+type UnsupportedContentTypeError struct {
+	entity.BaseError
+}
+
+var unsupportedContentType = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "unsupportedContentType",
+	Field: "file",
+}
+
+// An ExpiredError is an error that represents that a document is past its
+// expiration date, so it cannot be uploaded or approved.
+type ExpiredError struct {
+	entity.BaseError
+}
+
+var expired = &entity.ErrorDescription{
+	Kind:  entity.ErrorKindInvalid,
+	Code:  "documentExpired",
+	Field: "expiresAt",
+}
+
+// An AlreadyReviewedError is an error that represents that a document cannot
+// be reviewed again.
+type AlreadyReviewedError struct {
+	entity.BaseError
+}
+
+var alreadyReviewed = &entity.ErrorDescription{
+	Kind:   entity.ErrorKindAlreadyExists,
+	Code:   "documentAlreadyReviewed",
+	Detail: "document was already reviewed",
+}
+
+// An InvalidQueryError is an error that represents that a query to list
+// documents is malformed or out of bounds. Its field is the query parameter
+// that is not valid.
+type InvalidQueryError struct {
+	entity.BaseError
+}
+
+var invalidQuery = &entity.ErrorDescription{
+	Kind: entity.ErrorKindInvalid,
+	Code: "invalidQuery",
+}
@@ -0,0 +1,120 @@
+package verification
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// A MemoryRepository is a repository that performs CRUD operations on
+// documents kept in memory. It is safe for concurrent use and is meant to be
+// used in tests and when running the service locally without a database.
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	documents map[entity.ID]*entity.Document
+}
+
+// NewMemoryRepository creates an empty in-memory document repository.
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{documents: make(map[entity.ID]*entity.Document)}
+}
+
+// FindByID retrieves the document with the given ID, if it exists.
+func (r *MemoryRepository) FindByID(ID entity.ID) (*entity.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.documents[ID]
+	if !ok {
+		return nil, fmt.Errorf("verification.MemoryRepository: no document found with ID \"%s\"", ID)
+	}
+
+	c := *d
+
+	return &c, nil
+}
+
+// FindByUserID retrieves the documents of the user with the given ID, from the
+// most recent to the oldest.
+func (r *MemoryRepository) FindByUserID(userID entity.ID) ([]*entity.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var documents = make([]*entity.Document, 0)
+	for _, d := range r.documents {
+		if d.UserID == userID {
+			c := *d
+			documents = append(documents, &c)
+		}
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID > documents[j].ID
+	})
+
+	return documents, nil
+}
+
+// Search retrieves a page of the documents that satisfy the query's filters.
+func (r *MemoryRepository) Search(q *Query) (*Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var documents = make([]*entity.Document, 0)
+	for _, d := range r.documents {
+		c := *d
+		c.Expire(q.now)
+		if q.matches(&c) {
+			documents = append(documents, &c)
+		}
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID < documents[j].ID
+	})
+
+	if len(documents) > q.Limit+1 {
+		documents = documents[:q.Limit+1]
+	}
+
+	return newPage(q, documents), nil
+}
+
+// Create stores the new document in memory and returns the unique identifier
+// that was generated for it.
+func (r *MemoryRepository) Create(d *entity.Document) (entity.ID, error) {
+	if d == nil {
+		return entity.NilID, fmt.Errorf("verification.MemoryRepository: failed to create document (document is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *d
+	c.ID = entity.NewIDFromHex(primitive.NewObjectID().Hex())
+	r.documents[c.ID] = &c
+
+	return c.ID, nil
+}
+
+// Update updates the document in memory.
+func (r *MemoryRepository) Update(d *entity.Document) error {
+	if d == nil {
+		return fmt.Errorf("verification.MemoryRepository: failed to update document (document is nil)")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.documents[d.ID]; !ok {
+		return fmt.Errorf("verification.MemoryRepository: no matching document was found")
+	}
+
+	c := *d
+	r.documents[d.ID] = &c
+
+	return nil
+}
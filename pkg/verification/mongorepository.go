@@ -0,0 +1,261 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// A MongoRepository is a repository that performs CRUD operations on documents
+// in a MongoDB collection.
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+type document struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserID          primitive.ObjectID `bson:"userId"`
+	VehiculeID      string             `bson:"vehiculeId,omitempty"`
+	Type            string             `bson:"type"`
+	Status          string             `bson:"status"`
+	ExpiresAt       time.Time          `bson:"expiresAt"`
+	ContentType     string             `bson:"contentType"`
+	Size            int64              `bson:"size"`
+	FileKey         string             `bson:"fileKey"`
+	RejectionReason string             `bson:"rejectionReason,omitempty"`
+	UploadedAt      time.Time          `bson:"uploadedAt"`
+	ReviewedAt      time.Time          `bson:"reviewedAt"`
+}
+
+func newDocumentFromEntity(d *entity.Document) (*document, error) {
+	if d == nil {
+		return nil, fmt.Errorf("verification.MongoRepository: entity is nil")
+	}
+
+	id := primitive.NilObjectID
+	if !d.ID.IsZero() {
+		objectID, err := primitive.ObjectIDFromHex(d.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("verification.MongoRepository: failed to create object")
+		}
+
+		id = objectID
+	}
+
+	userID, err := primitive.ObjectIDFromHex(d.UserID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: failed to create user object ID")
+	}
+
+	return &document{
+		id,
+		userID,
+		d.VehiculeID.Hex(),
+		d.Type,
+		d.Status,
+		d.ExpiresAt,
+		d.ContentType,
+		d.Size,
+		d.FileKey,
+		d.RejectionReason,
+		d.UploadedAt,
+		d.ReviewedAt,
+	}, nil
+}
+
+func (d document) Entity() *entity.Document {
+	return &entity.Document{
+		ID:              entity.NewIDFromHex(d.ID.Hex()),
+		UserID:          entity.NewIDFromHex(d.UserID.Hex()),
+		VehiculeID:      entity.NewIDFromHex(d.VehiculeID),
+		Type:            d.Type,
+		Status:          d.Status,
+		ExpiresAt:       d.ExpiresAt,
+		ContentType:     d.ContentType,
+		Size:            d.Size,
+		FileKey:         d.FileKey,
+		RejectionReason: d.RejectionReason,
+		UploadedAt:      d.UploadedAt,
+		ReviewedAt:      d.ReviewedAt,
+	}
+}
+
+// NewMongoRepository creates a document repository for a MongoDB collection
+// and makes sure the indexes used to list documents exist.
+func NewMongoRepository(collection *mongo.Collection) (Repository, error) {
+	if collection == nil {
+		return nil, fmt.Errorf("verification.MongoRepository: collection is nil")
+	}
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("userId"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+			Options: options.Index().SetName("reviewQueue"),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: failed to create indexes (%s)", err)
+	}
+
+	return &MongoRepository{collection}, nil
+}
+
+// FindByID retrieves the document with the given ID, if it exists.
+func (r *MongoRepository) FindByID(ID entity.ID) (*entity.Document, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(ID))
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: failed to create object ID")
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectID}}
+	var d document
+	err = r.collection.FindOne(context.TODO(), filter).Decode(&d)
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: no document found with ID \"%s\" (%s)", ID, err)
+	}
+
+	return d.Entity(), nil
+}
+
+// FindByUserID retrieves the documents of the user with the given ID, from the
+// most recent to the oldest.
+func (r *MongoRepository) FindByUserID(userID entity.ID) ([]*entity.Document, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: failed to create user object ID")
+	}
+
+	filter := bson.D{{Key: "userId", Value: userObjectID}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+
+	return r.find(filter, findOptions)
+}
+
+// Search retrieves a page of the documents that satisfy the query's filters.
+func (r *MongoRepository) Search(q *Query) (*Page, error) {
+	filter := bson.D{}
+
+	usable := bson.A{entity.DocumentStatusPending, entity.DocumentStatusApproved}
+	switch q.Status {
+	case entity.DocumentStatusPending, entity.DocumentStatusApproved:
+		filter = append(filter,
+			bson.E{Key: "status", Value: q.Status},
+			bson.E{Key: "expiresAt", Value: bson.D{{Key: "$gt", Value: q.now}}},
+		)
+	case entity.DocumentStatusExpired:
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: entity.DocumentStatusExpired}},
+			bson.D{
+				{Key: "status", Value: bson.D{{Key: "$in", Value: usable}}},
+				{Key: "expiresAt", Value: bson.D{{Key: "$lte", Value: q.now}}},
+			},
+		}})
+	case entity.DocumentStatusRejected:
+		filter = append(filter, bson.E{Key: "status", Value: q.Status})
+	}
+
+	if q.After != "" {
+		afterID, err := primitive.ObjectIDFromHex(q.After)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("after", "verification: malformed cursor")}
+		}
+
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: afterID}}})
+	}
+
+	// One more document than the limit is fetched to know whether there is a
+	// next page.
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(q.Limit + 1))
+	documents, err := r.find(filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range documents {
+		d.Expire(q.now)
+	}
+
+	return newPage(q, documents), nil
+}
+
+// find retrieves the documents that match the filter.
+func (r *MongoRepository) find(filter bson.D, findOptions *options.FindOptions) ([]*entity.Document, error) {
+	cur, err := r.collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("verification.MongoRepository: failed to find documents (%s)", err)
+	}
+	defer cur.Close(context.TODO())
+
+	var documents = make([]*entity.Document, 0)
+	for cur.Next(context.TODO()) {
+		var d document
+		err := cur.Decode(&d)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, d.Entity())
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// Create stores the new document in the database and returns the unique
+// identifier that was generated for it.
+func (r *MongoRepository) Create(d *entity.Document) (entity.ID, error) {
+	doc, err := newDocumentFromEntity(d)
+	if err != nil {
+		return entity.NilID, fmt.Errorf("verification.MongoRepository: failed to create document from entity (%s)", err)
+	}
+
+	res, err := r.collection.InsertOne(context.TODO(), doc)
+	if err != nil {
+		return entity.NilID, fmt.Errorf("verification.MongoRepository: failed to create document (%s)", err)
+	}
+
+	ID, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return entity.NilID, fmt.Errorf("verification.MongoRepository: failed to get ID of created document")
+	}
+
+	return entity.ID(ID.Hex()), nil
+}
+
+// Update updates the document in the database.
+func (r *MongoRepository) Update(d *entity.Document) error {
+	doc, err := newDocumentFromEntity(d)
+	if err != nil {
+		return fmt.Errorf("verification.MongoRepository: failed to create document from entity (%s)", err)
+	}
+
+	filter := bson.D{{Key: "_id", Value: doc.ID}}
+	update := bson.D{{Key: "$set", Value: doc}}
+	res, err := r.collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return fmt.Errorf("verification.MongoRepository: failed to update document with ID \"%s\" (%s)", d.ID, err)
+	}
+
+	if res.MatchedCount <= 0 {
+		return fmt.Errorf("verification.MongoRepository: no matching document was found")
+	}
+
+	return nil
+}
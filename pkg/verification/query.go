@@ -0,0 +1,131 @@
+package verification
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// A Query describes which documents to list for review. Documents are listed
+// in the order in which they were uploaded, so that the oldest ones are
+// reviewed first.
+type Query struct {
+	// Status specifies the status of the documents to list. Documents that
+	// are past their expiration date are considered expired, whatever their
+	// stored status is.
+	//
+	// An empty status means any status.
+	Status string
+
+	// Limit specifies the maximum number of documents to list.
+	//
+	// Zero means DefaultLimit.
+	Limit int
+
+	// After specifies the cursor of the page to list. It is the next cursor
+	// that was returned with the previous page.
+	After string
+
+	// now is the time at which the documents' expiration is evaluated.
+	now time.Time
+}
+
+const (
+	// DefaultLimit represents the default number of documents listed at once.
+	DefaultLimit = 20
+
+	// MaxLimit represents the maximum number of documents listed at once.
+	MaxLimit = 100
+)
+
+// A Page contains documents listed by a query, along with the cursor of the
+// next page, if there is one.
+type Page struct {
+	Documents []*entity.Document `json:"documents"`
+	Next      string             `json:"next,omitempty"`
+}
+
+// ParseQuery creates a query from URL query values (status, limit and after)
+// and validates it.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		Status: values.Get("status"),
+		After:  values.Get("after"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		var err error
+		q.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("verification: limit \"%s\" is not a number", limit))}
+		}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// validate makes sure the query's values are within bounds and fills in the
+// default values.
+func (q *Query) validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return InvalidQueryError{invalidQuery.NewForField("limit", fmt.Sprintf("verification: limit must be between 1 and %d", MaxLimit))}
+	}
+
+	switch q.Status {
+	case "", entity.DocumentStatusPending, entity.DocumentStatusApproved, entity.DocumentStatusRejected, entity.DocumentStatusExpired:
+	default:
+		return InvalidQueryError{invalidQuery.NewForField("status", fmt.Sprintf("verification: status must be %s, %s, %s or %s", entity.DocumentStatusPending, entity.DocumentStatusApproved, entity.DocumentStatusRejected, entity.DocumentStatusExpired))}
+	}
+
+	if q.After != "" {
+		if _, err := primitive.ObjectIDFromHex(q.After); err != nil {
+			return InvalidQueryError{invalidQuery.NewForField("after", "verification: malformed cursor")}
+		}
+	}
+
+	return nil
+}
+
+// matches returns whether a document, whose expiration was already evaluated,
+// satisfies the query's filters.
+func (q *Query) matches(d *entity.Document) bool {
+	if q.Status != "" && d.Status != q.Status {
+		return false
+	}
+
+	if q.After != "" && d.ID.Hex() <= q.After {
+		return false
+	}
+
+	return true
+}
+
+// newPage creates a page from the documents that were found for a query. When
+// more documents than the query's limit were found, the extra documents are
+// dropped and the cursor of the next page is set to the last document's
+// unique identifier.
+func newPage(q *Query, documents []*entity.Document) *Page {
+	if len(documents) <= q.Limit {
+		return &Page{Documents: documents}
+	}
+
+	documents = documents[:q.Limit]
+
+	return &Page{
+		Documents: documents,
+		Next:      documents[len(documents)-1].ID.Hex(),
+	}
+}
@@ -0,0 +1,13 @@
+package verification
+
+import "azure.com/ecovo/user-service/pkg/entity"
+
+// Repository is an interface representing the ability to perform CRUD
+// operations on documents in a database.
+type Repository interface {
+	FindByID(ID entity.ID) (*entity.Document, error)
+	FindByUserID(userID entity.ID) ([]*entity.Document, error)
+	Search(q *Query) (*Page, error)
+	Create(d *entity.Document) (entity.ID, error)
+	Update(d *entity.Document) error
+}
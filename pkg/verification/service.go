@@ -0,0 +1,373 @@
+// Package verification lets users prove that they are allowed to drive by
+// uploading documents, such as their driver's license and the insurance
+// certificate of their vehicules, which are then reviewed by administrators.
+// A user whose required documents are approved and unexpired is a verified
+// driver.
+package verification
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+)
+
+// UseCase is an interface representing the ability to handle the business
+// logic that involves verifying drivers.
+type UseCase interface {
+	Upload(d *entity.Document, file io.Reader, subID string) (*entity.Document, error)
+	FindByID(ID entity.ID, userID entity.ID, caller *user.Caller) (*entity.Document, error)
+	FindByUserID(userID entity.ID, caller *user.Caller) ([]*entity.Document, error)
+	Open(d *entity.Document) (io.ReadCloser, error)
+	Search(q *Query) (*Page, error)
+	Approve(ID entity.ID) (*entity.Document, error)
+	Reject(ID entity.ID, reason string) (*entity.Document, error)
+	MaxFileSize() int64
+}
+
+// Config contains the rules that the uploaded documents must satisfy.
+type Config struct {
+	// MaxFileSize specifies the maximum size of an uploaded file in bytes.
+	//
+	// Zero means DefaultMaxFileSize.
+	MaxFileSize int64
+
+	// RequiredTypes specifies the types of documents that must be approved
+	// for a user to be a verified driver.
+	//
+	// Nil means entity.DriverDocumentTypes.
+	RequiredTypes []string
+}
+
+// DefaultMaxFileSize represents the default maximum size of an uploaded file
+// (10 MiB).
+const DefaultMaxFileSize = 10 << 20
+
+// contentTypes contains the content types of the files that can be uploaded.
+var contentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// A Service handles the business logic related to verifying drivers.
+type Service struct {
+	repo     Repository
+	uService user.UseCase
+	vService vehicule.UseCase
+	store    media.Store
+	config   Config
+	now      func() time.Time
+}
+
+// NewService creates a verification service to handle business logic and
+// manipulate documents through a repository. The files of the documents are
+// kept in a store.
+func NewService(repo Repository, uService user.UseCase, vService vehicule.UseCase, store media.Store, config *Config) *Service {
+	var c Config
+	if config != nil {
+		c = *config
+	}
+
+	if c.MaxFileSize == 0 {
+		c.MaxFileSize = DefaultMaxFileSize
+	}
+
+	if c.RequiredTypes == nil {
+		c.RequiredTypes = entity.DriverDocumentTypes
+	}
+
+	return &Service{repo, uService, vService, store, c, time.Now}
+}
+
+// MaxFileSize returns the maximum size of an uploaded file in bytes.
+func (s *Service) MaxFileSize() int64 {
+	return s.config.MaxFileSize
+}
+
+// Upload stores the file of a document that the user with the given
+// subscription ID uploaded, and persists the document so that it can be
+// reviewed. Users can only upload their own documents, and a document about a
+// vehicule must be about one of the user's vehicules. The file's content type
+// is detected from its content, whatever the client claims it is.
+func (s *Service) Upload(d *entity.Document, file io.Reader, subID string) (*entity.Document, error) {
+	if d == nil {
+		return nil, fmt.Errorf("verification.Service: document is nil")
+	}
+
+	u, err := s.uService.FindBySubID(subID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.UserID != u.ID {
+		return nil, ForbiddenError{forbidden.New(fmt.Sprintf("verification.Service: cannot add a document to another user \"%s\"", d.UserID))}
+	}
+
+	if !d.VehiculeID.IsZero() {
+		_, err = s.vService.FindByID(d.VehiculeID, u.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := s.now().UTC()
+	if !d.ExpiresAt.IsZero() && !now.Before(d.ExpiresAt) {
+		return nil, ExpiredError{expired.New(fmt.Sprintf("verification.Service: document expired on %s", d.ExpiresAt.Format("2006-01-02")))}
+	}
+
+	content, err := s.readFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d.ID = entity.NilID
+	d.Status = entity.DocumentStatusPending
+	d.ContentType = http.DetectContentType(content)
+	d.Size = int64(len(content))
+	d.FileKey = newFileKey(u.ID)
+	d.RejectionReason = ""
+	d.UploadedAt = now
+	d.ReviewedAt = time.Time{}
+
+	if !contentTypes[d.ContentType] {
+		return nil, UnsupportedContentTypeError{unsupportedContentType.New(fmt.Sprintf("verification.Service: file of type \"%s\" must be a PDF, a JPEG or a PNG", d.ContentType))}
+	}
+
+	err = d.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.Put(d.FileKey, d.ContentType, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	d.ID, err = s.repo.Create(d)
+	if err != nil {
+		_ = s.store.Delete(d.FileKey)
+
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// readFile reads an uploaded file, making sure it is neither empty nor larger
+// than the maximum size.
+func (s *Service) readFile(file io.Reader) ([]byte, error) {
+	if file == nil {
+		return nil, MissingFileError{missingFile.New("verification.Service: file is missing")}
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(file, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("verification.Service: failed to read file (%s)", err)
+	}
+
+	if len(content) == 0 {
+		return nil, MissingFileError{missingFile.New("verification.Service: file is empty")}
+	}
+
+	if int64(len(content)) > s.config.MaxFileSize {
+		return nil, FileTooLargeError{fileTooLarge.New(fmt.Sprintf("verification.Service: file is larger than %d bytes", s.config.MaxFileSize))}
+	}
+
+	return content, nil
+}
+
+// newFileKey generates a unique key under which the file of one of the user's
+// documents is stored.
+func newFileKey(userID entity.ID) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("documents/%s/%s", userID, hex.EncodeToString(b))
+}
+
+// FindByID retrieves the document with the given ID that belongs to the user
+// with the given ID, if it exists. Unless the caller is privileged, it can
+// only see its own documents.
+func (s *Service) FindByID(ID entity.ID, userID entity.ID, caller *user.Caller) (*entity.Document, error) {
+	err := s.checkCaller(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := s.repo.FindByID(ID)
+	if err != nil {
+		return nil, NotFoundError{notFound.New(err.Error())}
+	}
+
+	if d.UserID != userID {
+		return nil, NotFoundError{notFound.New(fmt.Sprintf("verification.Service: no document found with ID \"%s\" for user \"%s\"", ID, userID))}
+	}
+	d.Expire(s.now())
+
+	return d, nil
+}
+
+// FindByUserID retrieves the documents of the user with the given ID, from the
+// most recent to the oldest. Unless the caller is privileged, it can only see
+// its own documents.
+func (s *Service) FindByUserID(userID entity.ID, caller *user.Caller) ([]*entity.Document, error) {
+	err := s.checkCaller(userID, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	for _, d := range documents {
+		d.Expire(now)
+	}
+
+	return documents, nil
+}
+
+// checkCaller makes sure that the caller is allowed to see the documents of
+// the user with the given ID.
+func (s *Service) checkCaller(userID entity.ID, caller *user.Caller) error {
+	if caller == nil {
+		return fmt.Errorf("verification.Service: caller is nil")
+	}
+
+	u, err := s.uService.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !caller.Privileged && u.SubID != caller.SubID {
+		return ForbiddenError{forbidden.New(fmt.Sprintf("verification.Service: cannot see the documents of another user \"%s\"", userID))}
+	}
+
+	return nil
+}
+
+// Open opens the file of a document. The caller must close it.
+func (s *Service) Open(d *entity.Document) (io.ReadCloser, error) {
+	if d == nil {
+		return nil, fmt.Errorf("verification.Service: document is nil")
+	}
+
+	return s.store.Get(d.FileKey)
+}
+
+// Search retrieves a page of the documents of every user that satisfy the
+// query's filters, such as the documents waiting to be reviewed. A nil query
+// lists the first documents.
+func (s *Service) Search(q *Query) (*Page, error) {
+	if q == nil {
+		q = &Query{}
+	}
+
+	err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+	q.now = s.now()
+
+	return s.repo.Search(q)
+}
+
+// Approve marks the pending document with the given ID as approved and
+// updates whether its user is a verified driver.
+func (s *Service) Approve(ID entity.ID) (*entity.Document, error) {
+	return s.review(ID, entity.DocumentStatusApproved, "")
+}
+
+// Reject marks the document with the given ID as rejected for the given
+// reason and updates whether its user is a verified driver. A document that
+// was approved can still be rejected, for example when it turns out to be
+// forged.
+func (s *Service) Reject(ID entity.ID, reason string) (*entity.Document, error) {
+	return s.review(ID, entity.DocumentStatusRejected, reason)
+}
+
+func (s *Service) review(ID entity.ID, status string, reason string) (*entity.Document, error) {
+	d, err := s.repo.FindByID(ID)
+	if err != nil {
+		return nil, NotFoundError{notFound.New(err.Error())}
+	}
+
+	now := s.now().UTC()
+	d.Expire(now)
+
+	switch {
+	case d.Status == entity.DocumentStatusExpired:
+		return nil, ExpiredError{expired.New(fmt.Sprintf("verification.Service: document \"%s\" expired on %s", ID, d.ExpiresAt.Format("2006-01-02")))}
+	case d.Status == entity.DocumentStatusRejected,
+		d.Status == entity.DocumentStatusApproved && status == entity.DocumentStatusApproved:
+		return nil, AlreadyReviewedError{alreadyReviewed.New(fmt.Sprintf("verification.Service: document \"%s\" is already %s", ID, d.Status))}
+	}
+
+	d.Status = status
+	d.RejectionReason = reason
+	d.ReviewedAt = now
+
+	err = d.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Update(d)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.refreshVerifiedDriver(d.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// refreshVerifiedDriver updates until when the user with the given ID is a
+// verified driver, which is until the first of its required documents
+// expires. For each required type, the approved document that expires last
+// is used.
+func (s *Service) refreshVerifiedDriver(userID entity.ID) error {
+	documents, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	lastExpiration := make(map[string]time.Time)
+	for _, d := range documents {
+		d.Expire(now)
+		if d.Status == entity.DocumentStatusApproved && d.ExpiresAt.After(lastExpiration[d.Type]) {
+			lastExpiration[d.Type] = d.ExpiresAt
+		}
+	}
+
+	var until time.Time
+	for i, t := range s.config.RequiredTypes {
+		expiresAt, ok := lastExpiration[t]
+		if !ok {
+			until = time.Time{}
+			break
+		}
+
+		if i == 0 || expiresAt.Before(until) {
+			until = expiresAt
+		}
+	}
+
+	return s.uService.UpdateVerifiedDriverUntil(userID, until)
+}
@@ -0,0 +1,385 @@
+package verification
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"azure.com/ecovo/user-service/pkg/entity"
+	"azure.com/ecovo/user-service/pkg/media"
+	"azure.com/ecovo/user-service/pkg/user"
+	"azure.com/ecovo/user-service/pkg/vehicule"
+)
+
+// A memoryStore is a store that keeps files in memory.
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(key string, contentType string, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s[key] = content
+
+	return nil
+}
+
+func (s memoryStore) Get(key string) (io.ReadCloser, error) {
+	content, ok := s[key]
+	if !ok {
+		return nil, media.NotFoundError{}
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s memoryStore) Delete(key string) error {
+	delete(s, key)
+
+	return nil
+}
+
+// pdf is the beginning of a PDF file, which is enough for its content type to
+// be detected.
+const pdf = "%PDF-1.4\n"
+
+type testServices struct {
+	s        *Service
+	uService *user.Service
+	vService *vehicule.Service
+	store    memoryStore
+	now      time.Time
+}
+
+func newTestServices(t *testing.T) (*testServices, *entity.User, *entity.User) {
+	uService := user.NewService(user.NewMemoryRepository())
+	register := func(subID string) *entity.User {
+		u, err := uService.Register(&entity.User{
+			SubID:       subID,
+			FirstName:   "Harold",
+			LastName:    "The Great",
+			DateOfBirth: time.Date(1950, time.February, 12, 0, 0, 0, 0, time.UTC),
+			Gender:      entity.GenderMale,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return u
+	}
+
+	ts := &testServices{
+		uService: uService,
+		vService: vehicule.NewService(vehicule.NewMemoryRepository(), uService, nil),
+		store:    make(memoryStore),
+		now:      time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	ts.s = NewService(NewMemoryRepository(), uService, ts.vService, ts.store, &Config{MaxFileSize: 64})
+	ts.s.now = func() time.Time { return ts.now }
+
+	return ts, register("harold|1"), register("maurice|1")
+}
+
+func (ts *testServices) upload(t *testing.T, u *entity.User, documentType string, expiresAt time.Time) *entity.Document {
+	d, err := ts.s.Upload(&entity.Document{UserID: u.ID, Type: documentType, ExpiresAt: expiresAt}, strings.NewReader(pdf), u.SubID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestServiceUpload(t *testing.T) {
+	t.Run("Should store the file and wait for a review", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		d := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+
+		if d.Status != entity.DocumentStatusPending || d.ContentType != "application/pdf" || d.Size != int64(len(pdf)) {
+			t.Errorf("unexpected document %+v", d)
+		}
+
+		f, err := ts.s.Open(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		content, _ := ioutil.ReadAll(f)
+		if string(content) != pdf {
+			t.Errorf("expected stored file %q, got %q", pdf, content)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		document func(harold *entity.User, maurice *entity.User) (*entity.Document, string)
+		file     string
+		check    func(err error) bool
+	}{
+		{
+			"Should fail when uploading a document for another user",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: maurice.ID, Type: entity.DocumentTypeDriversLicense}, harold.SubID
+			},
+			pdf,
+			func(err error) bool { _, ok := err.(ForbiddenError); return ok },
+		},
+		{
+			"Should fail when vehicule belongs to another user",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: harold.ID, VehiculeID: "5c8f9ddfdc5bda1a3c2a2f1b", Type: entity.DocumentTypeInsurance}, harold.SubID
+			},
+			pdf,
+			func(err error) bool { _, ok := err.(vehicule.NotFoundError); return ok },
+		},
+		{
+			"Should fail when file is empty",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: harold.ID, Type: entity.DocumentTypeDriversLicense}, harold.SubID
+			},
+			"",
+			func(err error) bool { _, ok := err.(MissingFileError); return ok },
+		},
+		{
+			"Should fail when file is too large",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: harold.ID, Type: entity.DocumentTypeDriversLicense}, harold.SubID
+			},
+			pdf + strings.Repeat("a", 64),
+			func(err error) bool { _, ok := err.(FileTooLargeError); return ok },
+		},
+		{
+			"Should fail when file is not a PDF or an image",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: harold.ID, Type: entity.DocumentTypeDriversLicense}, harold.SubID
+			},
+			"hide the pain",
+			func(err error) bool { _, ok := err.(UnsupportedContentTypeError); return ok },
+		},
+		{
+			"Should fail when document type is unknown",
+			func(harold *entity.User, maurice *entity.User) (*entity.Document, string) {
+				return &entity.Document{UserID: harold.ID, Type: "passport"}, harold.SubID
+			},
+			pdf,
+			func(err error) bool { _, ok := err.(entity.ValidationErrors); return ok },
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ts, harold, maurice := newTestServices(t)
+
+			d, subID := test.document(harold, maurice)
+			d.ExpiresAt = ts.now.AddDate(1, 0, 0)
+
+			_, err := ts.s.Upload(d, strings.NewReader(test.file), subID)
+			if !test.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			if len(ts.store) != 0 {
+				t.Errorf("expected no file to be stored, got %d", len(ts.store))
+			}
+		})
+	}
+
+	t.Run("Should fail when document already expired", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		_, err := ts.s.Upload(&entity.Document{UserID: harold.ID, Type: entity.DocumentTypeDriversLicense, ExpiresAt: ts.now}, strings.NewReader(pdf), harold.SubID)
+		if _, ok := err.(ExpiredError); !ok {
+			t.Errorf("expected ExpiredError, got %v", err)
+		}
+	})
+}
+
+func TestServiceReview(t *testing.T) {
+	// verifiedDriver derives whether the user is a verified driver at the
+	// test's time rather than the user service's.
+	verifiedDriver := func(t *testing.T, ts *testServices, u *entity.User) bool {
+		found, err := ts.uService.FindByID(u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found.RefreshVerifiedDriver(ts.now)
+
+		return found.VerifiedDriver
+	}
+
+	t.Run("Should verify driver once every required document is approved", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		license := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(2, 0, 0))
+		insurance := ts.upload(t, harold, entity.DocumentTypeInsurance, ts.now.AddDate(1, 0, 0))
+
+		if _, err := ts.s.Approve(license.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if verifiedDriver(t, ts, harold) {
+			t.Error("expected driver not to be verified until the insurance is approved")
+		}
+
+		if _, err := ts.s.Approve(insurance.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if !verifiedDriver(t, ts, harold) {
+			t.Error("expected driver to be verified")
+		}
+
+		ts.now = insurance.ExpiresAt
+		if verifiedDriver(t, ts, harold) {
+			t.Error("expected driver to stop being verified once the insurance expired")
+		}
+
+		documents, err := ts.s.FindByUserID(harold.ID, &user.Caller{SubID: harold.SubID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if documents[0].Status != entity.DocumentStatusExpired || documents[1].Status != entity.DocumentStatusApproved {
+			t.Errorf("expected only the insurance to be expired, got %s and %s", documents[0].Status, documents[1].Status)
+		}
+	})
+
+	t.Run("Should stop verifying driver when an approved document is rejected", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		license := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+		insurance := ts.upload(t, harold, entity.DocumentTypeInsurance, ts.now.AddDate(1, 0, 0))
+		for _, d := range []*entity.Document{license, insurance} {
+			if _, err := ts.s.Approve(d.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		d, err := ts.s.Reject(license.ID, "forged")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d.Status != entity.DocumentStatusRejected || d.RejectionReason != "forged" || !d.ReviewedAt.Equal(ts.now) {
+			t.Errorf("unexpected document %+v", d)
+		}
+
+		if verifiedDriver(t, ts, harold) {
+			t.Error("expected driver to stop being verified")
+		}
+	})
+
+	t.Run("Should fail when reviewing a rejected document", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		d := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+		if _, err := ts.s.Reject(d.ID, "blurry"); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := ts.s.Approve(d.ID)
+		if _, ok := err.(AlreadyReviewedError); !ok {
+			t.Errorf("expected AlreadyReviewedError, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when rejecting without a reason", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		d := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+
+		_, err := ts.s.Reject(d.ID, " ")
+		if _, ok := err.(entity.ValidationErrors); !ok {
+			t.Errorf("expected ValidationErrors, got %v", err)
+		}
+	})
+
+	t.Run("Should fail when approving an expired document", func(t *testing.T) {
+		ts, harold, _ := newTestServices(t)
+
+		d := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+		ts.now = d.ExpiresAt
+
+		_, err := ts.s.Approve(d.ID)
+		if _, ok := err.(ExpiredError); !ok {
+			t.Errorf("expected ExpiredError, got %v", err)
+		}
+	})
+}
+
+func TestServiceFind(t *testing.T) {
+	ts, harold, maurice := newTestServices(t)
+	d := ts.upload(t, harold, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0))
+
+	t.Run("Should fail when another user looks up the documents", func(t *testing.T) {
+		_, err := ts.s.FindByUserID(harold.ID, &user.Caller{SubID: maurice.SubID})
+		if _, ok := err.(ForbiddenError); !ok {
+			t.Errorf("expected ForbiddenError, got %v", err)
+		}
+	})
+
+	t.Run("Should let privileged callers look up the documents", func(t *testing.T) {
+		found, err := ts.s.FindByID(d.ID, harold.ID, &user.Caller{Privileged: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found.FileKey != d.FileKey {
+			t.Errorf("expected document %+v, got %+v", d, found)
+		}
+	})
+
+	t.Run("Should fail when document belongs to another user", func(t *testing.T) {
+		_, err := ts.s.FindByID(d.ID, maurice.ID, &user.Caller{SubID: maurice.SubID})
+		if _, ok := err.(NotFoundError); !ok {
+			t.Errorf("expected NotFoundError, got %v", err)
+		}
+	})
+}
+
+func TestServiceSearch(t *testing.T) {
+	ts, harold, maurice := newTestServices(t)
+
+	var pending []*entity.Document
+	for _, u := range []*entity.User{harold, maurice, harold} {
+		pending = append(pending, ts.upload(t, u, entity.DocumentTypeDriversLicense, ts.now.AddDate(1, 0, 0)))
+	}
+	expired := ts.upload(t, maurice, entity.DocumentTypeInsurance, ts.now.Add(time.Hour))
+	ts.now = expired.ExpiresAt
+
+	p, err := ts.s.Search(&Query{Status: entity.DocumentStatusPending, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Documents) != 2 || p.Documents[0].ID != pending[0].ID || p.Next != pending[1].ID.Hex() {
+		t.Fatalf("unexpected first page %+v", p)
+	}
+
+	p, err = ts.s.Search(&Query{Status: entity.DocumentStatusPending, Limit: 2, After: p.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Documents) != 1 || p.Documents[0].ID != pending[2].ID || p.Next != "" {
+		t.Fatalf("unexpected last page %+v", p)
+	}
+
+	p, err = ts.s.Search(&Query{Status: entity.DocumentStatusExpired})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Documents) != 1 || p.Documents[0].ID != expired.ID {
+		t.Errorf("expected only the expired document, got %+v", p)
+	}
+
+	_, err = ts.s.Search(&Query{Status: "lost"})
+	if _, ok := err.(InvalidQueryError); !ok {
+		t.Errorf("expected InvalidQueryError, got %v", err)
+	}
+}